@@ -1,18 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/factory"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/middlewares"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/migrations"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/trash"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/user"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/reminder"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/clock"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/oidc"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -25,7 +37,12 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if err = createSchema(db); err != nil {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(db, os.Args[2:])
+		return
+	}
+
+	if err = migrateDatabase(db); err != nil {
 		log.Panic(err)
 	}
 
@@ -34,49 +51,225 @@ func main() {
 	userService := factory.NewUserService(userRepository)
 	userHandler := factory.NewUserHandler(userService)
 
+	// Init authorization module
+	authorizationRepository := factory.NewAuthorizationRepository(db)
+	authorizationService := factory.NewAuthorizationService(authorizationRepository)
+
 	// Init list module
 	listRepository := factory.NewListRepository(db)
-	listService := factory.NewListService(listRepository)
+	listService := factory.NewListService(listRepository, authorizationService)
 	listHandler := factory.NewListHandler(listService)
 
+	// Init member module
+	memberRepository := factory.NewMemberRepository(db)
+	memberService := factory.NewMemberService(memberRepository, authorizationService, userRepository)
+	memberHandler := factory.NewMemberHandler(memberService)
+
+	// Init field module
+	fieldRepository := factory.NewFieldRepository(db)
+	fieldService := factory.NewFieldService(fieldRepository, authorizationService)
+	fieldHandler := factory.NewFieldHandler(fieldService)
+
+	// Init workflow module
+	workflowRepository := factory.NewWorkflowRepository(db)
+	workflowService := factory.NewWorkflowService(workflowRepository, authorizationService)
+	workflowHandler := factory.NewWorkflowHandler(workflowService)
+
 	// Init item module
 	itemRepository := factory.NewItemRepository(db)
-	itemService := factory.NewItemService(itemRepository, listRepository, userRepository)
+	itemService := factory.NewItemService(
+		itemRepository, authorizationService, userRepository, fieldRepository, workflowRepository, clock.New(),
+	)
 	itemHandler := factory.NewItemHandler(itemService)
 
+	// Init tag module
+	tagRepository := factory.NewTagRepository(db)
+	tagService := factory.NewTagService(tagRepository, itemRepository, authorizationService)
+	tagHandler := factory.NewTagHandler(tagService)
+
+	// Init trash module
+	trashRepository := factory.NewTrashRepository(db)
+	trashService := factory.NewTrashService(trashRepository, authorizationService)
+	trashHandler := factory.NewTrashHandler(trashService)
+
+	// Init SSO application module
+	ssoRepository := factory.NewSSORepository(db)
+	ssoService := factory.NewSSOService(ssoRepository)
+	ssoHandler := factory.NewSSOHandler(ssoService)
+
 	// Init auth module
-	authService := factory.NewAuthService(userRepository)
+	oidcConfig, err := newOIDCConfig()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	mfaConfig, err := newMFAConfig()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	authRepository := factory.NewAuthRepository(db)
+	authService := factory.NewAuthService(userRepository, authRepository, ssoRepository, oidcConfig, mfaConfig, clock.New())
 	authHandler := factory.NewAuthHandler(authService)
 
 	createAdminUser(userRepository)
 
 	router := gin.Default()
+	if err = router.SetTrustedProxies(getTrustedProxies()); err != nil {
+		log.Panic(err)
+	}
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
 	routeGroup := router.Group("/api/v1") // TODO versionize me!!
 
 	// Auth routes
 	routeGroup.POST("/authenticate", authHandler.Authenticate)
 	routeGroup.POST("/authenticate/sso", authHandler.AuthenticateSSO)
+	newPublicEndpoint(routeGroup, http.MethodGet, "/authenticate/oidc", authHandler.StartOIDCLogin)
+	routeGroup.GET("/authenticate/oidc/callback", authHandler.AuthenticateOIDC)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/authenticate/oidc/link", authHandler.ConfirmOIDCLink)
+	routeGroup.POST("/authenticate/mfa", authHandler.VerifyMFA)
+	routeGroup.POST("/authenticate/mfa/enroll", authHandler.EnrollMFAChallenge)
+	routeGroup.POST("/authenticate/refresh", authHandler.Refresh)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/logout", authHandler.Logout)
+
+	// MFA routes
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/mfa/enroll", authHandler.EnrollMFA)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/mfa/confirm", authHandler.ConfirmMFA)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+	newPrivateEndpoint(routeGroup, http.MethodDelete, "/mfa", authHandler.DisableMFA)
 
 	// User routes
-	newPrivateEndpoint(routeGroup, http.MethodPost, "/users", userHandler.Save)
-	newPrivateEndpoint(routeGroup, http.MethodGet, "/users/:id", userHandler.Get)
-	newPrivateEndpoint(routeGroup, http.MethodPut, "/users/:id", userHandler.Update)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/users", middlewares.RequireRole(models.UserRoleAdmin), userHandler.Save)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/users", middlewares.RequireRole(models.UserRoleAdmin), userHandler.GetAll)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/users/:id", middlewares.RequireSelfOrRole("id", models.UserRoleAdmin), userHandler.Get)
+	newPrivateEndpoint(routeGroup, http.MethodPut, "/users/:id", middlewares.RequireSelfOrRole("id", models.UserRoleAdmin), userHandler.Update)
+	newPrivateEndpoint(routeGroup, http.MethodDelete, "/users/:id/mfa", middlewares.RequireRole(models.UserRoleAdmin), authHandler.ResetMFA)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/users/:id/unlock", middlewares.RequireRole(models.UserRoleAdmin), authHandler.UnlockLogin)
+
+	// SSO application routes
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/sso/applications", middlewares.RequireRole(models.UserRoleAdmin), ssoHandler.Save)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/sso/applications", middlewares.RequireRole(models.UserRoleAdmin), ssoHandler.GetAll)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/sso/applications/:app_id", middlewares.RequireRole(models.UserRoleAdmin), ssoHandler.Get)
+	newPrivateEndpoint(routeGroup, http.MethodPut, "/sso/applications/:app_id", middlewares.RequireRole(models.UserRoleAdmin), ssoHandler.Update)
+	newPrivateEndpoint(routeGroup, http.MethodDelete, "/sso/applications/:app_id", middlewares.RequireRole(models.UserRoleAdmin), ssoHandler.Delete)
 
 	// List routes
 	newPublicEndpoint(routeGroup, http.MethodPost, "/lists", listHandler.Save)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/lists", listHandler.GetAll)
 	newPublicEndpoint(routeGroup, http.MethodGet, "/lists/:list_id", listHandler.Get)
+	newPrivateEndpoint(routeGroup, http.MethodPut, "/lists/:list_id", listHandler.Update)
+	newPrivateEndpoint(routeGroup, http.MethodPatch, "/lists/:list_id", listHandler.Patch)
 	newPrivateEndpoint(routeGroup, http.MethodDelete, "/lists/:list_id", listHandler.Delete)
 
+	// Member routes
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/members", memberHandler.Add)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/lists/:list_id/members", memberHandler.GetAll)
+	newPrivateEndpoint(routeGroup, http.MethodPut, "/lists/:list_id/members/:user_id", memberHandler.Update)
+	newPrivateEndpoint(routeGroup, http.MethodDelete, "/lists/:list_id/members/:user_id", memberHandler.Delete)
+
+	// Field routes
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/fields", fieldHandler.Save)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/lists/:list_id/fields", fieldHandler.GetByList)
+	newPrivateEndpoint(routeGroup, http.MethodPut, "/lists/:list_id/fields/:field_id", fieldHandler.Update)
+	newPrivateEndpoint(routeGroup, http.MethodDelete, "/lists/:list_id/fields/:field_id", fieldHandler.Delete)
+
+	// Workflow routes
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/statuses", workflowHandler.Save)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/lists/:list_id/statuses", workflowHandler.GetByList)
+	newPrivateEndpoint(routeGroup, http.MethodPut, "/lists/:list_id/statuses/order", workflowHandler.Reorder)
+	newPrivateEndpoint(routeGroup, http.MethodPut, "/lists/:list_id/statuses/:status_id", workflowHandler.Update)
+	newPrivateEndpoint(routeGroup, http.MethodDelete, "/lists/:list_id/statuses/:status_id", workflowHandler.Delete)
+
 	// Item routes
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/items/next", itemHandler.NextUp)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/items/assigned", itemHandler.Assigned)
 	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/items", itemHandler.Save)
 	newPrivateEndpoint(routeGroup, http.MethodGet, "/lists/:list_id/items", itemHandler.GetByList)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/lists/:list_id/board", itemHandler.Board)
 	newPrivateEndpoint(routeGroup, http.MethodPut, "/lists/:list_id/items/:item_id", itemHandler.Update)
 	newPrivateEndpoint(routeGroup, http.MethodDelete, "/lists/:list_id/items/:item_id", itemHandler.Delete)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/items/:item_id/complete", itemHandler.Complete)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/items/:item_id/reopen", itemHandler.Reopen)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/lists/:list_id/items/:item_id/occurrences", itemHandler.GetOccurrences)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/items/:item_id/assignees", itemHandler.Assign)
+	newPrivateEndpoint(routeGroup, http.MethodDelete, "/lists/:list_id/items/:item_id/assignees/:user_id", itemHandler.Unassign)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/items/:item_id/watch", itemHandler.Watch)
+	newPrivateEndpoint(routeGroup, http.MethodDelete, "/lists/:list_id/items/:item_id/watch", itemHandler.Unwatch)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/items/:item_id/move", itemHandler.Move)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/items/:item_id/transfer", itemHandler.Transfer)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/items/:item_id/copy", itemHandler.Copy)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/items/transfer", itemHandler.TransferMany)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/items/copy", itemHandler.CopyMany)
+
+	// Tag routes
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/tags", tagHandler.Save)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/tags", tagHandler.GetAll)
+	newPrivateEndpoint(routeGroup, http.MethodPut, "/tags/:tag_id", tagHandler.Update)
+	newPrivateEndpoint(routeGroup, http.MethodDelete, "/tags/:tag_id", tagHandler.Delete)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/tags/:tag_id/merge", tagHandler.Merge)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/lists/:list_id/tags", tagHandler.SaveOnList)
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/lists/:list_id/tags", tagHandler.GetByList)
+	newPrivateEndpoint(routeGroup, http.MethodPut, "/lists/:list_id/items/:item_id/tags", tagHandler.SetItemTags)
+
+	// Trash routes
+	newPrivateEndpoint(routeGroup, http.MethodGet, "/trash", trashHandler.Get)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/trash/lists/:list_id/restore", trashHandler.RestoreList)
+	newPrivateEndpoint(routeGroup, http.MethodPost, "/trash/items/:item_id/restore", trashHandler.RestoreItem)
+
+	keyRotator, err := initJWTAuth(authRepository)
+	if err != nil {
+		log.Panic(err)
+	}
+	keyRotator.Start()
+
+	reminderScheduler, err := newReminderScheduler(db)
+	if err != nil {
+		log.Panic(err)
+	}
+	reminderScheduler.Start()
+
+	trashPurger, err := newTrashPurger(trashRepository)
+	if err != nil {
+		log.Panic(err)
+	}
+	trashPurger.Start()
+
+	shutdownTimeout, err := getDurationFromEnv("SHUTDOWN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	serve(router, shutdownTimeout)
 
-	auth.InitJWTAuth()
+	trashPurger.Stop()
+	reminderScheduler.Stop()
+	keyRotator.Stop()
+	log.Println("Server stopped")
+}
+
+// serve handles the requests until the process is interrupted or terminated,
+// then waits up to shutdownTimeout for the requests in progress.
+func serve(router *gin.Engine, shutdownTimeout time.Duration) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: fmt.Sprintf("0.0.0.0:%s", getRunningPort()), Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down server")
 
-	port := getRunningPort()
-	router.Run(fmt.Sprintf("0.0.0.0:%s", port))
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down server: %s\n", err.Error())
+	}
 }
 
 func initDatabase() (*gorm.DB, error) {
@@ -90,48 +283,273 @@ func initDatabase() (*gorm.DB, error) {
 		panic("failed to connect database")
 	}
 
-	db.AutoMigrate(
-		&models.User{},
-		&models.List{},
-		&models.Item{},
-	)
-
 	return db, nil
 }
 
-func createSchema(db *gorm.DB) error {
+func newMigrator(db *gorm.DB) (migrations.Migrator, error) {
 	path := os.Getenv("APP_PATH")
-	return runSQLFromFile(db, path+"/resources/database/up.sql")
+	loaded, err := migrations.Load(path + "/resources/database/migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return migrations.NewMigrator(db, loaded), nil
 }
 
-func runSQLFromFile(db *gorm.DB, file string) error {
-	schema, err := ioutil.ReadFile(file)
+func migrateDatabase(db *gorm.DB) error {
+	migrator, err := newMigrator(db)
 	if err != nil {
 		return err
 	}
 
-	statements := strings.Split(string(schema), ";")
+	applied, err := migrator.Up()
+	if err != nil {
+		return err
+	}
 
-	for _, statement := range statements {
-		command := strings.Join(strings.Fields(statement), " ")
-		if command == "" {
-			continue
+	log.Printf("Database schema up to date (%d migrations applied)\n", applied)
+	return nil
+}
+
+// runMigrateCommand handles "migrate up", "migrate down [steps]" and
+// "migrate status".
+func runMigrateCommand(db *gorm.DB, args []string) {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d migrations applied\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatalf("invalid number of steps: %s", args[1])
+			}
 		}
 
-		tx := db.Exec(command)
-		if tx.Error == nil {
-			continue
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d migrations reverted\n", reverted)
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
 		}
 
-		// Error: 1065 = Query was empty
-		if strings.Contains(tx.Error.Error(), "Error 1065") {
+		for _, migration := range status {
+			state := "pending"
+			if migration.Applied {
+				state = "applied at " + migration.AppliedAt.Format(time.RFC3339)
+			}
+			if migration.Modified {
+				state += " (modified)"
+			}
+			fmt.Printf("%04d_%s: %s\n", migration.Version, migration.Name, state)
+		}
+	default:
+		log.Fatalf("unknown migrate command %q, expected up, down or status", command)
+	}
+}
+
+// initJWTAuth configures how the access tokens are signed, from JWT_ALGORITHM
+// (HS256, RS256, ES256 or EdDSA), JWT_KEY_ROTATION, JWT_KEY_GRACE,
+// JWT_KEY_SECRET, which encrypts the stored private keys, and
+// JWT_LEGACY_HS256_UNTIL, the deadline of the HS256 tokens after switching
+// from HS256. It returns the rotator of the signing keys, run every
+// JWT_KEY_CHECK_INTERVAL.
+func initJWTAuth(repository auth.Repository) (auth.KeyRotator, error) {
+	rotation, err := getDurationFromEnv("JWT_KEY_ROTATION", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	grace, err := getDurationFromEnv("JWT_KEY_GRACE", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := getDurationFromEnv("JWT_KEY_CHECK_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every instance must load the next key before it starts signing.
+	if interval >= grace {
+		return nil, errors.New("JWT_KEY_CHECK_INTERVAL must be shorter than JWT_KEY_GRACE")
+	}
+
+	legacyUntil, err := getTimeFromEnv("JWT_LEGACY_HS256_UNTIL")
+	if err != nil {
+		return nil, err
+	}
+
+	config := auth.KeyConfig{
+		Algorithm:   os.Getenv("JWT_ALGORITHM"),
+		Rotation:    rotation,
+		Grace:       grace,
+		Secret:      []byte(os.Getenv("JWT_KEY_SECRET")),
+		LegacyUntil: legacyUntil,
+	}
+	if err = auth.InitJWTAuth(repository, config, clock.New()); err != nil {
+		return nil, err
+	}
+
+	return auth.NewKeyRotator(interval), nil
+}
+
+// newOIDCConfig enables the login with the OpenID Connect provider of
+// OIDC_ISSUER, when it is set.
+func newOIDCConfig() (*auth.OIDCConfig, error) {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil, nil
+	}
+
+	config := oidc.Config{
+		Issuer:       issuer,
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       strings.Fields(os.Getenv("OIDC_SCOPES")),
+	}
+
+	if config.ClientID == "" || config.RedirectURL == "" {
+		return nil, errors.New("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required with OIDC_ISSUER")
+	}
+
+	createUsers, err := getBoolFromEnv("OIDC_CREATE_USERS", true)
+	if err != nil {
+		return nil, err
+	}
+
+	linkVerifiedEmail, err := getBoolFromEnv("OIDC_LINK_VERIFIED_EMAIL", false)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &auth.OIDCConfig{
+		RelyingParty:      oidc.NewRelyingParty(config, client, clock.New()),
+		CreateUsers:       createUsers,
+		LinkVerifiedEmail: linkVerifiedEmail,
+	}, nil
+}
+
+// newMFAConfig reads the name shown in the authenticator apps, MFA_ISSUER,
+// the roles that must enable MFA, MFA_REQUIRED_ROLES, separated by commas,
+// and the secret that keys the hashes of the recovery codes,
+// MFA_RECOVERY_SECRET.
+func newMFAConfig() (auth.MFAConfig, error) {
+	config := auth.MFAConfig{Issuer: os.Getenv("MFA_ISSUER")}
+	if config.Issuer == "" {
+		config.Issuer = "List Manager"
+	}
+
+	config.RecoverySecret = []byte(os.Getenv("MFA_RECOVERY_SECRET"))
+	if len(config.RecoverySecret) < constants.MinMFARecoverySecretSize {
+		return config, fmt.Errorf("MFA_RECOVERY_SECRET must have at least %d characters", constants.MinMFARecoverySecretSize)
+	}
+
+	for _, value := range strings.Split(os.Getenv("MFA_REQUIRED_ROLES"), ",") {
+		role := models.UserRole(strings.TrimSpace(value))
+		switch role {
+		case "":
 			continue
+		case models.UserRoleAdmin, models.UserRoleMember, models.UserRoleService:
+			config.RequiredRoles = append(config.RequiredRoles, role)
+		default:
+			return config, fmt.Errorf("invalid MFA_REQUIRED_ROLES: unknown role %s", role)
 		}
+	}
 
-		return err
+	return config, nil
+}
+
+func newReminderScheduler(db *gorm.DB) (reminder.Scheduler, error) {
+	notifier, err := reminder.NewNotifierFromEnv()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	interval, err := getDurationFromEnv("REMINDER_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := reminder.NewRepository(db)
+	return reminder.NewScheduler(repository, notifier, clock.New(), interval), nil
+}
+
+func newTrashPurger(repository trash.Repository) (trash.Purger, error) {
+	retention, err := getDurationFromEnv("TRASH_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := getDurationFromEnv("TRASH_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return trash.NewPurger(repository, clock.New(), retention, interval), nil
+}
+
+func getDurationFromEnv(name string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return duration, nil
+}
+
+// getTimeFromEnv reads an optional RFC 3339 time, zero when it is not set.
+func getTimeFromEnv(name string) (time.Time, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return parsed, nil
+}
+
+func getBoolFromEnv(name string, defaultValue bool) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return parsed, nil
 }
 
 func createAdminUser(userRepository user.Repository) {
@@ -140,36 +558,32 @@ func createAdminUser(userRepository user.Repository) {
 		Email:    "admin@admin.com",
 		Login:    "admin",
 		Password: "admin",
+		Role:     models.UserRoleAdmin,
 	}
 
 	admin.HashPassword()
 	_ = userRepository.Save(&admin)
 }
 
-func newPrivateEndpoint(routeGroup *gin.RouterGroup, httpMethod string, endpoint string, handler gin.HandlerFunc) {
-	switch httpMethod {
-	case http.MethodPost:
-		routeGroup.POST(endpoint, middlewares.Authenticate(), handler)
-	case http.MethodGet:
-		routeGroup.GET(endpoint, middlewares.Authenticate(), handler)
-	case http.MethodPut:
-		routeGroup.PUT(endpoint, middlewares.Authenticate(), handler)
-	case http.MethodDelete:
-		routeGroup.DELETE(endpoint, middlewares.Authenticate(), handler)
-	}
+func newPrivateEndpoint(routeGroup *gin.RouterGroup, httpMethod string, endpoint string, handlers ...gin.HandlerFunc) {
+	routeGroup.Handle(httpMethod, endpoint, append([]gin.HandlerFunc{middlewares.Authenticate()}, handlers...)...)
+}
+
+func newPublicEndpoint(routeGroup *gin.RouterGroup, httpMethod string, endpoint string, handlers ...gin.HandlerFunc) {
+	routeGroup.Handle(httpMethod, endpoint, append([]gin.HandlerFunc{middlewares.PublicAuthenticate()}, handlers...)...)
 }
 
-func newPublicEndpoint(routeGroup *gin.RouterGroup, httpMethod string, endpoint string, handler gin.HandlerFunc) {
-	switch httpMethod {
-	case http.MethodPost:
-		routeGroup.POST(endpoint, middlewares.PublicAuthenticate(), handler)
-	case http.MethodGet:
-		routeGroup.GET(endpoint, middlewares.PublicAuthenticate(), handler)
-	case http.MethodPut:
-		routeGroup.PUT(endpoint, middlewares.PublicAuthenticate(), handler)
-	case http.MethodDelete:
-		routeGroup.DELETE(endpoint, middlewares.PublicAuthenticate(), handler)
+// getTrustedProxies returns the proxies, in TRUSTED_PROXIES separated by
+// commas, whose X-Forwarded-For header tells the client IP. By default no
+// proxy is trusted, so the header cannot be forged to evade the login limits.
+func getTrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
 	}
+	return proxies
 }
 
 func getRunningPort() string {
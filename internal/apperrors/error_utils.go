@@ -13,6 +13,8 @@ func HandleServiceError(c *gin.Context, err error) {
 		c.IndentedJSON(http.StatusNotFound, models.NewHttpError(err))
 	case *ObjectInInvalidStateError:
 		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+	case *ForbiddenError:
+		c.IndentedJSON(http.StatusForbidden, models.NewHttpError(err))
 	case *LoginAlreadyRegistered:
 		c.IndentedJSON(http.StatusConflict, models.NewHttpError(err))
 	default:
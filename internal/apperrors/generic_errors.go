@@ -1,6 +1,10 @@
 package apperrors
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"time"
+)
 
 type (
 	NotFoundError struct {
@@ -22,6 +26,20 @@ type (
 	LoginAlreadyRegistered struct {
 		msg string
 	}
+
+	ForbiddenError struct {
+		msg string
+	}
+
+	TooManyAttemptsError struct {
+		msg        string
+		retryAfter time.Duration
+	}
+
+	AccountLockedError struct {
+		msg        string
+		retryAfter time.Duration
+	}
 )
 
 func NewNotFoundError(entity string, id uint64) error {
@@ -54,10 +72,38 @@ func NewUserSSOLoginError() error {
 	return &UserLoginError{msg: "Invalid SSO login or token."}
 }
 
+func NewUserOIDCLoginError() error {
+	return &UserLoginError{msg: "Invalid or expired OIDC login."}
+}
+
+func NewInvalidMFAChallengeError() error {
+	return &UserLoginError{msg: "Invalid or expired MFA challenge."}
+}
+
+func NewInvalidMFACodeError() error {
+	return &UserLoginError{msg: "Invalid MFA code."}
+}
+
+func NewInvalidRefreshTokenError() error {
+	return &UserLoginError{msg: "Invalid or expired refresh token."}
+}
+
 func NewLoginAlreadyRegisteredError(login string) error {
 	return LoginAlreadyRegistered{msg: fmt.Sprintf("Login %s already in use.", login)}
 }
 
+func NewForbiddenError(msg string) error {
+	return &ForbiddenError{msg}
+}
+
+func NewTooManyAttemptsError(retryAfter time.Duration) error {
+	return &TooManyAttemptsError{msg: "Too many failed logins, try again later.", retryAfter: retryAfter}
+}
+
+func NewAccountLockedError(retryAfter time.Duration) error {
+	return &AccountLockedError{msg: "Login temporarily locked after too many failed logins.", retryAfter: retryAfter}
+}
+
 func (e NotFoundError) Error() string {
 	return e.msg
 }
@@ -77,3 +123,29 @@ func (e UserLoginError) Error() string {
 func (e LoginAlreadyRegistered) Error() string {
 	return e.msg
 }
+
+func (e ForbiddenError) Error() string {
+	return e.msg
+}
+
+func (e TooManyAttemptsError) Error() string {
+	return e.msg
+}
+
+// RetryAfterSeconds is the value of the Retry-After header.
+func (e TooManyAttemptsError) RetryAfterSeconds() int {
+	return retryAfterSeconds(e.retryAfter)
+}
+
+func (e AccountLockedError) Error() string {
+	return e.msg
+}
+
+// RetryAfterSeconds is the value of the Retry-After header.
+func (e AccountLockedError) RetryAfterSeconds() int {
+	return retryAfterSeconds(e.retryAfter)
+}
+
+func retryAfterSeconds(duration time.Duration) int {
+	return int(math.Max(1, math.Ceil(duration.Seconds())))
+}
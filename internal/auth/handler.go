@@ -2,10 +2,14 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,6 +17,20 @@ type (
 	Handler interface {
 		Authenticate(c *gin.Context)
 		AuthenticateSSO(c *gin.Context)
+		StartOIDCLogin(c *gin.Context)
+		AuthenticateOIDC(c *gin.Context)
+		ConfirmOIDCLink(c *gin.Context)
+		VerifyMFA(c *gin.Context)
+		EnrollMFAChallenge(c *gin.Context)
+		EnrollMFA(c *gin.Context)
+		ConfirmMFA(c *gin.Context)
+		RegenerateRecoveryCodes(c *gin.Context)
+		DisableMFA(c *gin.Context)
+		ResetMFA(c *gin.Context)
+		UnlockLogin(c *gin.Context)
+		Refresh(c *gin.Context)
+		Logout(c *gin.Context)
+		JWKS(c *gin.Context)
 	}
 
 	handler struct {
@@ -31,6 +49,7 @@ func (h handler) Authenticate(c *gin.Context) {
 		return
 	}
 
+	authRequest.ClientIP = c.ClientIP()
 	authResponse, err := h.service.Authenticate(authRequest)
 	if err != nil {
 		h.handleAuthError(c, err)
@@ -56,12 +75,273 @@ func (h handler) AuthenticateSSO(c *gin.Context) {
 	c.IndentedJSON(http.StatusOK, authResponse)
 }
 
+// StartOIDCLogin returns the URL where the user logs in at the OpenID Connect
+// provider. A logged user links the provider identity to the account instead.
+// The state of the login is kept in a cookie, so only this browser finishes it.
+func (h handler) StartOIDCLogin(c *gin.Context) {
+	var userID *uint64
+	value, _ := c.Get(constants.CtxClaimsKey)
+	if claims, ok := value.(*JWTClaim); ok {
+		userID = &claims.ID
+	}
+
+	authorization, err := h.service.StartOIDCLogin(userID)
+	if err != nil {
+		h.handleAuthError(c, err)
+		return
+	}
+
+	setOIDCStateCookie(c, authorization.State, int(constants.OIDCLoginLifetime.Seconds()))
+	c.IndentedJSON(http.StatusOK, authorization)
+}
+
+// AuthenticateOIDC is the redirect URL of the OpenID Connect provider.
+func (h handler) AuthenticateOIDC(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		err := fmt.Errorf("OIDC login refused by the provider: %s", providerError)
+		c.IndentedJSON(http.StatusUnauthorized, models.NewHttpError(err))
+		return
+	}
+
+	var authRequest models.AuthRequestOIDC
+	if err := c.BindQuery(&authRequest); err != nil || authRequest.Code == "" || authRequest.State == "" {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("missing code or state")))
+		return
+	}
+
+	authRequest.BrowserState, _ = c.Cookie(constants.OIDCStateCookie)
+	setOIDCStateCookie(c, "", -1)
+
+	authResponse, err := h.service.AuthenticateOIDC(&authRequest)
+	if err != nil {
+		h.handleAuthError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, authResponse)
+}
+
+// ConfirmOIDCLink links the provider identity of a linking login to the
+// logged user.
+func (h handler) ConfirmOIDCLink(c *gin.Context) {
+	var linkRequest models.OIDCLinkRequest
+	if err := c.BindJSON(&linkRequest); err != nil || linkRequest.LinkToken == "" {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("invalid body request format")))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+	if err := h.service.ConfirmOIDCLink(userID, &linkRequest); err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// setOIDCStateCookie keeps the state of the OIDC login in the browser. The
+// cookie is sent back on the redirect of the provider, but not to other sites
+// nor to scripts. A negative maxAge clears it.
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(constants.OIDCStateCookie, state, maxAge, "/", "", secure, true)
+}
+
+// VerifyMFA finishes a login that requires the second factor.
+func (h handler) VerifyMFA(c *gin.Context) {
+	var authRequest models.AuthRequestMFA
+	if err := c.BindJSON(&authRequest); err != nil || authRequest.MFAToken == "" ||
+		(authRequest.Code == "") == (authRequest.RecoveryCode == "") {
+		err := errors.New("mfa_token and either code or recovery_code are required")
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	authRequest.ClientIP = c.ClientIP()
+	authResponse, err := h.service.VerifyMFA(&authRequest)
+	if err != nil {
+		h.handleAuthError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, authResponse)
+}
+
+// EnrollMFAChallenge creates the secret of a user that must enable MFA to
+// finish the login.
+func (h handler) EnrollMFAChallenge(c *gin.Context) {
+	var authRequest models.AuthRequestMFA
+	if err := c.BindJSON(&authRequest); err != nil || authRequest.MFAToken == "" {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("mfa_token is required")))
+		return
+	}
+
+	enrollment, err := h.service.EnrollMFAChallenge(&authRequest)
+	if err != nil {
+		h.handleAuthError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, enrollment)
+}
+
+func (h handler) EnrollMFA(c *gin.Context) {
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	enrollment, err := h.service.EnrollMFA(userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, enrollment)
+}
+
+func (h handler) ConfirmMFA(c *gin.Context) {
+	codeRequest, err := getMFACodeBodyRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+	codes, err := h.service.ConfirmMFA(userID, codeRequest)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, codes)
+}
+
+func (h handler) RegenerateRecoveryCodes(c *gin.Context) {
+	codeRequest, err := getMFACodeBodyRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+	codes, err := h.service.RegenerateRecoveryCodes(userID, codeRequest)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, codes)
+}
+
+func (h handler) DisableMFA(c *gin.Context) {
+	var codeRequest models.MFACodeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&codeRequest); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("invalid body request format")))
+			return
+		}
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+	if err := h.service.DisableMFA(userID, &codeRequest); err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h handler) ResetMFA(c *gin.Context) {
+	id, err := utils.GetIDFromRequest(c, "id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	if err = h.service.ResetMFA(id); err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UnlockLogin lets a user locked after too many failed logins try again.
+func (h handler) UnlockLogin(c *gin.Context) {
+	id, err := utils.GetIDFromRequest(c, "id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	actorID := c.GetUint64(constants.CtxUserKey)
+	if err = h.service.UnlockLogin(actorID, id); err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h handler) Refresh(c *gin.Context) {
+	var refreshRequest models.AuthRequestRefresh
+	if err := c.BindJSON(&refreshRequest); err != nil || refreshRequest.RefreshToken == "" {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("invalid body request format")))
+		return
+	}
+
+	authResponse, err := h.service.Refresh(&refreshRequest)
+	if err != nil {
+		h.handleAuthError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, authResponse)
+}
+
+func (h handler) Logout(c *gin.Context) {
+	var logoutRequest models.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&logoutRequest); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("invalid body request format")))
+			return
+		}
+	}
+
+	value, _ := c.Get(constants.CtxClaimsKey)
+	claims, ok := value.(*JWTClaim)
+	if !ok {
+		c.IndentedJSON(http.StatusUnauthorized, models.NewHttpError(errors.New("missing Authorization token")))
+		return
+	}
+
+	err := h.service.Logout(claims, &logoutRequest)
+	if err != nil {
+		h.handleAuthError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// JWKS publishes the keys that verify the access tokens. The next key is
+// published ahead of its use, so verifiers may cache the response.
+func (h handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(constants.JWKSCacheTime.Seconds())))
+	c.JSON(http.StatusOK, h.service.PublicKeys())
+}
+
 func (h handler) handleAuthError(c *gin.Context, err error) {
-	switch err.(type) {
+	switch e := err.(type) {
 	case *apperrors.UserLoginError:
 		c.IndentedJSON(http.StatusUnauthorized, models.NewHttpError(err))
+	case *apperrors.TooManyAttemptsError:
+		c.Header("Retry-After", strconv.Itoa(e.RetryAfterSeconds()))
+		c.IndentedJSON(http.StatusTooManyRequests, models.NewHttpError(err))
+	case *apperrors.AccountLockedError:
+		c.Header("Retry-After", strconv.Itoa(e.RetryAfterSeconds()))
+		c.IndentedJSON(http.StatusLocked, models.NewHttpError(err))
 	default:
-		c.IndentedJSON(http.StatusInternalServerError, models.NewHttpError(err))
+		apperrors.HandleServiceError(c, err)
 	}
 }
 
@@ -75,6 +355,16 @@ func getAuthBodyRequest(c *gin.Context) (*models.AuthRequest, error) {
 	return &authRequest, nil
 }
 
+func getMFACodeBodyRequest(c *gin.Context) (*models.MFACodeRequest, error) {
+	var codeRequest models.MFACodeRequest
+
+	if err := c.BindJSON(&codeRequest); err != nil || codeRequest.Code == "" {
+		return nil, errors.New("code is required")
+	}
+
+	return &codeRequest, nil
+}
+
 func getAuthSSOBodyRequest(c *gin.Context) (*models.AuthRequestSSO, error) {
 	var authRequest models.AuthRequestSSO
 
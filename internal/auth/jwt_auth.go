@@ -1,38 +1,88 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"log"
 	"os"
+	"sync"
 	"time"
 
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/clock"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
 	"github.com/dgrijalva/jwt-go"
 )
 
-var jwtKey = []byte("supersecretkey")
+var (
+	jwtKey        = []byte("supersecretkey")
+	signingKeys   *keyring
+	revokedTokens *revocationCache
+	legacyUntil   time.Time
+)
+
+// revocationCache keeps the ids of the revoked access tokens in memory,
+// reloaded every constants.RevokedTokensCacheTime, so validating a token does
+// not query the database on every request. A token revoked by another
+// instance is accepted here until the next reload.
+type revocationCache struct {
+	repository Repository
+	clock      clock.Clock
+	mutex      sync.RWMutex
+	jtis       map[string]bool
+	loadedAt   time.Time
+}
 
 type JWTClaim struct {
-	ID    uint64 `json:"id"`
-	Login string `json:"login"`
-	Email string `json:"email"`
+	ID    uint64          `json:"id"`
+	Login string          `json:"login"`
+	Email string          `json:"email"`
+	Role  models.UserRole `json:"role"`
 	jwt.StandardClaims
 }
 
 func GenerateJWT(user *models.User) (string, error) {
-	expirationTime := time.Now().Add(constants.TokenExpirationTime)
+	jti, err := generateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expirationTime := now.Add(constants.TokenExpirationTime)
 	claims := &JWTClaim{
 		ID:    user.ID,
 		Email: user.Email,
 		Login: user.Login,
+		Role:  user.Role,
 		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			IssuedAt:  now.Unix(),
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
-	return tokenString, err
+	if signingKeys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(jwtKey)
+	}
+
+	key := signingKeys.signing(signingKeys.clock.Now())
+	if key == nil {
+		// The rotator did not run in time: create the next key now.
+		if err = signingKeys.rotate(); err != nil {
+			return "", err
+		}
+
+		if key = signingKeys.signing(signingKeys.clock.Now()); key == nil {
+			return "", errors.New("no active signing key")
+		}
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.model.KID
+	return token.SignedString(key.private)
 }
 
 func ValidateToken(signedToken string) (*JWTClaim, error) {
@@ -45,6 +95,10 @@ func ValidateToken(signedToken string) (*JWTClaim, error) {
 		return nil, errors.New("token expired")
 	}
 
+	if err = checkTokenRevocation(claims); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
@@ -52,9 +106,7 @@ func GetClaims(signedToken string) (*JWTClaim, error) {
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&JWTClaim{},
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtKey), nil
-		},
+		verificationKey,
 	)
 	if err != nil {
 		return nil, err
@@ -68,20 +120,168 @@ func GetClaims(signedToken string) (*JWTClaim, error) {
 	return claims, nil
 }
 
-func InitJWTAuth() {
+// verificationKey selects the key of the token. Tokens signed by a key of
+// the keyring name it in the kid header, and tokens without kid are HS256
+// tokens signed by JWT_SECRET, accepted with the keyring only until the
+// legacy deadline.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if token.Method != jwt.SigningMethodHS256 || len(jwtKey) == 0 || !acceptsLegacyTokens() {
+			return nil, errors.New("unexpected token signing method")
+		}
+		return jwtKey, nil
+	}
+
+	if signingKeys == nil {
+		return nil, errors.New("unknown token signing key")
+	}
+
+	now := signingKeys.clock.Now()
+	key := signingKeys.verifying(kid, now)
+	if key == nil {
+		signingKeys.reloadOnMiss(now)
+		key = signingKeys.verifying(kid, now)
+	}
+
+	if key == nil {
+		return nil, errors.New("unknown token signing key")
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected token signing method")
+	}
+
+	return key.publicKey, nil
+}
+
+// acceptsLegacyTokens tells whether the tokens signed by JWT_SECRET are
+// accepted, always with HS256 and before the legacy deadline otherwise.
+func acceptsLegacyTokens() bool {
+	return signingKeys == nil || signingKeys.clock.Now().Before(legacyUntil)
+}
+
+// InitJWTAuth loads the HS256 key, the cache of the revoked tokens and, for
+// the asymmetric algorithms, the signing keys, creating the first one when
+// needed.
+func InitJWTAuth(repository Repository, config KeyConfig, clock clock.Clock) error {
+	if config.Algorithm == "" {
+		config.Algorithm = "HS256"
+	}
+
+	if err := config.validate(); err != nil {
+		return err
+	}
+
 	key := os.Getenv("JWT_SECRET")
 	jwtKey = []byte(key)
+	revokedTokens = &revocationCache{repository: repository, clock: clock}
+	signingKeys = nil
+	legacyUntil = config.LegacyUntil
+
+	if config.Algorithm == "HS256" {
+		if key == "" {
+			return errors.New("JWT_SECRET is required by HS256")
+		}
+		return nil
+	}
+
+	keys := &keyring{repository: repository, clock: clock, config: config}
+	if err := keys.rotate(); err != nil {
+		return err
+	}
+
+	signingKeys = keys
+	return nil
 }
 
-func ValidateTokenSSO(token string, login string) error {
-	claims, err := ValidateToken(token)
+// PublicKeys returns the published signing keys, in the JWKS format. With
+// HS256 there is none.
+func PublicKeys() *models.JWKSDTO {
+	jwks := models.JWKSDTO{Keys: []models.JWKDTO{}}
+	if signingKeys == nil {
+		return &jwks
+	}
+
+	for _, key := range signingKeys.published(signingKeys.clock.Now()) {
+		jwks.Keys = append(jwks.Keys, key.jwk())
+	}
+	return &jwks
+}
+
+func checkTokenRevocation(claims *JWTClaim) error {
+	if revokedTokens == nil || claims.Id == "" {
+		return nil
+	}
+
+	revoked, err := revokedTokens.isRevoked(claims.Id)
 	if err != nil {
-		return err
+		log.Printf("Error checking token revocation: %s\n", err.Error())
+		return errors.New("cannot validate token")
 	}
 
-	if claims.Login != login {
-		return errors.New("invalid login sso")
+	if revoked {
+		return errors.New("token revoked")
 	}
 
 	return nil
 }
+
+// rememberRevokedToken rejects the token on this instance right away, without
+// waiting for the next reload of the cache.
+func rememberRevokedToken(jti string) {
+	if revokedTokens == nil {
+		return
+	}
+
+	revokedTokens.mutex.Lock()
+	defer revokedTokens.mutex.Unlock()
+
+	if revokedTokens.jtis != nil {
+		revokedTokens.jtis[jti] = true
+	}
+}
+
+func (c *revocationCache) isRevoked(jti string) (bool, error) {
+	now := c.clock.Now().UTC()
+	c.mutex.RLock()
+	fresh := c.jtis != nil && now.Before(c.loadedAt.Add(constants.RevokedTokensCacheTime))
+	revoked := c.jtis[jti]
+	c.mutex.RUnlock()
+
+	if fresh {
+		return revoked, nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Another request may have reloaded the cache in the meantime.
+	if c.jtis == nil || !now.Before(c.loadedAt.Add(constants.RevokedTokensCacheTime)) {
+		stored, err := c.repository.GetRevokedAccessTokens(now)
+		if err != nil {
+			return false, err
+		}
+
+		c.jtis = make(map[string]bool, len(stored))
+		for _, id := range stored {
+			c.jtis[id] = true
+		}
+		c.loadedAt = now
+	}
+
+	return c.jtis[jti], nil
+}
+
+func generateRandomToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
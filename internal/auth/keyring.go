@@ -0,0 +1,456 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/clock"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// keyReloadInterval is how often a token signed by an unknown key reloads
+// the keys, which another instance may have just created.
+const keyReloadInterval = time.Minute
+
+// encryptedKeyPrefix marks the private keys encrypted with AES-256-GCM.
+const encryptedKeyPrefix = "aes256gcm:"
+
+// signingMethods are the asymmetric algorithms that sign the access tokens.
+var signingMethods = map[string]jwt.SigningMethod{
+	"RS256": jwt.SigningMethodRS256,
+	"ES256": jwt.SigningMethodES256,
+	"EdDSA": SigningMethodEdDSA,
+}
+
+// KeyConfig tells how the access tokens are signed. With HS256 they are
+// signed by JWT_SECRET. With the other algorithms a key signs the tokens for
+// Rotation. The next key is published Grace before it starts signing, and a
+// retired key keeps verifying tokens for Grace. The private keys are stored
+// encrypted by Secret, so the database alone does not sign tokens. The HS256
+// tokens issued before switching from HS256 are accepted until LegacyUntil,
+// and never when it is zero.
+type KeyConfig struct {
+	Algorithm   string
+	Rotation    time.Duration
+	Grace       time.Duration
+	Secret      []byte
+	LegacyUntil time.Time
+}
+
+type (
+	signingKey struct {
+		model     models.SigningKey
+		method    jwt.SigningMethod
+		private   crypto.Signer
+		publicKey crypto.PublicKey
+	}
+
+	keyring struct {
+		repository Repository
+		clock      clock.Clock
+		config     KeyConfig
+		mutex      sync.RWMutex
+		keys       []signingKey
+		loadedAt   time.Time
+		// rotating serializes the rotations of the instance.
+		rotating sync.Mutex
+	}
+)
+
+func (c KeyConfig) validate() error {
+	if c.Algorithm == "HS256" {
+		return nil
+	}
+
+	if signingMethods[c.Algorithm] == nil {
+		return fmt.Errorf("unknown JWT algorithm %s", c.Algorithm)
+	}
+
+	if c.Rotation <= 0 {
+		return errors.New("the JWT key rotation must be positive")
+	}
+
+	if c.Grace < constants.TokenExpirationTime {
+		return fmt.Errorf("the JWT key grace must be at least %s", constants.TokenExpirationTime)
+	}
+
+	if len(c.Secret) < constants.MinJWTKeySecretSize {
+		return fmt.Errorf("the JWT key secret must have at least %d characters", constants.MinJWTKeySecretSize)
+	}
+
+	return nil
+}
+
+func (c KeyConfig) cipher() (cipher.AEAD, error) {
+	key := sha256.Sum256(c.Secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealPrivateKey encrypts the private key, bound to the kid so it cannot be
+// moved to another key.
+func (c KeyConfig) sealPrivateKey(kid string, private []byte) (string, error) {
+	aead, err := c.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, private, []byte(kid))
+	return encryptedKeyPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c KeyConfig) openPrivateKey(kid string, stored string) ([]byte, error) {
+	encoded := strings.TrimPrefix(stored, encryptedKeyPrefix)
+	if encoded == stored {
+		return nil, errors.New("private key not encrypted")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := c.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("invalid encrypted private key")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(kid))
+}
+
+// signing returns the newest active key of the configured algorithm.
+func (k *keyring) signing(now time.Time) *signingKey {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		key := &k.keys[i]
+		if key.model.Algorithm == k.config.Algorithm &&
+			!key.model.ActivatesAt.After(now) && now.Before(key.model.RetiresAt) {
+			return key
+		}
+	}
+	return nil
+}
+
+// verifying returns the published key with the kid. Keys of another
+// algorithm still verify the tokens they signed until they expire.
+func (k *keyring) verifying(kid string, now time.Time) *signingKey {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	for i := range k.keys {
+		key := &k.keys[i]
+		if key.model.KID == kid && now.Before(key.model.ExpiresAt) {
+			return key
+		}
+	}
+	return nil
+}
+
+func (k *keyring) published(now time.Time) []signingKey {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	keys := []signingKey{}
+	for _, key := range k.keys {
+		if now.Before(key.model.ExpiresAt) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// rotate loads the stored keys, creates the next key when the newest one is
+// about to retire, or an active key when there is none, and removes the
+// expired keys. The instances rotate one at a time, each one seeing the keys
+// created by the others.
+func (k *keyring) rotate() error {
+	k.rotating.Lock()
+	defer k.rotating.Unlock()
+
+	return k.repository.WithSigningKeyLock(func() error {
+		return k.rotateLocked(k.clock.Now().UTC())
+	})
+}
+
+func (k *keyring) rotateLocked(now time.Time) error {
+	if err := k.load(now); err != nil {
+		return err
+	}
+
+	var newest *signingKey
+	k.mutex.RLock()
+	for i := range k.keys {
+		if k.keys[i].model.Algorithm == k.config.Algorithm {
+			newest = &k.keys[i]
+		}
+	}
+	k.mutex.RUnlock()
+
+	activatesAt := now
+	if newest != nil && now.Before(newest.model.RetiresAt) {
+		if newest.model.RetiresAt.After(now.Add(k.config.Grace)) {
+			return k.repository.DeleteExpiredSigningKeys(now)
+		}
+		activatesAt = newest.model.RetiresAt
+	}
+
+	key, err := k.generate(now, activatesAt)
+	if err != nil {
+		return err
+	}
+
+	if err = k.repository.SaveSigningKey(&key.model); err != nil {
+		return err
+	}
+	log.Printf("Created JWT signing key %s, active from %s\n", key.model.KID, activatesAt.Format(time.RFC3339))
+
+	if err = k.load(now); err != nil {
+		return err
+	}
+
+	return k.repository.DeleteExpiredSigningKeys(now)
+}
+
+// load replaces the keys by the stored ones not expired yet, sorted by the
+// time they start signing.
+func (k *keyring) load(now time.Time) error {
+	stored, err := k.repository.GetSigningKeys(now)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]signingKey, 0, len(*stored))
+	for _, model := range *stored {
+		key, err := k.parse(model)
+		if err != nil {
+			log.Printf("Ignoring JWT signing key %s: %s\n", model.KID, err.Error())
+			continue
+		}
+		keys = append(keys, *key)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].model.ActivatesAt.Before(keys[j].model.ActivatesAt)
+	})
+
+	k.mutex.Lock()
+	k.keys = keys
+	k.loadedAt = now
+	k.mutex.Unlock()
+	return nil
+}
+
+// reloadOnMiss loads the keys again when a token names an unknown key, at
+// most once per keyReloadInterval.
+func (k *keyring) reloadOnMiss(now time.Time) {
+	k.mutex.RLock()
+	recent := now.Before(k.loadedAt.Add(keyReloadInterval))
+	k.mutex.RUnlock()
+
+	if recent {
+		return
+	}
+
+	if err := k.load(now); err != nil {
+		log.Printf("Error loading JWT signing keys: %s\n", err.Error())
+	}
+}
+
+func (k *keyring) generate(now time.Time, activatesAt time.Time) (*signingKey, error) {
+	var private crypto.Signer
+	var err error
+	switch k.config.Algorithm {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	kid, err := generateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := k.config.sealPrivateKey(kid, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		return nil, err
+	}
+
+	retiresAt := activatesAt.Add(k.config.Rotation)
+	return &signingKey{
+		model: models.SigningKey{
+			KID:         kid,
+			Algorithm:   k.config.Algorithm,
+			PrivateKey:  sealed,
+			ActivatesAt: activatesAt,
+			RetiresAt:   retiresAt,
+			ExpiresAt:   retiresAt.Add(k.config.Grace),
+			CreatedAt:   now,
+		},
+		method:    signingMethods[k.config.Algorithm],
+		private:   private,
+		publicKey: private.Public(),
+	}, nil
+}
+
+func (k *keyring) parse(model models.SigningKey) (*signingKey, error) {
+	method := signingMethods[model.Algorithm]
+	if method == nil {
+		return nil, fmt.Errorf("unknown algorithm %s", model.Algorithm)
+	}
+
+	private, err := k.config.openPrivateKey(model.KID, model.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(private)
+	if block == nil {
+		return nil, errors.New("invalid PEM private key")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key")
+	}
+
+	return &signingKey{model: model, method: method, private: signer, publicKey: signer.Public()}, nil
+}
+
+// jwk returns the public key in the JSON Web Key format.
+func (key *signingKey) jwk() models.JWKDTO {
+	jwk := models.JWKDTO{Kid: key.model.KID, Use: "sig", Alg: key.model.Algorithm}
+
+	switch publicKey := key.publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeJWKInt(publicKey.N, 0)
+		jwk.E = encodeJWKInt(big.NewInt(int64(publicKey.E)), 0)
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = publicKey.Curve.Params().Name
+		jwk.X = encodeJWKInt(publicKey.X, size)
+		jwk.Y = encodeJWKInt(publicKey.Y, size)
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+
+	return jwk
+}
+
+// encodeJWKInt encodes the big-endian bytes of the integer, left padded with
+// zeros to size, in base64url without padding.
+func encodeJWKInt(value *big.Int, size int) string {
+	bytes := value.Bytes()
+	if len(bytes) < size {
+		bytes = append(make([]byte, size-len(bytes)), bytes...)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+type (
+	// KeyRotator periodically reloads the signing keys and rotates them.
+	KeyRotator interface {
+		Start()
+		Stop()
+		RunOnce() error
+	}
+
+	keyRotator struct {
+		interval time.Duration
+		stop     chan struct{}
+		wait     sync.WaitGroup
+	}
+)
+
+func NewKeyRotator(interval time.Duration) KeyRotator {
+	return &keyRotator{
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+func (r *keyRotator) Start() {
+	r.wait.Add(1)
+	go func() {
+		defer r.wait.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.RunOnce(); err != nil {
+					log.Printf("Error rotating JWT signing keys: %s\n", err.Error())
+				}
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (r *keyRotator) Stop() {
+	close(r.stop)
+	r.wait.Wait()
+}
+
+// RunOnce rotates the keys of InitJWTAuth. Nothing is done for HS256.
+func (r *keyRotator) RunOnce() error {
+	if signingKeys == nil {
+		return nil
+	}
+	return signingKeys.rotate()
+}
@@ -0,0 +1,230 @@
+package auth_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+var keySecret = []byte("0123456789abcdef0123456789abcdef")
+
+func initKeys(t *testing.T, config auth.KeyConfig, clock *testutil.Clock) *fakeTokenRepository {
+	t.Setenv("JWT_SECRET", "legacy-secret")
+	repository := &fakeTokenRepository{usedSSOTokens: map[string]time.Time{}}
+
+	assert.Nil(t, auth.InitJWTAuth(repository, config, clock))
+	t.Cleanup(func() {
+		assert.Nil(t, auth.InitJWTAuth(repository, auth.KeyConfig{Algorithm: "HS256"}, clock))
+	})
+	return repository
+}
+
+func tokenKID(t *testing.T, token string) string {
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, &auth.JWTClaim{})
+	assert.Nil(t, err)
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestSigningKeysRotateWithGraceWindow(t *testing.T) {
+	start := time.Now().UTC()
+	clock := &testutil.Clock{Current: start}
+	repository := initKeys(t, auth.KeyConfig{Algorithm: "ES256", Rotation: 48 * time.Hour, Grace: 24 * time.Hour, Secret: keySecret}, clock)
+	rotator := auth.NewKeyRotator(time.Hour)
+	user := models.User{ID: 1, Login: "john", Role: models.UserRoleMember}
+
+	oldToken, err := auth.GenerateJWT(&user)
+	assert.Nil(t, err)
+	assert.Len(t, repository.signingKeys, 1)
+	oldKID := repository.signingKeys[0].KID
+	assert.Equal(t, oldKID, tokenKID(t, oldToken))
+
+	// The next key is published a grace window before it starts signing.
+	clock.Current = start.Add(25 * time.Hour)
+	assert.Nil(t, rotator.RunOnce())
+	assert.Len(t, repository.signingKeys, 2)
+	assert.Len(t, auth.PublicKeys().Keys, 2)
+
+	token, err := auth.GenerateJWT(&user)
+	assert.Nil(t, err)
+	assert.Equal(t, oldKID, tokenKID(t, token))
+
+	// Once it signs, the old key still verifies during the grace window.
+	clock.Current = start.Add(49 * time.Hour)
+	assert.Nil(t, rotator.RunOnce())
+
+	newToken, err := auth.GenerateJWT(&user)
+	assert.Nil(t, err)
+	assert.NotEqual(t, oldKID, tokenKID(t, newToken))
+
+	_, err = auth.ValidateToken(oldToken)
+	assert.Nil(t, err)
+	_, err = auth.ValidateToken(newToken)
+	assert.Nil(t, err)
+
+	// After the grace window the old key is removed, and the key after the
+	// current one is published.
+	clock.Current = start.Add(73 * time.Hour)
+	assert.Nil(t, rotator.RunOnce())
+
+	_, err = auth.ValidateToken(oldToken)
+	assert.NotNil(t, err)
+
+	keys := auth.PublicKeys().Keys
+	assert.Len(t, keys, 2)
+	for _, key := range keys {
+		assert.NotEqual(t, oldKID, key.Kid)
+	}
+}
+
+func TestSigningAlgorithmsPublishTheirKeys(t *testing.T) {
+	tests := map[string]func(t *testing.T, jwk models.JWKDTO){
+		"RS256": func(t *testing.T, jwk models.JWKDTO) {
+			assert.Equal(t, "RSA", jwk.Kty)
+			assert.Equal(t, "AQAB", jwk.E)
+			assert.Len(t, jwk.N, 342)
+		},
+		"ES256": func(t *testing.T, jwk models.JWKDTO) {
+			assert.Equal(t, "EC", jwk.Kty)
+			assert.Equal(t, "P-256", jwk.Crv)
+			assert.Len(t, jwk.X, 43)
+			assert.Len(t, jwk.Y, 43)
+		},
+		"EdDSA": func(t *testing.T, jwk models.JWKDTO) {
+			assert.Equal(t, "OKP", jwk.Kty)
+			assert.Equal(t, "Ed25519", jwk.Crv)
+			assert.Len(t, jwk.X, 43)
+		},
+	}
+
+	for algorithm, check := range tests {
+		t.Run(algorithm, func(t *testing.T) {
+			clock := &testutil.Clock{Current: time.Now().UTC()}
+			initKeys(t, auth.KeyConfig{Algorithm: algorithm, Rotation: 48 * time.Hour, Grace: 24 * time.Hour, Secret: keySecret}, clock)
+
+			token, err := auth.GenerateJWT(&models.User{ID: 1, Login: "john"})
+			assert.Nil(t, err)
+
+			claims, err := auth.ValidateToken(token)
+			assert.Nil(t, err)
+			assert.Equal(t, "john", claims.Login)
+
+			keys := auth.PublicKeys().Keys
+			assert.Len(t, keys, 1)
+			assert.Equal(t, algorithm, keys[0].Alg)
+			assert.Equal(t, "sig", keys[0].Use)
+			assert.Equal(t, tokenKID(t, token), keys[0].Kid)
+			check(t, keys[0])
+		})
+	}
+}
+
+func TestLegacyTokensVerifyUntilTheDeadline(t *testing.T) {
+	start := time.Now().UTC()
+	clock := &testutil.Clock{Current: start}
+	user := models.User{ID: 1, Login: "john"}
+
+	initKeys(t, auth.KeyConfig{Algorithm: "HS256"}, clock)
+	legacyToken, err := auth.GenerateJWT(&user)
+	assert.Nil(t, err)
+
+	config := auth.KeyConfig{
+		Algorithm: "EdDSA", Rotation: 48 * time.Hour, Grace: 24 * time.Hour, Secret: keySecret,
+		LegacyUntil: start.Add(30 * time.Minute),
+	}
+	initKeys(t, config, clock)
+	_, err = auth.ValidateToken(legacyToken)
+	assert.Nil(t, err)
+
+	// A token signed with HS256 must not name a key of the keyring.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.JWTClaim{Login: "john"})
+	forged.Header["kid"] = tokenKID(t, mustGenerate(t, &user))
+	signed, err := forged.SignedString([]byte("legacy-secret"))
+	assert.Nil(t, err)
+
+	_, err = auth.ValidateToken(signed)
+	assert.NotNil(t, err)
+
+	// After the deadline the legacy tokens are no longer accepted, even
+	// before they expire.
+	clock.Advance(31 * time.Minute)
+	_, err = auth.ValidateToken(legacyToken)
+	assert.NotNil(t, err)
+}
+
+func TestLegacyTokensAreRejectedWithoutTransition(t *testing.T) {
+	clock := &testutil.Clock{Current: time.Now().UTC()}
+	user := models.User{ID: 1, Login: "john"}
+
+	initKeys(t, auth.KeyConfig{Algorithm: "HS256"}, clock)
+	legacyToken, err := auth.GenerateJWT(&user)
+	assert.Nil(t, err)
+
+	// JWT_SECRET is still set, but no deadline was configured.
+	initKeys(t, auth.KeyConfig{Algorithm: "EdDSA", Rotation: 48 * time.Hour, Grace: 24 * time.Hour, Secret: keySecret}, clock)
+	_, err = auth.ValidateToken(legacyToken)
+	assert.NotNil(t, err)
+
+	// Without JWT_SECRET they are rejected even before the deadline.
+	t.Setenv("JWT_SECRET", "")
+	assert.Nil(t, auth.InitJWTAuth(&fakeTokenRepository{}, auth.KeyConfig{
+		Algorithm: "EdDSA", Rotation: 48 * time.Hour, Grace: 24 * time.Hour, Secret: keySecret,
+		LegacyUntil: clock.Now().Add(time.Hour),
+	}, clock))
+	_, err = auth.ValidateToken(legacyToken)
+	assert.NotNil(t, err)
+}
+
+func mustGenerate(t *testing.T, user *models.User) string {
+	token, err := auth.GenerateJWT(user)
+	assert.Nil(t, err)
+	return token
+}
+
+func TestSigningKeysAreStoredEncrypted(t *testing.T) {
+	clock := &testutil.Clock{Current: time.Now().UTC()}
+	config := auth.KeyConfig{Algorithm: "ES256", Rotation: 48 * time.Hour, Grace: 24 * time.Hour, Secret: keySecret}
+	repository := initKeys(t, config, clock)
+	token := mustGenerate(t, &models.User{ID: 1, Login: "john"})
+
+	assert.Len(t, repository.signingKeys, 1)
+	assert.NotContains(t, repository.signingKeys[0].PrivateKey, "PRIVATE KEY")
+
+	// Another secret does not open the stored key, which is replaced.
+	config.Secret = []byte("another secret, 32 characters...")
+	assert.Nil(t, auth.InitJWTAuth(repository, config, clock))
+	assert.Len(t, repository.signingKeys, 2)
+	assert.NotEqual(t, tokenKID(t, token), tokenKID(t, mustGenerate(t, &models.User{ID: 1, Login: "john"})))
+
+	_, err := auth.ValidateToken(token)
+	assert.NotNil(t, err)
+
+	config.Secret = []byte("short")
+	assert.NotNil(t, auth.InitJWTAuth(repository, config, clock))
+}
+
+func TestConcurrentRotationsCreateOneKey(t *testing.T) {
+	start := time.Now().UTC()
+	clock := &testutil.Clock{Current: start}
+	repository := initKeys(t, auth.KeyConfig{Algorithm: "EdDSA", Rotation: 48 * time.Hour, Grace: 24 * time.Hour, Secret: keySecret}, clock)
+	rotator := auth.NewKeyRotator(time.Hour)
+
+	clock.Current = start.Add(25 * time.Hour)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, rotator.RunOnce())
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, repository.signingKeys, 2)
+}
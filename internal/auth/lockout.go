@@ -0,0 +1,274 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+// maxAttemptKeyLength keeps the keys of long logins within the column.
+const maxAttemptKeyLength = 255
+
+// loginAttempt is a login being tried, whose attempt was reserved by
+// reserveLoginAttempt before the password or the code is checked.
+type loginAttempt struct {
+	login    string
+	userID   *uint64
+	ip       string
+	ipKey    string
+	reserved []models.LoginAttempt
+}
+
+// UnlockLogin forgets the failed logins of the user, at every IP, unlocking
+// the login.
+func (s service) UnlockLogin(actorID uint64, userID uint64) error {
+	user, err := s.repository.Get(userID)
+	if err != nil {
+		log.Printf("Error getting user %d: %s\n", userID, err.Error())
+		return apperrors.NewInternalError("Internal error getting user")
+	}
+
+	if user == nil {
+		return apperrors.NewNotFoundError("user", userID)
+	}
+
+	key := userAttemptKey(user.ID)
+	err = s.tokenRepository.ResetLoginAttempts(key)
+	if err == nil {
+		err = s.tokenRepository.ResetLoginAttemptsByPrefix(pairAttemptKey(key, ""))
+	}
+
+	if err != nil {
+		log.Printf("Error unlocking login %s: %s\n", user.Login, err.Error())
+		return apperrors.NewInternalError("Internal error unlocking login")
+	}
+
+	log.Printf("Login %s unlocked by user %d\n", user.Login, actorID)
+	s.audit(models.AuthAudit{
+		Event:   models.AuthAuditLoginUnlocked,
+		Login:   user.Login,
+		UserID:  &user.ID,
+		ActorID: &actorID,
+	})
+	return nil
+}
+
+// reserveLoginAttempt refuses a login while it is locked, or while it must
+// wait after its last failed login, and otherwise counts it as a failure
+// until it succeeds. The attempts are counted by the user, or by the login
+// when no user has it, by the user at the IP and by the IP. An IP the user
+// logged in from is not held back by the failures of the user made
+// elsewhere, so failing the logins of a user does not lock the user out.
+func (s service) reserveLoginAttempt(login string, user *models.User, ip string) (*loginAttempt, error) {
+	now := s.clock.Now().UTC()
+	attempt := loginAttempt{login: login, ip: ip}
+	accountKey := loginAttemptKey(login)
+	if user != nil {
+		attempt.userID = &user.ID
+		accountKey = userAttemptKey(user.ID)
+	}
+
+	trusted := false
+	keys := []string{}
+	if user != nil && ip != "" {
+		var err error
+		trusted, err = s.tokenRepository.IsLoginIPTrusted(user.ID, ip, now.Add(-constants.TrustedLoginIPLifetime))
+		if err != nil {
+			log.Printf("Error checking the IP of the login of %s: %s\n", login, err.Error())
+			return nil, apperrors.NewInternalError("Internal error getting user to login")
+		}
+		keys = append(keys, pairAttemptKey(accountKey, ip))
+	}
+
+	if !trusted {
+		keys = append(keys, accountKey)
+	}
+
+	if ip != "" {
+		attempt.ipKey = ipAttemptKey(ip)
+		keys = append(keys, attempt.ipKey)
+	}
+
+	reserved, err := s.tokenRepository.ReserveLoginAttempts(keys, now, now.Add(-constants.LoginAttemptWindow),
+		func(attempts []models.LoginAttempt) error {
+			for _, current := range attempts {
+				if err := attempt.check(current, now); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	switch err.(type) {
+	case nil:
+		attempt.reserved = *reserved
+		return &attempt, nil
+	case *apperrors.AccountLockedError, *apperrors.TooManyAttemptsError:
+		s.auditRejectedLogin(models.AuthAuditLoginThrottled, &attempt)
+		return nil, err
+	default:
+		log.Printf("Error counting the login attempt of %s: %s\n", login, err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting user to login")
+	}
+}
+
+// check refuses the login while the attempts of the key are locked, or until
+// its backoff after the last failure has passed.
+func (a *loginAttempt) check(current models.LoginAttempt, now time.Time) error {
+	isIP := current.AttemptKey == a.ipKey
+	if !isIP && current.LockedUntil != nil && now.Before(*current.LockedUntil) {
+		return apperrors.NewAccountLockedError(current.LockedUntil.Sub(now))
+	}
+
+	if current.LastFailureAt.Before(now.Add(-constants.LoginAttemptWindow)) {
+		return nil
+	}
+
+	freeAttempts := constants.FreeLoginAttempts
+	if isIP {
+		freeAttempts = constants.FreeIPLoginAttempts
+	}
+
+	retryAt := current.LastFailureAt.Add(loginBackoff(current.Failures, freeAttempts))
+	if now.Before(retryAt) {
+		return apperrors.NewTooManyAttemptsError(retryAt.Sub(now))
+	}
+
+	return nil
+}
+
+// failLogin keeps the reserved attempt as a failed login, and locks the user
+// and the user at the IP once they reach constants.LoginLockoutThreshold
+// failures.
+func (s service) failLogin(event models.AuthAuditEvent, attempt *loginAttempt) {
+	s.auditRejectedLogin(event, attempt)
+
+	now := s.clock.Now().UTC()
+	locked := false
+	for _, reserved := range attempt.reserved {
+		if reserved.AttemptKey == attempt.ipKey || reserved.Failures < constants.LoginLockoutThreshold {
+			continue
+		}
+
+		if err := s.tokenRepository.LockLogin(reserved.AttemptKey, now.Add(constants.LoginLockoutDuration)); err != nil {
+			log.Printf("Error locking login %s: %s\n", reserved.AttemptKey, err.Error())
+			continue
+		}
+
+		log.Printf("Login %s locked after %d failed logins\n", reserved.AttemptKey, reserved.Failures)
+		locked = true
+	}
+
+	if locked {
+		s.auditRejectedLogin(models.AuthAuditLoginLocked, attempt)
+	}
+}
+
+// releaseLoginAttempt uncounts the reserved attempt of a login that did not
+// fail, as one that still waits for the second factor.
+func (s service) releaseLoginAttempt(attempt *loginAttempt) {
+	if err := s.tokenRepository.ReleaseLoginAttempts(attempt.keys()...); err != nil {
+		log.Printf("Error releasing login attempt of %s: %s\n", attempt.login, err.Error())
+	}
+}
+
+// succeedLogin forgets the failed logins of a user that logged in and trusts
+// the IP of the login. The failures of the IP are kept, since an attacker may
+// own an account.
+func (s service) succeedLogin(attempt *loginAttempt) {
+	keys := []string{}
+	for _, key := range attempt.keys() {
+		if key != attempt.ipKey {
+			keys = append(keys, key)
+		}
+	}
+
+	if err := s.tokenRepository.ResetLoginAttempts(keys...); err != nil {
+		log.Printf("Error resetting login attempts of %s: %s\n", attempt.login, err.Error())
+	}
+
+	if attempt.ipKey == "" {
+		return
+	}
+
+	if err := s.tokenRepository.ReleaseLoginAttempts(attempt.ipKey); err != nil {
+		log.Printf("Error releasing login attempt from %s: %s\n", attempt.ip, err.Error())
+	}
+
+	if err := s.tokenRepository.TrustLoginIP(*attempt.userID, attempt.ip, s.clock.Now().UTC()); err != nil {
+		log.Printf("Error trusting the IP of the login of %s: %s\n", attempt.login, err.Error())
+	}
+}
+
+func (a *loginAttempt) keys() []string {
+	keys := make([]string, len(a.reserved))
+	for i, reserved := range a.reserved {
+		keys[i] = reserved.AttemptKey
+	}
+	return keys
+}
+
+func (s service) auditRejectedLogin(event models.AuthAuditEvent, attempt *loginAttempt) {
+	s.audit(models.AuthAudit{
+		Event:  event,
+		Login:  truncate(attempt.login, maxAttemptKeyLength),
+		UserID: attempt.userID,
+		IP:     attempt.ip,
+	})
+}
+
+func (s service) audit(entry models.AuthAudit) {
+	entry.CreatedAt = s.clock.Now().UTC()
+	if err := s.tokenRepository.SaveAuthAudit(&entry); err != nil {
+		log.Printf("Error saving auth audit %s of %s: %s\n", entry.Event, entry.Login, err.Error())
+	}
+}
+
+// loginBackoff returns how long to wait after the last failed login, doubling
+// from constants.LoginBackoffBase at each failure beyond the free ones.
+func loginBackoff(failures int, freeAttempts int) time.Duration {
+	exceeded := failures - freeAttempts
+	if exceeded < 0 {
+		return 0
+	}
+
+	if exceeded > 16 {
+		return constants.MaxLoginBackoff
+	}
+
+	backoff := constants.LoginBackoffBase << exceeded
+	if backoff > constants.MaxLoginBackoff {
+		return constants.MaxLoginBackoff
+	}
+	return backoff
+}
+
+// loginAttemptKey keys the attempts of a login no user has. It ignores the
+// case of the login, as the database does.
+func loginAttemptKey(login string) string {
+	return "login:" + truncate(strings.ToLower(login), maxAttemptKeyLength)
+}
+
+func userAttemptKey(userID uint64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func pairAttemptKey(userKey string, ip string) string {
+	return userKey + ":ip:" + ip
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
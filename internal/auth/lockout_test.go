@@ -0,0 +1,179 @@
+package auth_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *mfaFixture) authenticate(login string, password string, ip string) (*models.AuthResponse, error) {
+	return f.service.Authenticate(&models.AuthRequest{Login: login, Password: password, ClientIP: ip})
+}
+
+// failLogins fails the login of the user the given times, waiting out the
+// backoff before each attempt.
+func (f *mfaFixture) failLogins(t *testing.T, login string, times int) {
+	for i := 0; i < times; i++ {
+		f.clock.Advance(constants.MaxLoginBackoff)
+		_, err := f.authenticate(login, "wrong", "10.0.0.1")
+		assertErrorType[*apperrors.UserLoginError](t, err)
+	}
+}
+
+func TestLoginBackoffAfterFailedLogins(t *testing.T) {
+	fixture := newMFAFixture(t)
+
+	fixture.failLogins(t, "john", constants.FreeLoginAttempts)
+
+	_, err := fixture.authenticate("john", mfaPassword, "10.0.0.1")
+	assertErrorType[*apperrors.TooManyAttemptsError](t, err)
+	assert.Equal(t, 1, err.(*apperrors.TooManyAttemptsError).RetryAfterSeconds())
+
+	fixture.clock.Advance(constants.LoginBackoffBase)
+	response, err := fixture.authenticate("john", mfaPassword, "10.0.0.1")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, response.Token)
+
+	// A successful login forgets the failures of the user, but not of the IP.
+	assert.NotContains(t, fixture.tokens.loginAttempts, "user:1")
+	assert.NotContains(t, fixture.tokens.loginAttempts, "user:1:ip:10.0.0.1")
+	assert.Equal(t, constants.FreeLoginAttempts, fixture.tokens.loginAttempts["ip:10.0.0.1"].Failures)
+}
+
+func TestLoginBackoffForgetsOldFailures(t *testing.T) {
+	fixture := newMFAFixture(t)
+	fixture.failLogins(t, "john", constants.FreeLoginAttempts+2)
+
+	fixture.clock.Advance(constants.LoginAttemptWindow + time.Second)
+	_, err := fixture.authenticate("john", "wrong", "")
+	assertErrorType[*apperrors.UserLoginError](t, err)
+	assert.Equal(t, 1, fixture.tokens.loginAttempts["user:1"].Failures)
+}
+
+func TestLoginLockedAfterFailedLogins(t *testing.T) {
+	fixture := newMFAFixture(t)
+	fixture.failLogins(t, "john", constants.LoginLockoutThreshold)
+
+	// The password is not checked while the login is locked.
+	_, err := fixture.authenticate("john", mfaPassword, "10.0.0.2")
+	assertErrorType[*apperrors.AccountLockedError](t, err)
+	assert.Equal(t, int(constants.LoginLockoutDuration/time.Second), err.(*apperrors.AccountLockedError).RetryAfterSeconds())
+
+	events := map[models.AuthAuditEvent]int{}
+	for _, entry := range fixture.tokens.audits {
+		events[entry.Event]++
+	}
+	assert.Equal(t, constants.LoginLockoutThreshold, events[models.AuthAuditLoginFailed])
+	assert.Equal(t, 1, events[models.AuthAuditLoginLocked])
+	assert.Equal(t, 1, events[models.AuthAuditLoginThrottled])
+
+	fixture.clock.Advance(constants.LoginLockoutDuration)
+	_, err = fixture.authenticate("john", mfaPassword, "10.0.0.2")
+	assert.Nil(t, err)
+}
+
+func TestUnlockLogin(t *testing.T) {
+	fixture := newMFAFixture(t)
+	fixture.failLogins(t, "john", constants.LoginLockoutThreshold)
+
+	assert.Nil(t, fixture.service.UnlockLogin(2, 1))
+	_, err := fixture.authenticate("john", mfaPassword, "")
+	assert.Nil(t, err)
+
+	unlock := fixture.tokens.audits[len(fixture.tokens.audits)-1]
+	assert.Equal(t, models.AuthAuditLoginUnlocked, unlock.Event)
+	assert.Equal(t, uint64(1), *unlock.UserID)
+	assert.Equal(t, uint64(2), *unlock.ActorID)
+
+	assertErrorType[*apperrors.NotFoundError](t, fixture.service.UnlockLogin(2, 99))
+}
+
+func TestLoginBackoffByIP(t *testing.T) {
+	fixture := newMFAFixture(t)
+
+	// Spraying passwords over many logins is throttled by the IP.
+	for i := 0; i < constants.FreeIPLoginAttempts; i++ {
+		_, err := fixture.authenticate(fmt.Sprintf("user%d", i), "wrong", "10.0.0.1")
+		assertErrorType[*apperrors.UserLoginError](t, err)
+	}
+
+	_, err := fixture.authenticate("john", mfaPassword, "10.0.0.1")
+	assertErrorType[*apperrors.TooManyAttemptsError](t, err)
+
+	_, err = fixture.authenticate("john", mfaPassword, "10.0.0.2")
+	assert.Nil(t, err)
+}
+
+func TestMFAFailuresCountAgainstLogin(t *testing.T) {
+	fixture := newMFAFixture(t)
+	secret, _ := fixture.enroll(t, 1)
+
+	challenge := fixture.login(t, "john")
+	for i := 0; i < constants.FreeLoginAttempts; i++ {
+		_, err := fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, Code: "000000"})
+		assertErrorType[*apperrors.UserLoginError](t, err)
+	}
+
+	_, err := fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, Code: fixture.code(t, secret)})
+	assertErrorType[*apperrors.TooManyAttemptsError](t, err)
+	assert.Equal(t, models.AuthAuditMFAFailed, fixture.tokens.audits[0].Event)
+}
+
+func TestLoginLockDoesNotLockTrustedIP(t *testing.T) {
+	fixture := newMFAFixture(t)
+	_, err := fixture.authenticate("john", mfaPassword, "10.0.0.1")
+	assert.Nil(t, err)
+
+	// Someone else fails the logins of John until they are locked.
+	for i := 0; i < constants.LoginLockoutThreshold; i++ {
+		fixture.clock.Advance(constants.MaxLoginBackoff)
+		_, err = fixture.authenticate("john", "wrong", "10.0.0.9")
+		assertErrorType[*apperrors.UserLoginError](t, err)
+	}
+
+	// John still logs in from the IP of the last login, but not elsewhere.
+	_, err = fixture.authenticate("john", mfaPassword, "10.0.0.1")
+	assert.Nil(t, err)
+
+	_, err = fixture.authenticate("john", mfaPassword, "10.0.0.3")
+	assertErrorType[*apperrors.AccountLockedError](t, err)
+}
+
+func TestLoginBackoffOfUnknownLogin(t *testing.T) {
+	fixture := newMFAFixture(t)
+
+	// Logins no user has are counted by the login, ignoring its case.
+	for _, login := range []string{"ghost", "GHOST"} {
+		_, err := fixture.authenticate(login, "wrong", "")
+		assertErrorType[*apperrors.UserLoginError](t, err)
+	}
+	assert.Equal(t, 2, fixture.tokens.loginAttempts["login:ghost"].Failures)
+}
+
+func TestConcurrentLoginsAreCountedBeforeThePasswordCheck(t *testing.T) {
+	fixture := newMFAFixture(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = fixture.authenticate("john", "wrong", "")
+		}()
+	}
+	wg.Wait()
+
+	// Only the free attempts check the password, the others wait their turn.
+	events := map[models.AuthAuditEvent]int{}
+	for _, entry := range fixture.tokens.audits {
+		events[entry.Event]++
+	}
+	assert.Equal(t, constants.FreeLoginAttempts, events[models.AuthAuditLoginFailed])
+	assert.Equal(t, 20-constants.FreeLoginAttempts, events[models.AuthAuditLoginThrottled])
+}
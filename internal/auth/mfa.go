@@ -0,0 +1,446 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/totp"
+)
+
+// MFAConfig tells how the TOTP secrets are presented to the authenticator
+// apps and which roles must enable MFA before logging in with a password.
+// RecoverySecret keys the hashes of the recovery codes, so the stored hashes
+// alone do not allow guessing the codes.
+type MFAConfig struct {
+	Issuer         string
+	RequiredRoles  []models.UserRole
+	RecoverySecret []byte
+}
+
+func (c MFAConfig) requires(role models.UserRole) bool {
+	return role.In(c.RequiredRoles...)
+}
+
+// authenticateWithMFA issues the tokens of a user authenticated by the
+// password, an SSO application or the OIDC provider, or an MFA challenge when
+// the user has MFA or must enable it. Every login goes through it, so the
+// second factor cannot be skipped by choosing another way to log in.
+func (s service) authenticateWithMFA(user *models.User) (*models.AuthResponse, error) {
+	mfa, err := s.tokenRepository.GetMFA(user.ID)
+	if err != nil {
+		log.Printf("Error getting MFA of user %s: %s\n", user.Login, err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting user to login")
+	}
+
+	if mfa.IsConfirmed() {
+		return s.newMFAChallenge(user, false)
+	}
+
+	if s.mfa.requires(user.Role) {
+		return s.newMFAChallenge(user, true)
+	}
+
+	return s.authenticateUser(user)
+}
+
+func (s service) newMFAChallenge(user *models.User, enrollment bool) (*models.AuthResponse, error) {
+	token, err := generateRandomToken(32)
+	if err != nil {
+		log.Printf("Error generating MFA challenge: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error generating token")
+	}
+
+	challenge := models.MFAChallenge{
+		TokenHash:  hashToken(token),
+		UserID:     user.ID,
+		Enrollment: enrollment,
+		ExpiresAt:  s.clock.Now().UTC().Add(constants.MFAChallengeLifetime),
+	}
+
+	if err = s.tokenRepository.SaveMFAChallenge(&challenge); err != nil {
+		log.Printf("Error saving MFA challenge of user %s: %s\n", user.Login, err.Error())
+		return nil, apperrors.NewInternalError("Internal error generating token")
+	}
+
+	return &models.AuthResponse{
+		MFARequired:           true,
+		MFAEnrollmentRequired: enrollment,
+		MFAToken:              token,
+	}, nil
+}
+
+// VerifyMFA exchanges the MFA token of a login and the TOTP code, or one of
+// the recovery codes, for the tokens of the user. An enrollment challenge
+// confirms the secret created by EnrollMFAChallenge and returns the recovery
+// codes along with the tokens. Wrong codes count as failed logins.
+func (s service) VerifyMFA(authRequest *models.AuthRequestMFA) (*models.AuthResponse, error) {
+	challenge, user, err := s.getMFAChallenge(authRequest.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt, err := s.reserveLoginAttempt(user.Login, user, authRequest.ClientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	response, failed, err := s.verifyMFA(challenge, user, authRequest)
+	switch {
+	case failed:
+		s.failLogin(models.AuthAuditMFAFailed, attempt)
+	case err != nil:
+		s.releaseLoginAttempt(attempt)
+	default:
+		s.succeedLogin(attempt)
+	}
+	return response, err
+}
+
+// verifyMFA checks the code of the challenge and issues the tokens, telling
+// whether the code was wrong.
+func (s service) verifyMFA(
+	challenge *models.MFAChallenge,
+	user *models.User,
+	authRequest *models.AuthRequestMFA,
+) (*models.AuthResponse, bool, error) {
+	mfa, err := s.tokenRepository.GetMFA(user.ID)
+	if err != nil {
+		log.Printf("Error getting MFA of user %s: %s\n", user.Login, err.Error())
+		return nil, false, apperrors.NewInternalError("Internal error verifying MFA")
+	}
+
+	var recoveryCodes []string
+	var ok bool
+	switch {
+	case challenge.Enrollment:
+		if mfa == nil || mfa.IsConfirmed() {
+			return nil, false, apperrors.NewObjectInInvalidStateError("MFA enrollment not started")
+		}
+
+		if ok, err = s.useTOTP(mfa, authRequest.Code); ok && err == nil {
+			recoveryCodes, err = s.confirmMFA(mfa)
+		}
+	case !mfa.IsConfirmed():
+		return nil, false, apperrors.NewInvalidMFAChallengeError()
+	case authRequest.RecoveryCode != "":
+		ok, err = s.tokenRepository.UseRecoveryCode(user.ID, s.mfa.hashRecoveryCode(authRequest.RecoveryCode), s.clock.Now().UTC())
+		if ok {
+			log.Printf("User %s logged in with a recovery code\n", user.Login)
+		}
+	default:
+		ok, err = s.useTOTP(mfa, authRequest.Code)
+	}
+
+	if err != nil {
+		log.Printf("Error verifying MFA of user %s: %s\n", user.Login, err.Error())
+		return nil, false, apperrors.NewInternalError("Internal error verifying MFA")
+	}
+
+	if !ok {
+		if err = s.tokenRepository.FailMFAChallenge(challenge.TokenHash); err != nil {
+			log.Printf("Error counting MFA attempt of user %s: %s\n", user.Login, err.Error())
+		}
+		return nil, true, apperrors.NewInvalidMFACodeError()
+	}
+
+	deleted, err := s.tokenRepository.DeleteMFAChallenge(challenge.TokenHash)
+	if err != nil {
+		log.Printf("Error deleting MFA challenge of user %s: %s\n", user.Login, err.Error())
+		return nil, false, apperrors.NewInternalError("Internal error verifying MFA")
+	}
+
+	if !deleted {
+		return nil, false, apperrors.NewInvalidMFAChallengeError()
+	}
+
+	response, err := s.authenticateUser(user)
+	if err != nil {
+		return nil, false, err
+	}
+
+	response.RecoveryCodes = recoveryCodes
+	return response, false, nil
+}
+
+// EnrollMFAChallenge creates the secret of a user that must enable MFA to log
+// in, from the MFA token of the login.
+func (s service) EnrollMFAChallenge(authRequest *models.AuthRequestMFA) (*models.MFAEnrollmentDTO, error) {
+	challenge, user, err := s.getMFAChallenge(authRequest.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !challenge.Enrollment {
+		return nil, apperrors.NewObjectInInvalidStateError("MFA already enabled")
+	}
+
+	return s.startMFAEnrollment(user)
+}
+
+// EnrollMFA creates a new secret for the user, replacing one not confirmed
+// yet. MFA is enabled once ConfirmMFA verifies a code of the secret.
+func (s service) EnrollMFA(userID uint64) (*models.MFAEnrollmentDTO, error) {
+	user, mfa, err := s.getUserMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mfa.IsConfirmed() {
+		return nil, apperrors.NewObjectInInvalidStateError("MFA already enabled")
+	}
+
+	return s.startMFAEnrollment(user)
+}
+
+// ConfirmMFA enables MFA with the code of the secret created by EnrollMFA and
+// returns the recovery codes, shown only this time.
+func (s service) ConfirmMFA(userID uint64, codeRequest *models.MFACodeRequest) (*models.MFARecoveryCodesDTO, error) {
+	_, mfa, err := s.getUserMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mfa == nil {
+		return nil, apperrors.NewObjectInInvalidStateError("MFA enrollment not started")
+	}
+
+	if mfa.IsConfirmed() {
+		return nil, apperrors.NewObjectInInvalidStateError("MFA already enabled")
+	}
+
+	if err = s.checkTOTP(mfa, codeRequest.Code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.confirmMFA(mfa)
+	if err != nil {
+		log.Printf("Error confirming MFA of user %d: %s\n", userID, err.Error())
+		return nil, apperrors.NewInternalError("Internal error confirming MFA")
+	}
+
+	return &models.MFARecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, used or
+// not, by new ones.
+func (s service) RegenerateRecoveryCodes(userID uint64, codeRequest *models.MFACodeRequest) (*models.MFARecoveryCodesDTO, error) {
+	_, mfa, err := s.getUserMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mfa.IsConfirmed() {
+		return nil, apperrors.NewObjectInInvalidStateError("MFA not enabled")
+	}
+
+	if err = s.checkTOTP(mfa, codeRequest.Code); err != nil {
+		return nil, err
+	}
+
+	codes, hashed, err := s.mfa.newRecoveryCodes(userID)
+	if err != nil {
+		log.Printf("Error generating recovery codes: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error generating recovery codes")
+	}
+
+	if err = s.tokenRepository.ReplaceRecoveryCodes(userID, hashed); err != nil {
+		log.Printf("Error saving recovery codes of user %d: %s\n", userID, err.Error())
+		return nil, apperrors.NewInternalError("Internal error generating recovery codes")
+	}
+
+	return &models.MFARecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
+
+// DisableMFA removes the secret and the recovery codes of the user and ends
+// the sessions of the user. Users of the roles that require MFA cannot
+// disable it.
+func (s service) DisableMFA(userID uint64, codeRequest *models.MFACodeRequest) error {
+	user, mfa, err := s.getUserMFA(userID)
+	if err != nil {
+		return err
+	}
+
+	if mfa == nil {
+		return apperrors.NewObjectInInvalidStateError("MFA not enabled")
+	}
+
+	if s.mfa.requires(user.Role) {
+		return apperrors.NewForbiddenError(fmt.Sprintf("MFA is required for the %s role", user.Role))
+	}
+
+	if mfa.IsConfirmed() {
+		if err = s.checkTOTP(mfa, codeRequest.Code); err != nil {
+			return err
+		}
+	}
+
+	return s.deleteMFA(user)
+}
+
+// ResetMFA removes the MFA of a user that lost the authenticator and the
+// recovery codes, and ends the sessions of the user, which may have been
+// opened by whoever has the authenticator. Users of the roles that require
+// MFA enable it again in the next login.
+func (s service) ResetMFA(userID uint64) error {
+	user, _, err := s.getUserMFA(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.deleteMFA(user)
+}
+
+func (s service) getMFAChallenge(token string) (*models.MFAChallenge, *models.User, error) {
+	challenge, err := s.tokenRepository.GetMFAChallenge(hashToken(token))
+	if err != nil {
+		log.Printf("Error getting MFA challenge: %s\n", err.Error())
+		return nil, nil, apperrors.NewInternalError("Internal error verifying MFA")
+	}
+
+	if challenge == nil || !s.clock.Now().Before(challenge.ExpiresAt) ||
+		challenge.Attempts >= constants.MaxMFAAttempts {
+		return nil, nil, apperrors.NewInvalidMFAChallengeError()
+	}
+
+	user, err := s.repository.Get(challenge.UserID)
+	if err != nil {
+		log.Printf("Error getting user of MFA challenge: %s\n", err.Error())
+		return nil, nil, apperrors.NewInternalError("Internal error verifying MFA")
+	}
+
+	if user == nil {
+		return nil, nil, apperrors.NewInvalidMFAChallengeError()
+	}
+
+	return challenge, user, nil
+}
+
+func (s service) getUserMFA(userID uint64) (*models.User, *models.UserMFA, error) {
+	user, err := s.repository.Get(userID)
+	if err != nil {
+		log.Printf("Error getting user %d: %s\n", userID, err.Error())
+		return nil, nil, apperrors.NewInternalError("Internal error getting user")
+	}
+
+	if user == nil {
+		return nil, nil, apperrors.NewNotFoundError("user", userID)
+	}
+
+	mfa, err := s.tokenRepository.GetMFA(userID)
+	if err != nil {
+		log.Printf("Error getting MFA of user %s: %s\n", user.Login, err.Error())
+		return nil, nil, apperrors.NewInternalError("Internal error getting MFA")
+	}
+
+	return user, mfa, nil
+}
+
+func (s service) startMFAEnrollment(user *models.User) (*models.MFAEnrollmentDTO, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("Error generating MFA secret: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error enrolling MFA")
+	}
+
+	mfa := models.UserMFA{UserID: user.ID, Secret: secret, CreatedAt: s.clock.Now().UTC()}
+	if err = s.tokenRepository.SaveMFA(&mfa); err != nil {
+		log.Printf("Error saving MFA of user %s: %s\n", user.Login, err.Error())
+		return nil, apperrors.NewInternalError("Internal error enrolling MFA")
+	}
+
+	return &models.MFAEnrollmentDTO{Secret: secret, URI: totp.URI(s.mfa.Issuer, user.Login, secret)}, nil
+}
+
+func (s service) confirmMFA(mfa *models.UserMFA) ([]string, error) {
+	codes, hashed, err := s.mfa.newRecoveryCodes(mfa.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	mfa.ConfirmedAt = &now
+	if err = s.tokenRepository.ConfirmMFA(mfa, hashed); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s service) deleteMFA(user *models.User) error {
+	if err := s.tokenRepository.DeleteMFA(user.ID, s.clock.Now().UTC()); err != nil {
+		log.Printf("Error deleting MFA of user %s: %s\n", user.Login, err.Error())
+		return apperrors.NewInternalError("Internal error disabling MFA")
+	}
+	return nil
+}
+
+// checkTOTP verifies the code of a logged user.
+func (s service) checkTOTP(mfa *models.UserMFA, code string) error {
+	ok, err := s.useTOTP(mfa, code)
+	if err != nil {
+		log.Printf("Error verifying MFA of user %d: %s\n", mfa.UserID, err.Error())
+		return apperrors.NewInternalError("Internal error verifying MFA")
+	}
+
+	if !ok {
+		return apperrors.NewObjectInInvalidStateError("invalid MFA code")
+	}
+
+	return nil
+}
+
+// useTOTP tells if the code is valid and was not used yet.
+func (s service) useTOTP(mfa *models.UserMFA, code string) (bool, error) {
+	step, ok := totp.Validate(mfa.Secret, code, s.clock.Now())
+	if !ok {
+		return false, nil
+	}
+
+	used, err := s.tokenRepository.UseMFAStep(mfa.UserID, step)
+	if used {
+		mfa.LastUsedStep = step
+	}
+	return used, err
+}
+
+// newRecoveryCodes returns the recovery codes, formatted as
+// xxxx-xxxx-xxxx-xxxx, and their hashes to store.
+func (c MFAConfig) newRecoveryCodes(userID uint64) ([]string, []models.MFARecoveryCode, error) {
+	codes := make([]string, 0, constants.MFARecoveryCodeCount)
+	hashed := make([]models.MFARecoveryCode, 0, constants.MFARecoveryCodeCount)
+	for len(codes) < constants.MFARecoveryCodeCount {
+		value := make([]byte, constants.MFARecoveryCodeSize)
+		if _, err := rand.Read(value); err != nil {
+			return nil, nil, err
+		}
+
+		encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(value))
+		groups := make([]string, 0, len(encoded)/4)
+		for i := 0; i < len(encoded); i += 4 {
+			groups = append(groups, encoded[i:i+4])
+		}
+
+		code := strings.Join(groups, "-")
+		codes = append(codes, code)
+		hashed = append(hashed, models.MFARecoveryCode{UserID: userID, CodeHash: c.hashRecoveryCode(code)})
+	}
+	return codes, hashed, nil
+}
+
+// hashRecoveryCode hashes the code with the recovery secret, ignoring case,
+// spaces and dashes.
+func (c MFAConfig) hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	mac := hmac.New(sha256.New, c.RecoverySecret)
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))
+}
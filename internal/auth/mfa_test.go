@@ -0,0 +1,298 @@
+package auth_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/totp"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	mfaPassword       = "secret"
+	mfaRecoverySecret = "0123456789abcdef0123456789abcdef"
+)
+
+type mfaFixture struct {
+	service auth.Service
+	tokens  *fakeTokenRepository
+	clock   *testutil.Clock
+}
+
+func newMFAFixture(t *testing.T) *mfaFixture {
+	hash, err := bcrypt.GenerateFromPassword([]byte(mfaPassword), bcrypt.MinCost)
+	assert.Nil(t, err)
+
+	users := &testutil.UserRepository{Users: map[string]*models.User{
+		"john": {ID: 1, Login: "john", Password: string(hash), Role: models.UserRoleMember},
+		"root": {ID: 2, Login: "root", Password: string(hash), Role: models.UserRoleAdmin},
+	}}
+	tokens := &fakeTokenRepository{}
+	clock := &testutil.Clock{Current: time.Now()}
+	config := auth.MFAConfig{
+		Issuer:         "List Manager",
+		RequiredRoles:  []models.UserRole{models.UserRoleAdmin},
+		RecoverySecret: []byte(mfaRecoverySecret),
+	}
+
+	service := auth.NewService(users, tokens, &testutil.SSORepository{}, nil, config, clock)
+	return &mfaFixture{service, tokens, clock}
+}
+
+func (f *mfaFixture) login(t *testing.T, login string) *models.AuthResponse {
+	response, err := f.service.Authenticate(&models.AuthRequest{Login: login, Password: mfaPassword})
+	assert.Nil(t, err)
+	return response
+}
+
+func (f *mfaFixture) code(t *testing.T, secret string) string {
+	code, err := totp.Code(secret, totp.Step(f.clock.Now()))
+	assert.Nil(t, err)
+	return code
+}
+
+// nextPeriod moves the clock to the next code.
+func (f *mfaFixture) nextPeriod() {
+	f.clock.Advance(totp.Period)
+}
+
+func (f *mfaFixture) enroll(t *testing.T, userID uint64) (string, []string) {
+	enrollment, err := f.service.EnrollMFA(userID)
+	assert.Nil(t, err)
+
+	codes, err := f.service.ConfirmMFA(userID, &models.MFACodeRequest{Code: f.code(t, enrollment.Secret)})
+	assert.Nil(t, err)
+	f.nextPeriod()
+	return enrollment.Secret, codes.RecoveryCodes
+}
+
+func assertErrorType[T error](t *testing.T, err error) {
+	_, ok := err.(T)
+	assert.True(t, ok, "expected a %T, got %v", *new(T), err)
+}
+
+func TestAuthenticateWithoutMFA(t *testing.T) {
+	fixture := newMFAFixture(t)
+
+	response := fixture.login(t, "john")
+	assert.NotEmpty(t, response.Token)
+	assert.False(t, response.MFARequired)
+}
+
+func TestMFAEnrollmentAndLogin(t *testing.T) {
+	fixture := newMFAFixture(t)
+
+	enrollment, err := fixture.service.EnrollMFA(1)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/List%20Manager:john?"))
+
+	// MFA is enabled only by a code of the secret.
+	_, err = fixture.service.ConfirmMFA(1, &models.MFACodeRequest{Code: "000000"})
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+	assert.False(t, fixture.login(t, "john").MFARequired)
+
+	code := fixture.code(t, enrollment.Secret)
+	codes, err := fixture.service.ConfirmMFA(1, &models.MFACodeRequest{Code: code})
+	assert.Nil(t, err)
+	assert.Len(t, codes.RecoveryCodes, 10)
+
+	_, err = fixture.service.EnrollMFA(1)
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+
+	challenge := fixture.login(t, "john")
+	assert.True(t, challenge.MFARequired)
+	assert.False(t, challenge.MFAEnrollmentRequired)
+	assert.Empty(t, challenge.Token)
+	assert.NotEmpty(t, challenge.MFAToken)
+
+	// The code used to confirm cannot be used again.
+	_, err = fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, Code: code})
+	assertErrorType[*apperrors.UserLoginError](t, err)
+
+	fixture.nextPeriod()
+	request := models.AuthRequestMFA{MFAToken: challenge.MFAToken, Code: fixture.code(t, enrollment.Secret)}
+	response, err := fixture.service.VerifyMFA(&request)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.Equal(t, uint64(1), response.User.ID)
+
+	// The challenge is used once.
+	fixture.nextPeriod()
+	request.Code = fixture.code(t, enrollment.Secret)
+	_, err = fixture.service.VerifyMFA(&request)
+	assertErrorType[*apperrors.UserLoginError](t, err)
+}
+
+func TestMFARecoveryCodesAreSingleUse(t *testing.T) {
+	fixture := newMFAFixture(t)
+	_, codes := fixture.enroll(t, 1)
+
+	// Recovery codes ignore case and dashes.
+	recoveryCode := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	challenge := fixture.login(t, "john")
+	response, err := fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, RecoveryCode: recoveryCode})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, response.Token)
+
+	challenge = fixture.login(t, "john")
+	_, err = fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, RecoveryCode: codes[0]})
+	assertErrorType[*apperrors.UserLoginError](t, err)
+
+	_, err = fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, RecoveryCode: codes[1]})
+	assert.Nil(t, err)
+}
+
+func TestMFARecoveryCodesAreKeyedBySecret(t *testing.T) {
+	fixture := newMFAFixture(t)
+	_, codes := fixture.enroll(t, 1)
+
+	assert.Regexp(t, `^[a-z2-7]{4}(-[a-z2-7]{4}){3}$`, codes[0])
+
+	// The stored hash is not the plain hash of the code.
+	normalized := strings.ReplaceAll(codes[0], "-", "")
+	plain := sha256.Sum256([]byte(normalized))
+	mac := hmac.New(sha256.New, []byte(mfaRecoverySecret))
+	mac.Write([]byte(normalized))
+	hashes := []string{}
+	for _, code := range fixture.tokens.recoveryCodes {
+		hashes = append(hashes, code.CodeHash)
+	}
+	assert.NotContains(t, hashes, hex.EncodeToString(plain[:]))
+	assert.Contains(t, hashes, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestMFAChallengeLimitsAttemptsAndExpires(t *testing.T) {
+	fixture := newMFAFixture(t)
+	secret, _ := fixture.enroll(t, 1)
+
+	challenge := fixture.login(t, "john")
+	for i := 0; i < 5; i++ {
+		// Waits for the backoff of the failed codes.
+		fixture.clock.Advance(10 * time.Second)
+		_, err := fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, Code: "000000"})
+		assertErrorType[*apperrors.UserLoginError](t, err)
+	}
+
+	fixture.clock.Advance(10 * time.Second)
+	_, err := fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, Code: fixture.code(t, secret)})
+	assert.Equal(t, apperrors.NewInvalidMFAChallengeError(), err)
+
+	challenge = fixture.login(t, "john")
+	fixture.clock.Advance(6 * time.Minute)
+	_, err = fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, Code: fixture.code(t, secret)})
+	assert.Equal(t, apperrors.NewInvalidMFAChallengeError(), err)
+}
+
+func TestMFARequiredForRole(t *testing.T) {
+	fixture := newMFAFixture(t)
+
+	challenge := fixture.login(t, "root")
+	assert.True(t, challenge.MFARequired)
+	assert.True(t, challenge.MFAEnrollmentRequired)
+	assert.Empty(t, challenge.Token)
+
+	_, err := fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, Code: "000000"})
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+
+	enrollment, err := fixture.service.EnrollMFAChallenge(&models.AuthRequestMFA{MFAToken: challenge.MFAToken})
+	assert.Nil(t, err)
+
+	response, err := fixture.service.VerifyMFA(&models.AuthRequestMFA{
+		MFAToken: challenge.MFAToken, Code: fixture.code(t, enrollment.Secret),
+	})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.Len(t, response.RecoveryCodes, 10)
+
+	// The role cannot disable MFA, but an administrator can reset it.
+	fixture.nextPeriod()
+	err = fixture.service.DisableMFA(2, &models.MFACodeRequest{Code: fixture.code(t, enrollment.Secret)})
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	assert.Nil(t, fixture.service.ResetMFA(2))
+	assert.True(t, fixture.login(t, "root").MFAEnrollmentRequired)
+	fixture.assertSessionsRevoked(t, 2)
+
+	assertErrorType[*apperrors.NotFoundError](t, fixture.service.ResetMFA(99))
+}
+
+func TestDisableMFA(t *testing.T) {
+	fixture := newMFAFixture(t)
+	secret, _ := fixture.enroll(t, 1)
+
+	err := fixture.service.DisableMFA(1, &models.MFACodeRequest{Code: "000000"})
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+
+	challenge := fixture.login(t, "john")
+	_, err = fixture.service.VerifyMFA(&models.AuthRequestMFA{MFAToken: challenge.MFAToken, Code: fixture.code(t, secret)})
+	assert.Nil(t, err)
+
+	fixture.nextPeriod()
+	err = fixture.service.DisableMFA(1, &models.MFACodeRequest{Code: fixture.code(t, secret)})
+	assert.Nil(t, err)
+	assert.Empty(t, fixture.tokens.recoveryCodes)
+	fixture.assertSessionsRevoked(t, 1)
+	assert.NotEmpty(t, fixture.login(t, "john").Token)
+}
+
+// assertSessionsRevoked checks the user had refresh tokens and all of them
+// were revoked now.
+func (f *mfaFixture) assertSessionsRevoked(t *testing.T, userID uint64) {
+	count := 0
+	for _, token := range f.tokens.refreshTokens {
+		if token.UserID == userID {
+			count++
+			if assert.NotNil(t, token.RevokedAt) {
+				assert.True(t, f.clock.Now().Equal(*token.RevokedAt))
+			}
+		}
+	}
+	assert.NotZero(t, count)
+}
+
+func assertMFAChallenge(t *testing.T, response *models.AuthResponse, err error, enrollment bool) {
+	assert.Nil(t, err)
+	assert.True(t, response.MFARequired)
+	assert.Equal(t, enrollment, response.MFAEnrollmentRequired)
+	assert.NotEmpty(t, response.MFAToken)
+	assert.Empty(t, response.Token)
+	assert.Empty(t, response.RefreshToken)
+}
+
+func TestSSOLoginRequiresMFA(t *testing.T) {
+	confirmedAt := time.Now()
+	fixture := newSSOFixture(hmacApplication())
+	fixture.tokens.mfa = map[uint64]models.UserMFA{1: {UserID: 1, Secret: "secret", ConfirmedAt: &confirmedAt}}
+
+	response, err := fixture.authenticate(signHMAC(t, fixture.claims("a1"), ssoSecret))
+	assertMFAChallenge(t, response, err, false)
+
+	config := auth.MFAConfig{RequiredRoles: []models.UserRole{models.UserRoleMember}}
+	fixture = newSSOFixtureWithMFA(config, hmacApplication())
+
+	response, err = fixture.authenticate(signHMAC(t, fixture.claims("a2"), ssoSecret))
+	assertMFAChallenge(t, response, err, true)
+}
+
+func TestOIDCLoginRequiresMFA(t *testing.T) {
+	confirmedAt := time.Now()
+	fixture := newOIDCFixture(t, false, true)
+	fixture.tokens.mfa = map[uint64]models.UserMFA{1: {UserID: 1, Secret: "secret", ConfirmedAt: &confirmedAt}}
+
+	response, err := fixture.login(t, nil, jwt.MapClaims{"sub": "s1", "email": "john@test.com", "email_verified": true})
+	assertMFAChallenge(t, response, err, false)
+
+	// The linked identity logs in with the second factor as well.
+	response, err = fixture.login(t, nil, jwt.MapClaims{"sub": "s1"})
+	assertMFAChallenge(t, response, err, false)
+}
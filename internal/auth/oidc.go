@@ -0,0 +1,358 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"strings"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/oidc"
+)
+
+// OIDCConfig enables the login with an OpenID Connect provider. A subject
+// seen for the first time is linked to the user with the same verified email
+// when LinkVerifiedEmail is set, or else gets a new user when CreateUsers is
+// set. Logged users link a subject to their account by confirming it.
+type OIDCConfig struct {
+	RelyingParty      *oidc.RelyingParty
+	CreateUsers       bool
+	LinkVerifiedEmail bool
+}
+
+// StartOIDCLogin returns the URL where the user logs in at the provider, and
+// the state the browser must keep to finish the login. When userID is
+// informed, the login links the provider identity to that user once the user
+// confirms it.
+func (s service) StartOIDCLogin(userID *uint64) (*models.OIDCAuthorizationDTO, error) {
+	if s.oidc == nil {
+		return nil, apperrors.NewObjectInInvalidStateError("OIDC login is not configured")
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		value, err := oidc.RandomString()
+		if err != nil {
+			log.Printf("Error generating OIDC login: %s\n", err.Error())
+			return nil, apperrors.NewInternalError("Internal error starting OIDC login")
+		}
+		values[i] = value
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	authorizationURL, err := s.oidc.RelyingParty.AuthCodeURL(state, nonce, verifier)
+	if err != nil {
+		log.Printf("Error building OIDC authorization URL: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error starting OIDC login")
+	}
+
+	login := models.OIDCLogin{
+		StateHash:    hashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		UserID:       userID,
+		ExpiresAt:    s.clock.Now().UTC().Add(constants.OIDCLoginLifetime),
+	}
+
+	if err = s.tokenRepository.SaveOIDCLogin(&login); err != nil {
+		log.Printf("Error saving OIDC login: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error starting OIDC login")
+	}
+
+	return &models.OIDCAuthorizationDTO{AuthorizationURL: authorizationURL, State: state}, nil
+}
+
+// AuthenticateOIDC finishes the login started by StartOIDCLogin with the code
+// and the state the provider redirected the user with. The state must match
+// the one kept by the browser, so a callback URL that reaches another browser
+// is refused. A linking login returns a link for its user to confirm instead
+// of the tokens.
+func (s service) AuthenticateOIDC(authRequest *models.AuthRequestOIDC) (*models.AuthResponse, error) {
+	if s.oidc == nil {
+		return nil, apperrors.NewObjectInInvalidStateError("OIDC login is not configured")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(authRequest.State), []byte(authRequest.BrowserState)) != 1 {
+		log.Printf("Rejected OIDC callback without the state of the browser\n")
+		return nil, apperrors.NewUserOIDCLoginError()
+	}
+
+	login, err := s.tokenRepository.ConsumeOIDCLogin(hashToken(authRequest.State))
+	if err != nil {
+		log.Printf("Error getting OIDC login: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error finishing OIDC login")
+	}
+
+	if login == nil || !s.clock.Now().Before(login.ExpiresAt) {
+		return nil, apperrors.NewUserOIDCLoginError()
+	}
+
+	claims, err := s.oidc.RelyingParty.Exchange(authRequest.Code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		log.Printf("Rejected OIDC login: %s\n", err.Error())
+		return nil, apperrors.NewUserOIDCLoginError()
+	}
+
+	if login.UserID != nil {
+		return s.newOIDCLink(*login.UserID, claims)
+	}
+
+	user, err := s.oidcUser(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authenticateWithMFA(user)
+}
+
+// ConfirmOIDCLink links the provider identity of a linking login to the user
+// who started it.
+func (s service) ConfirmOIDCLink(userID uint64, linkRequest *models.OIDCLinkRequest) error {
+	link, err := s.tokenRepository.ConsumeOIDCLink(hashToken(linkRequest.LinkToken))
+	if err != nil {
+		log.Printf("Error getting OIDC link: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error linking OIDC identity")
+	}
+
+	if link == nil || link.UserID != userID || !s.clock.Now().Before(link.ExpiresAt) {
+		return apperrors.NewObjectInInvalidStateError("invalid or expired link token")
+	}
+
+	linked, err := s.tokenRepository.SaveUserIdentity(&models.UserIdentity{
+		UserID:    userID,
+		Issuer:    link.Issuer,
+		Subject:   link.Subject,
+		CreatedAt: s.clock.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Error saving OIDC identity of user %d: %s\n", userID, err.Error())
+		return apperrors.NewInternalError("Internal error linking OIDC identity")
+	}
+
+	if !linked {
+		return apperrors.NewObjectInInvalidStateError("OIDC identity already linked to another user")
+	}
+
+	log.Printf("Linked OIDC subject %s to user %d\n", link.Subject, userID)
+	return nil
+}
+
+// newOIDCLink keeps the identity that logged in at the provider until the
+// user who started the linking login confirms it. A subject already linked to
+// the user just logs the user in.
+func (s service) newOIDCLink(userID uint64, claims *oidc.Claims) (*models.AuthResponse, error) {
+	identity, err := s.getUserIdentity(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity != nil {
+		if identity.UserID != userID {
+			return nil, apperrors.NewObjectInInvalidStateError("OIDC identity already linked to another user")
+		}
+
+		user, err := s.getOIDCUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		return s.authenticateWithMFA(user)
+	}
+
+	token, err := generateRandomToken(32)
+	if err != nil {
+		log.Printf("Error generating OIDC link: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error generating token")
+	}
+
+	link := models.OIDCLink{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		Issuer:    claims.Issuer,
+		Subject:   claims.Subject,
+		ExpiresAt: s.clock.Now().UTC().Add(constants.OIDCLinkLifetime),
+	}
+
+	if err = s.tokenRepository.SaveOIDCLink(&link); err != nil {
+		log.Printf("Error saving OIDC link of user %d: %s\n", userID, err.Error())
+		return nil, apperrors.NewInternalError("Internal error finishing OIDC login")
+	}
+
+	return &models.AuthResponse{OIDCLinkRequired: true, OIDCLinkToken: token}, nil
+}
+
+// oidcUser returns the user linked to the provider subject, linking or
+// creating it the first time the subject logs in.
+func (s service) oidcUser(claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.getUserIdentity(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity != nil {
+		return s.getOIDCUser(identity.UserID)
+	}
+
+	if s.oidc.LinkVerifiedEmail && claims.EmailVerified && claims.Email != "" {
+		user, err := s.repository.GetByEmail(claims.Email)
+		if err != nil {
+			log.Printf("Error getting user to link OIDC identity: %s\n", err.Error())
+			return nil, apperrors.NewInternalError("Internal error finishing OIDC login")
+		}
+
+		if user != nil {
+			return s.linkOIDCUser(user, claims)
+		}
+	}
+
+	if !s.oidc.CreateUsers {
+		log.Printf("Rejected OIDC login of unknown subject %s\n", claims.Subject)
+		return nil, apperrors.NewUserOIDCLoginError()
+	}
+
+	return s.createOIDCUser(claims)
+}
+
+// linkOIDCUser links the subject to the user with its verified email. When a
+// concurrent login linked the subject first, its user is logged in instead.
+func (s service) linkOIDCUser(user *models.User, claims *oidc.Claims) (*models.User, error) {
+	linked, err := s.tokenRepository.SaveUserIdentity(s.newUserIdentity(user.ID, claims))
+	if err != nil {
+		log.Printf("Error saving OIDC identity of user %s: %s\n", user.Login, err.Error())
+		return nil, apperrors.NewInternalError("Internal error finishing OIDC login")
+	}
+
+	if !linked {
+		return s.getLinkedOIDCUser(claims)
+	}
+
+	return user, nil
+}
+
+func (s service) newUserIdentity(userID uint64, claims *oidc.Claims) *models.UserIdentity {
+	return &models.UserIdentity{
+		UserID:    userID,
+		Issuer:    claims.Issuer,
+		Subject:   claims.Subject,
+		CreatedAt: s.clock.Now().UTC(),
+	}
+}
+
+func (s service) getUserIdentity(claims *oidc.Claims) (*models.UserIdentity, error) {
+	identity, err := s.tokenRepository.GetUserIdentity(claims.Issuer, claims.Subject)
+	if err != nil {
+		log.Printf("Error getting OIDC identity: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error finishing OIDC login")
+	}
+
+	return identity, nil
+}
+
+// getLinkedOIDCUser returns the user a concurrent login linked the subject to.
+func (s service) getLinkedOIDCUser(claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.getUserIdentity(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity == nil {
+		log.Printf("OIDC subject %s was neither linked nor found\n", claims.Subject)
+		return nil, apperrors.NewInternalError("Internal error finishing OIDC login")
+	}
+
+	return s.getOIDCUser(identity.UserID)
+}
+
+func (s service) getOIDCUser(id uint64) (*models.User, error) {
+	user, err := s.repository.Get(id)
+	if err != nil {
+		log.Printf("Error getting user to login: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error finishing OIDC login")
+	}
+
+	if user == nil {
+		return nil, apperrors.NewUserOIDCLoginError()
+	}
+
+	return user, nil
+}
+
+// createOIDCUser creates a member for the subject, linked to it. Without a
+// password, the user logs in only through the provider. Only a verified email
+// is kept, so an unverified one cannot take the email of its owner. When a
+// concurrent first login of the subject creates its user first, that user is
+// logged in instead.
+func (s service) createOIDCUser(claims *oidc.Claims) (*models.User, error) {
+	login, err := s.availableLogin(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = login
+	}
+
+	user := models.User{
+		Name:  name,
+		Login: login,
+		Role:  models.UserRoleMember,
+	}
+	if claims.EmailVerified {
+		user.Email = claims.Email
+	}
+
+	saved, err := s.tokenRepository.SaveUserWithIdentity(&user, s.newUserIdentity(0, claims))
+	if err != nil {
+		// The login of the user may have been taken by the same subject.
+		log.Printf("Error saving OIDC user %s: %s\n", login, err.Error())
+		if identity, _ := s.tokenRepository.GetUserIdentity(claims.Issuer, claims.Subject); identity != nil {
+			return s.getOIDCUser(identity.UserID)
+		}
+		return nil, apperrors.NewInternalError("Internal error finishing OIDC login")
+	}
+
+	if !saved {
+		return s.getLinkedOIDCUser(claims)
+	}
+
+	log.Printf("Created user %s for OIDC subject %s\n", login, claims.Subject)
+	return &user, nil
+}
+
+// availableLogin returns the preferred username of the subject, or else its
+// email, numbered when the login is taken.
+func (s service) availableLogin(claims *oidc.Claims) (string, error) {
+	base := strings.TrimSpace(claims.PreferredUsername)
+	if base == "" {
+		base = strings.TrimSpace(claims.Email)
+	}
+	if base == "" {
+		base = "oidc-" + claims.Subject
+	}
+
+	for i := 1; i <= constants.MaxOIDCLoginSuffix; i++ {
+		login := base
+		if i > 1 {
+			login = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		exists, err := s.repository.ExistsByLogin(login)
+		if err != nil {
+			log.Printf("Error checking login of OIDC user: %s\n", err.Error())
+			return "", apperrors.NewInternalError("Internal error finishing OIDC login")
+		}
+
+		if !exists {
+			return login, nil
+		}
+	}
+
+	suffix, err := generateRandomToken(4)
+	if err != nil {
+		log.Printf("Error generating login of OIDC user: %s\n", err.Error())
+		return "", apperrors.NewInternalError("Internal error finishing OIDC login")
+	}
+	return base + "-" + suffix, nil
+}
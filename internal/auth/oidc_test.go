@@ -0,0 +1,269 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/oidc"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/oidc/oidctest"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type oidcFixture struct {
+	provider *oidctest.Provider
+	service  auth.Service
+	users    *testutil.UserRepository
+	tokens   *fakeTokenRepository
+	clock    *testutil.Clock
+}
+
+func newOIDCFixture(t *testing.T, createUsers bool, linkVerifiedEmail bool) *oidcFixture {
+	provider, err := oidctest.NewProvider("list-manager", "client-secret")
+	assert.Nil(t, err)
+	t.Cleanup(provider.Close)
+
+	clock := &testutil.Clock{Current: time.Now()}
+	users := &testutil.UserRepository{Users: map[string]*models.User{
+		"john": {ID: 1, Login: "john", Email: "john@test.com", Role: models.UserRoleMember},
+	}}
+	tokens := &fakeTokenRepository{users: users}
+	config := &auth.OIDCConfig{
+		RelyingParty:      oidc.NewRelyingParty(provider.Config("https://app.test/callback"), provider.Client(), clock),
+		CreateUsers:       createUsers,
+		LinkVerifiedEmail: linkVerifiedEmail,
+	}
+
+	service := auth.NewService(users, tokens, &testutil.SSORepository{}, config, auth.MFAConfig{}, clock)
+	return &oidcFixture{provider, service, users, tokens, clock}
+}
+
+// authorize starts a login and logs the user in at the provider, returning
+// the callback the browser that started the login makes.
+func (f *oidcFixture) authorize(t *testing.T, userID *uint64, claims jwt.MapClaims) models.AuthRequestOIDC {
+	authorization, err := f.service.StartOIDCLogin(userID)
+	assert.Nil(t, err)
+
+	code, state, err := f.provider.Authorize(authorization.AuthorizationURL, claims)
+	assert.Nil(t, err)
+	return models.AuthRequestOIDC{Code: code, State: state, BrowserState: authorization.State}
+}
+
+func (f *oidcFixture) login(t *testing.T, userID *uint64, claims jwt.MapClaims) (*models.AuthResponse, error) {
+	request := f.authorize(t, userID, claims)
+	return f.service.AuthenticateOIDC(&request)
+}
+
+func assertOIDCLoginError(t *testing.T, err error) {
+	_, ok := err.(*apperrors.UserLoginError)
+	assert.True(t, ok, "expected an OIDC login error, got %v", err)
+}
+
+func TestAuthenticateOIDCCreatesUser(t *testing.T) {
+	fixture := newOIDCFixture(t, true, false)
+	claims := jwt.MapClaims{"sub": "s1", "preferred_username": "mary", "name": "Mary", "email": "mary@test.com"}
+
+	response, err := fixture.login(t, nil, claims)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.Equal(t, "mary", response.User.Login)
+	assert.Equal(t, "Mary", response.User.Name)
+	assert.Equal(t, models.UserRoleMember, response.User.Role)
+	assert.Len(t, fixture.users.Users, 2)
+
+	// The email is not verified by the provider, so it is not kept.
+	assert.Empty(t, fixture.users.Users["mary"].Email)
+
+	// The next login of the subject finds the same user.
+	response, err = fixture.login(t, nil, claims)
+	assert.Nil(t, err)
+	assert.Equal(t, fixture.users.Users["mary"].ID, response.User.ID)
+	assert.Len(t, fixture.users.Users, 2)
+	assert.Len(t, fixture.tokens.identities, 1)
+}
+
+func TestAuthenticateOIDCKeepsVerifiedEmail(t *testing.T) {
+	fixture := newOIDCFixture(t, true, false)
+
+	_, err := fixture.login(t, nil, jwt.MapClaims{"sub": "s1", "preferred_username": "mary", "email": "mary@test.com", "email_verified": true})
+	assert.Nil(t, err)
+	assert.Equal(t, "mary@test.com", fixture.users.Users["mary"].Email)
+}
+
+func TestAuthenticateOIDCConcurrentFirstLogin(t *testing.T) {
+	fixture := newOIDCFixture(t, true, false)
+	claims := jwt.MapClaims{"sub": "s1", "preferred_username": "mary"}
+
+	// Another login of the subject creates its user while this one is running.
+	fixture.tokens.beforeSaveIdentity = func() {
+		winner := &models.User{Login: "mary-winner"}
+		_ = fixture.users.Save(winner)
+		fixture.tokens.identities = append(fixture.tokens.identities, models.UserIdentity{
+			UserID:  winner.ID,
+			Issuer:  fixture.provider.Issuer,
+			Subject: "s1",
+		})
+	}
+
+	response, err := fixture.login(t, nil, claims)
+	assert.Nil(t, err)
+	assert.Equal(t, "mary-winner", response.User.Login)
+	assert.Len(t, fixture.users.Users, 2)
+	assert.Len(t, fixture.tokens.identities, 1)
+}
+
+func TestAuthenticateOIDCNumbersTakenLogin(t *testing.T) {
+	fixture := newOIDCFixture(t, true, false)
+
+	response, err := fixture.login(t, nil, jwt.MapClaims{"sub": "s1", "preferred_username": "john"})
+	assert.Nil(t, err)
+	assert.Equal(t, "john-2", response.User.Login)
+}
+
+func TestAuthenticateOIDCRejectsInvalidState(t *testing.T) {
+	fixture := newOIDCFixture(t, true, false)
+	claims := jwt.MapClaims{"sub": "s1"}
+
+	request := fixture.authorize(t, nil, claims)
+	_, err := fixture.service.AuthenticateOIDC(&models.AuthRequestOIDC{Code: request.Code, State: "unknown", BrowserState: "unknown"})
+	assertOIDCLoginError(t, err)
+
+	_, err = fixture.service.AuthenticateOIDC(&request)
+	assert.Nil(t, err)
+
+	// A state is used once.
+	_, err = fixture.service.AuthenticateOIDC(&request)
+	assertOIDCLoginError(t, err)
+
+	request = fixture.authorize(t, nil, claims)
+	fixture.clock.Advance(11 * time.Minute)
+	_, err = fixture.service.AuthenticateOIDC(&request)
+	assertOIDCLoginError(t, err)
+}
+
+func TestAuthenticateOIDCRejectsInvalidIDToken(t *testing.T) {
+	tests := map[string]jwt.MapClaims{
+		"other nonce":    {"sub": "s1", "nonce": "another-nonce"},
+		"other audience": {"sub": "s1", "aud": "another-app"},
+		"expired":        {"sub": "s1", "exp": time.Now().Add(-time.Hour).Unix()},
+	}
+
+	for name, claims := range tests {
+		t.Run(name, func(t *testing.T) {
+			fixture := newOIDCFixture(t, true, false)
+
+			_, err := fixture.login(t, nil, claims)
+			assertOIDCLoginError(t, err)
+			assert.Len(t, fixture.users.Users, 1)
+		})
+	}
+}
+
+func TestAuthenticateOIDCRejectsOtherBrowser(t *testing.T) {
+	fixture := newOIDCFixture(t, true, false)
+	claims := jwt.MapClaims{"sub": "s1"}
+
+	// The callback URL of a login started elsewhere is refused, with or without
+	// the state of another login.
+	request := fixture.authorize(t, nil, claims)
+	other := fixture.authorize(t, nil, claims)
+	for _, browserState := range []string{"", other.BrowserState} {
+		_, err := fixture.service.AuthenticateOIDC(&models.AuthRequestOIDC{Code: request.Code, State: request.State, BrowserState: browserState})
+		assertOIDCLoginError(t, err)
+	}
+
+	// The login is still pending for its own browser.
+	_, err := fixture.service.AuthenticateOIDC(&request)
+	assert.Nil(t, err)
+	assert.Len(t, fixture.users.Users, 2)
+}
+
+func TestAuthenticateOIDCLinksLoggedUser(t *testing.T) {
+	fixture := newOIDCFixture(t, false, false)
+	johnID := uint64(1)
+
+	// Unknown subjects are rejected when users are not created.
+	_, err := fixture.login(t, nil, jwt.MapClaims{"sub": "s1"})
+	assertOIDCLoginError(t, err)
+
+	// The link waits for the user to confirm it.
+	response, err := fixture.login(t, &johnID, jwt.MapClaims{"sub": "s1"})
+	assert.Nil(t, err)
+	assert.True(t, response.OIDCLinkRequired)
+	assert.NotEmpty(t, response.OIDCLinkToken)
+	assert.Empty(t, response.Token)
+	assert.Empty(t, fixture.tokens.identities)
+
+	err = fixture.service.ConfirmOIDCLink(johnID, &models.OIDCLinkRequest{LinkToken: response.OIDCLinkToken})
+	assert.Nil(t, err)
+
+	response, err = fixture.login(t, nil, jwt.MapClaims{"sub": "s1"})
+	assert.Nil(t, err)
+	assert.Equal(t, johnID, response.User.ID)
+
+	// A linking login of the linked subject just logs the user in.
+	response, err = fixture.login(t, &johnID, jwt.MapClaims{"sub": "s1"})
+	assert.Nil(t, err)
+	assert.Equal(t, johnID, response.User.ID)
+
+	// The identity cannot be linked to another user.
+	fixture.users.Users["mary"] = &models.User{ID: 2, Login: "mary"}
+	maryID := uint64(2)
+	_, err = fixture.login(t, &maryID, jwt.MapClaims{"sub": "s1"})
+	_, ok := err.(*apperrors.ObjectInInvalidStateError)
+	assert.True(t, ok, "expected an invalid state error, got %v", err)
+}
+
+func TestConfirmOIDCLinkRequiresTheSameUser(t *testing.T) {
+	fixture := newOIDCFixture(t, false, false)
+	fixture.users.Users["mary"] = &models.User{ID: 2, Login: "mary"}
+	johnID := uint64(1)
+
+	response, err := fixture.login(t, &johnID, jwt.MapClaims{"sub": "s1"})
+	assert.Nil(t, err)
+
+	// Another user cannot confirm the link, and the token is used once.
+	err = fixture.service.ConfirmOIDCLink(2, &models.OIDCLinkRequest{LinkToken: response.OIDCLinkToken})
+	_, ok := err.(*apperrors.ObjectInInvalidStateError)
+	assert.True(t, ok, "expected an invalid state error, got %v", err)
+
+	err = fixture.service.ConfirmOIDCLink(johnID, &models.OIDCLinkRequest{LinkToken: response.OIDCLinkToken})
+	_, ok = err.(*apperrors.ObjectInInvalidStateError)
+	assert.True(t, ok, "expected an invalid state error, got %v", err)
+
+	// An expired link is not confirmed.
+	response, err = fixture.login(t, &johnID, jwt.MapClaims{"sub": "s1"})
+	assert.Nil(t, err)
+	fixture.clock.Advance(11 * time.Minute)
+	err = fixture.service.ConfirmOIDCLink(johnID, &models.OIDCLinkRequest{LinkToken: response.OIDCLinkToken})
+	_, ok = err.(*apperrors.ObjectInInvalidStateError)
+	assert.True(t, ok, "expected an invalid state error, got %v", err)
+	assert.Empty(t, fixture.tokens.identities)
+}
+
+func TestAuthenticateOIDCLinksVerifiedEmail(t *testing.T) {
+	fixture := newOIDCFixture(t, true, true)
+
+	// An unverified email neither links the user nor is kept by the created
+	// user, so it cannot make the email ambiguous for the next login.
+	response, err := fixture.login(t, nil, jwt.MapClaims{"sub": "s1", "email": "john@test.com", "email_verified": false})
+	assert.Nil(t, err)
+	assert.NotEqual(t, uint64(1), response.User.ID)
+
+	response, err = fixture.login(t, nil, jwt.MapClaims{"sub": "s2", "email": "john@test.com", "email_verified": true})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), response.User.ID)
+}
+
+func TestStartOIDCLoginWithoutConfiguration(t *testing.T) {
+	fixture := newSSOFixture()
+
+	_, err := fixture.service.StartOIDCLogin(nil)
+	_, ok := err.(*apperrors.ObjectInInvalidStateError)
+	assert.True(t, ok, "expected an invalid state error, got %v", err)
+}
@@ -0,0 +1,111 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSessionFixture logs john in, with the access tokens checked against the
+// revocations of the fixture.
+func newSessionFixture(t *testing.T) (*mfaFixture, *models.AuthResponse) {
+	fixture := newMFAFixture(t)
+	t.Setenv("JWT_SECRET", "session-secret")
+	assert.Nil(t, auth.InitJWTAuth(fixture.tokens, auth.KeyConfig{Algorithm: "HS256"}, fixture.clock))
+	t.Cleanup(func() {
+		assert.Nil(t, auth.InitJWTAuth(&fakeTokenRepository{}, auth.KeyConfig{Algorithm: "HS256"}, fixture.clock))
+	})
+
+	return fixture, fixture.login(t, "john")
+}
+
+func (f *mfaFixture) refresh(refreshToken string) (*models.AuthResponse, error) {
+	return f.service.Refresh(&models.AuthRequestRefresh{RefreshToken: refreshToken})
+}
+
+func TestRefreshRotatesTheToken(t *testing.T) {
+	fixture, session := newSessionFixture(t)
+
+	refreshed, err := fixture.refresh(session.RefreshToken)
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, refreshed.Token)
+	assert.NotEqual(t, session.RefreshToken, refreshed.RefreshToken)
+	assert.Equal(t, "john", refreshed.User.Login)
+
+	old, next := fixture.tokens.refreshTokens[0], fixture.tokens.refreshTokens[1]
+	assert.True(t, fixture.clock.Now().Equal(*old.RevokedAt))
+	assert.True(t, fixture.clock.Now().Equal(next.CreatedAt))
+	assert.Equal(t, &next.ID, old.ReplacedByID)
+	assert.Equal(t, old.FamilyID, next.FamilyID)
+	assert.Nil(t, next.RevokedAt)
+}
+
+func TestRefreshTokenReuseRevokesTheFamily(t *testing.T) {
+	fixture, session := newSessionFixture(t)
+	refreshed, err := fixture.refresh(session.RefreshToken)
+	assert.Nil(t, err)
+
+	// The used token leaked: whoever holds the current one loses it too.
+	_, err = fixture.refresh(session.RefreshToken)
+	assertErrorType[*apperrors.UserLoginError](t, err)
+
+	_, err = fixture.refresh(refreshed.RefreshToken)
+	assertErrorType[*apperrors.UserLoginError](t, err)
+	for _, token := range fixture.tokens.refreshTokens {
+		assert.NotNil(t, token.RevokedAt)
+	}
+}
+
+func TestRefreshRejectsUnknownAndExpiredTokens(t *testing.T) {
+	fixture, session := newSessionFixture(t)
+
+	_, err := fixture.refresh("unknown")
+	assertErrorType[*apperrors.UserLoginError](t, err)
+
+	fixture.clock.Advance(constants.RefreshTokenExpirationTime + time.Second)
+	_, err = fixture.refresh(session.RefreshToken)
+	assertErrorType[*apperrors.UserLoginError](t, err)
+}
+
+func TestLogoutRevokesTheSession(t *testing.T) {
+	fixture, session := newSessionFixture(t)
+	claims, err := auth.ValidateToken(session.Token)
+	assert.Nil(t, err)
+
+	err = fixture.service.Logout(claims, &models.LogoutRequest{RefreshToken: session.RefreshToken})
+
+	assert.Nil(t, err)
+	_, err = auth.ValidateToken(session.Token)
+	assert.NotNil(t, err)
+	_, err = fixture.refresh(session.RefreshToken)
+	assertErrorType[*apperrors.UserLoginError](t, err)
+}
+
+func TestRevokedTokensAreCached(t *testing.T) {
+	fixture, session := newSessionFixture(t)
+
+	for i := 0; i < 3; i++ {
+		_, err := auth.ValidateToken(session.Token)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, fixture.tokens.revokedTokenLoads)
+
+	// A token revoked by another instance is rejected once the cache expires.
+	claims, err := auth.GetClaims(session.Token)
+	assert.Nil(t, err)
+	assert.Nil(t, fixture.tokens.RevokeAccessToken(claims.Id, time.Unix(claims.ExpiresAt, 0)))
+
+	_, err = auth.ValidateToken(session.Token)
+	assert.Nil(t, err)
+
+	fixture.clock.Advance(constants.RevokedTokensCacheTime)
+	_, err = auth.ValidateToken(session.Token)
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, fixture.tokens.revokedTokenLoads)
+}
@@ -0,0 +1,551 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errRefreshTokenAlreadyUsed = errors.New("refresh token already used")
+	errIdentityLinked          = errors.New("identity already linked")
+)
+
+type (
+	Repository interface {
+		SaveRefreshToken(token *models.RefreshToken) error
+		GetRefreshTokenByHash(hash string) (*models.RefreshToken, error)
+		RotateRefreshToken(old *models.RefreshToken, new *models.RefreshToken, now time.Time) error
+		RevokeRefreshTokenFamily(familyID string, now time.Time) error
+		RevokeAccessToken(jti string, expiresAt time.Time) error
+		GetRevokedAccessTokens(now time.Time) ([]string, error)
+		UseSSOToken(applicationID uint64, jti string, expiresAt time.Time) (bool, error)
+		DeleteExpiredTokens(now time.Time) error
+		GetSigningKeys(now time.Time) (*[]models.SigningKey, error)
+		SaveSigningKey(key *models.SigningKey) error
+		DeleteExpiredSigningKeys(now time.Time) error
+		WithSigningKeyLock(fn func() error) error
+		SaveOIDCLogin(login *models.OIDCLogin) error
+		ConsumeOIDCLogin(stateHash string) (*models.OIDCLogin, error)
+		GetUserIdentity(issuer string, subject string) (*models.UserIdentity, error)
+		SaveUserIdentity(identity *models.UserIdentity) (bool, error)
+		SaveUserWithIdentity(user *models.User, identity *models.UserIdentity) (bool, error)
+		SaveOIDCLink(link *models.OIDCLink) error
+		ConsumeOIDCLink(tokenHash string) (*models.OIDCLink, error)
+		GetMFA(userID uint64) (*models.UserMFA, error)
+		SaveMFA(mfa *models.UserMFA) error
+		ConfirmMFA(mfa *models.UserMFA, codes []models.MFARecoveryCode) error
+		DeleteMFA(userID uint64, now time.Time) error
+		UseMFAStep(userID uint64, step int64) (bool, error)
+		ReplaceRecoveryCodes(userID uint64, codes []models.MFARecoveryCode) error
+		UseRecoveryCode(userID uint64, hash string, now time.Time) (bool, error)
+		SaveMFAChallenge(challenge *models.MFAChallenge) error
+		GetMFAChallenge(hash string) (*models.MFAChallenge, error)
+		FailMFAChallenge(hash string) error
+		DeleteMFAChallenge(hash string) (bool, error)
+		ReserveLoginAttempts(keys []string, now time.Time, since time.Time, check func(attempts []models.LoginAttempt) error) (*[]models.LoginAttempt, error)
+		ReleaseLoginAttempts(keys ...string) error
+		LockLogin(key string, until time.Time) error
+		ResetLoginAttempts(keys ...string) error
+		ResetLoginAttemptsByPrefix(prefix string) error
+		TrustLoginIP(userID uint64, ip string, now time.Time) error
+		IsLoginIPTrusted(userID uint64, ip string, since time.Time) (bool, error)
+		SaveAuthAudit(entry *models.AuthAudit) error
+	}
+
+	repository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r repository) SaveRefreshToken(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r repository) GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Where(&models.RefreshToken{TokenHash: hash}).First(&token).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &token, err
+}
+
+// RotateRefreshToken marks old as used and stores new in a single
+// transaction. The update only succeeds while old is still active, so two
+// concurrent refreshes with the same token cannot both win.
+func (r repository) RotateRefreshToken(old *models.RefreshToken, new *models.RefreshToken, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(new).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? and revoked_at is null", old.ID).
+			Updates(map[string]interface{}{"revoked_at": now, "replaced_by_id": new.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errRefreshTokenAlreadyUsed
+		}
+
+		return nil
+	})
+}
+
+func (r repository) RevokeRefreshTokenFamily(familyID string, now time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? and revoked_at is null", familyID).
+		Update("revoked_at", now).
+		Error
+}
+
+func (r repository) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	token := models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&token).Error
+}
+
+// GetRevokedAccessTokens returns the ids of the revoked access tokens not
+// expired at now.
+func (r repository) GetRevokedAccessTokens(now time.Time) ([]string, error) {
+	var jtis []string
+	err := r.db.Model(&models.RevokedToken{}).Where("expires_at > ?", now).Pluck("jti", &jtis).Error
+	return jtis, err
+}
+
+// UseSSOToken records the jti of an SSO token of the application and tells
+// if it was already used. The insert is atomic, so a token replayed
+// concurrently is used only once.
+func (r repository) UseSSOToken(applicationID uint64, jti string, expiresAt time.Time) (bool, error) {
+	use := models.SSOTokenUse{ApplicationID: applicationID, JTI: jti, ExpiresAt: expiresAt}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&use)
+	return result.RowsAffected == 0, result.Error
+}
+
+func (r repository) DeleteExpiredTokens(now time.Time) error {
+	err := r.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Where("expires_at < ?", now).Delete(&models.SSOTokenUse{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Where("expires_at < ?", now).Delete(&models.OIDCLogin{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Where("expires_at < ?", now).Delete(&models.OIDCLink{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Where("expires_at < ?", now).Delete(&models.MFAChallenge{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = r.db.
+		Where("last_login_at < ?", now.Add(-constants.TrustedLoginIPLifetime)).
+		Delete(&models.TrustedLoginIP{}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	err = r.db.
+		Where("last_failure_at < ?", now.Add(-constants.LoginAttemptWindow)).
+		Where("locked_until is null or locked_until < ?", now).
+		Delete(&models.LoginAttempt{}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	return r.db.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error
+}
+
+// GetSigningKeys returns the signing keys not expired at now.
+func (r repository) GetSigningKeys(now time.Time) (*[]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := r.db.Where("expires_at > ?", now).Order("activates_at, id").Find(&keys).Error
+	return &keys, err
+}
+
+func (r repository) SaveSigningKey(key *models.SigningKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r repository) DeleteExpiredSigningKeys(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&models.SigningKey{}).Error
+}
+
+// WithSigningKeyLock runs fn while no other instance rotates the signing
+// keys, so only one of them creates the next key.
+func (r repository) WithSigningKeyLock(fn func() error) error {
+	acquired, err := utils.WithLock(r.db, constants.SigningKeyLockName, constants.SigningKeyLockTimeout, fn)
+	if err == nil && !acquired {
+		err = errors.New("timed out waiting for the signing key lock")
+	}
+	return err
+}
+
+func (r repository) SaveOIDCLogin(login *models.OIDCLogin) error {
+	return r.db.Create(login).Error
+}
+
+// ConsumeOIDCLogin returns the login with the state hash and deletes it. Only
+// the delete that removes the login returns it, so a state is used once even
+// by concurrent callbacks.
+func (r repository) ConsumeOIDCLogin(stateHash string) (*models.OIDCLogin, error) {
+	var login *models.OIDCLogin
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var found models.OIDCLogin
+		err := tx.Where("state_hash = ?", stateHash).First(&found).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("state_hash = ?", stateHash).Delete(&models.OIDCLogin{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 1 {
+			login = &found
+		}
+		return nil
+	})
+	return login, err
+}
+
+func (r repository) GetUserIdentity(issuer string, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.Where("issuer = ? and subject = ?", issuer, subject).First(&identity).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &identity, err
+}
+
+// SaveUserIdentity links the subject to the user. It returns false when the
+// subject was linked to a user in the meantime.
+func (r repository) SaveUserIdentity(identity *models.UserIdentity) (bool, error) {
+	return saveUserIdentity(r.db, identity)
+}
+
+// SaveUserWithIdentity creates the user of a subject seen for the first time
+// and links the subject to it, in a single transaction. It returns false and
+// creates no user when the subject was linked to a user in the meantime, as
+// by a concurrent first login.
+func (r repository) SaveUserWithIdentity(user *models.User, identity *models.UserIdentity) (bool, error) {
+	saved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		identity.UserID = user.ID
+		linked, err := saveUserIdentity(tx, identity)
+		if err != nil {
+			return err
+		}
+
+		if !linked {
+			return errIdentityLinked
+		}
+
+		saved = true
+		return nil
+	})
+
+	if errors.Is(err, errIdentityLinked) {
+		return false, nil
+	}
+
+	return saved, err
+}
+
+func saveUserIdentity(db *gorm.DB, identity *models.UserIdentity) (bool, error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(identity)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r repository) SaveOIDCLink(link *models.OIDCLink) error {
+	return r.db.Create(link).Error
+}
+
+// ConsumeOIDCLink returns the link with the token hash and deletes it, so a
+// link is confirmed once even by concurrent requests.
+func (r repository) ConsumeOIDCLink(tokenHash string) (*models.OIDCLink, error) {
+	var link *models.OIDCLink
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var found models.OIDCLink
+		err := tx.Where("token_hash = ?", tokenHash).First(&found).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("token_hash = ?", tokenHash).Delete(&models.OIDCLink{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 1 {
+			link = &found
+		}
+		return nil
+	})
+	return link, err
+}
+
+func (r repository) GetMFA(userID uint64) (*models.UserMFA, error) {
+	var mfa models.UserMFA
+	err := r.db.Where("user_id = ?", userID).First(&mfa).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &mfa, err
+}
+
+// SaveMFA stores the secret of the user, replacing the previous one.
+func (r repository) SaveMFA(mfa *models.UserMFA) error {
+	return r.db.Save(mfa).Error
+}
+
+// ConfirmMFA stores the confirmed secret with its recovery codes.
+func (r repository) ConfirmMFA(mfa *models.UserMFA, codes []models.MFARecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(mfa).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, mfa.UserID, codes)
+	})
+}
+
+// DeleteMFA removes the MFA of the user and revokes the refresh tokens of the
+// user, so the sessions opened with the removed factor end with it.
+func (r repository) DeleteMFA(userID uint64, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? and revoked_at is null", userID).
+			Update("revoked_at", now).
+			Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ?", userID).Delete(&models.MFAChallenge{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error
+	})
+}
+
+// UseMFAStep records the period of an accepted code and tells if it was the
+// first use. The update only succeeds for a period after the last one used,
+// so a code cannot be used twice, even concurrently.
+func (r repository) UseMFAStep(userID uint64, step int64) (bool, error) {
+	result := r.db.Model(&models.UserMFA{}).
+		Where("user_id = ? and last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r repository) ReplaceRecoveryCodes(userID uint64, codes []models.MFARecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint64, codes []models.MFARecoveryCode) error {
+	err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&codes).Error
+}
+
+// UseRecoveryCode marks the unused recovery code of the user as used and
+// tells if it succeeded.
+func (r repository) UseRecoveryCode(userID uint64, hash string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? and code_hash = ? and used_at is null", userID, hash).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r repository) SaveMFAChallenge(challenge *models.MFAChallenge) error {
+	return r.db.Create(challenge).Error
+}
+
+func (r repository) GetMFAChallenge(hash string) (*models.MFAChallenge, error) {
+	var challenge models.MFAChallenge
+	err := r.db.Where("token_hash = ?", hash).First(&challenge).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &challenge, err
+}
+
+func (r repository) FailMFAChallenge(hash string) error {
+	return r.db.Model(&models.MFAChallenge{}).
+		Where("token_hash = ?", hash).
+		Update("attempts", gorm.Expr("attempts + 1")).
+		Error
+}
+
+// DeleteMFAChallenge removes the challenge and tells if it existed, so only
+// one request completes it.
+func (r repository) DeleteMFAChallenge(hash string) (bool, error) {
+	result := r.db.Where("token_hash = ?", hash).Delete(&models.MFAChallenge{})
+	return result.RowsAffected == 1, result.Error
+}
+
+// ReserveLoginAttempts counts an attempt of each key before the password is
+// checked, as a failure to be released if the login succeeds. The attempts
+// of the keys are locked while check decides whether the login may be tried,
+// so concurrent logins are checked one at a time and none of them is counted
+// when check refuses it. The failures before since are forgotten.
+func (r repository) ReserveLoginAttempts(
+	keys []string,
+	now time.Time,
+	since time.Time,
+	check func(attempts []models.LoginAttempt) error,
+) (*[]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		missing := make([]models.LoginAttempt, len(keys))
+		for i, key := range keys {
+			missing[i] = models.LoginAttempt{AttemptKey: key, LastFailureAt: now}
+		}
+
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&missing).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("attempt_key in ?", keys).
+			Order("attempt_key").
+			Find(&attempts).
+			Error
+		if err != nil {
+			return err
+		}
+
+		if err = check(attempts); err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.LoginAttempt{}).
+			Where("attempt_key in ?", keys).
+			Updates(map[string]interface{}{
+				// The failures are updated first, while last_failure_at is
+				// still the previous failure.
+				"failures":        gorm.Expr("IF(last_failure_at < ?, 1, failures + 1)", since),
+				"last_failure_at": now,
+			}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		for i := range attempts {
+			if attempts[i].LastFailureAt.Before(since) {
+				attempts[i].Failures = 0
+			}
+			attempts[i].Failures++
+			attempts[i].LastFailureAt = now
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempts, nil
+}
+
+// ReleaseLoginAttempts uncounts the attempts reserved for a login that did
+// not fail.
+func (r repository) ReleaseLoginAttempts(keys ...string) error {
+	return r.db.Model(&models.LoginAttempt{}).
+		Where("attempt_key in ? and failures > 0", keys).
+		Update("failures", gorm.Expr("failures - 1")).
+		Error
+}
+
+func (r repository) LockLogin(key string, until time.Time) error {
+	return r.db.Model(&models.LoginAttempt{}).
+		Where("attempt_key = ?", key).
+		Update("locked_until", until).
+		Error
+}
+
+func (r repository) ResetLoginAttempts(keys ...string) error {
+	return r.db.Where("attempt_key in ?", keys).Delete(&models.LoginAttempt{}).Error
+}
+
+func (r repository) ResetLoginAttemptsByPrefix(prefix string) error {
+	return r.db.Where("attempt_key LIKE ?", utils.EscapeLike(prefix)+"%").Delete(&models.LoginAttempt{}).Error
+}
+
+// TrustLoginIP records that the user logged in from the IP.
+func (r repository) TrustLoginIP(userID uint64, ip string, now time.Time) error {
+	trusted := models.TrustedLoginIP{UserID: userID, IP: ip, LastLoginAt: now}
+	return r.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"last_login_at"}),
+	}).Create(&trusted).Error
+}
+
+// IsLoginIPTrusted tells if the user logged in from the IP since the given
+// time.
+func (r repository) IsLoginIPTrusted(userID uint64, ip string, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.TrustedLoginIP{}).
+		Where("user_id = ? and ip = ? and last_login_at >= ?", userID, ip, since).
+		Count(&count).
+		Error
+	return count > 0, err
+}
+
+func (r repository) SaveAuthAudit(entry *models.AuthAudit) error {
+	return r.db.Create(entry).Error
+}
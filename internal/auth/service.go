@@ -1,10 +1,15 @@
 package auth
 
 import (
+	"errors"
 	"log"
+	"time"
 
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/sso"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/user"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/clock"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
 )
 
@@ -12,32 +17,80 @@ type (
 	Service interface {
 		Authenticate(authRequest *models.AuthRequest) (*models.AuthResponse, error)
 		AuthenticateSSO(authRequest *models.AuthRequestSSO) (*models.AuthResponse, error)
+		StartOIDCLogin(userID *uint64) (*models.OIDCAuthorizationDTO, error)
+		AuthenticateOIDC(authRequest *models.AuthRequestOIDC) (*models.AuthResponse, error)
+		ConfirmOIDCLink(userID uint64, linkRequest *models.OIDCLinkRequest) error
+		VerifyMFA(authRequest *models.AuthRequestMFA) (*models.AuthResponse, error)
+		EnrollMFAChallenge(authRequest *models.AuthRequestMFA) (*models.MFAEnrollmentDTO, error)
+		EnrollMFA(userID uint64) (*models.MFAEnrollmentDTO, error)
+		ConfirmMFA(userID uint64, codeRequest *models.MFACodeRequest) (*models.MFARecoveryCodesDTO, error)
+		RegenerateRecoveryCodes(userID uint64, codeRequest *models.MFACodeRequest) (*models.MFARecoveryCodesDTO, error)
+		DisableMFA(userID uint64, codeRequest *models.MFACodeRequest) error
+		ResetMFA(userID uint64) error
+		UnlockLogin(actorID uint64, userID uint64) error
+		Refresh(refreshRequest *models.AuthRequestRefresh) (*models.AuthResponse, error)
+		Logout(claims *JWTClaim, logoutRequest *models.LogoutRequest) error
+		PublicKeys() *models.JWKSDTO
 	}
 
 	service struct {
-		repository user.Repository
+		repository      user.Repository
+		tokenRepository Repository
+		ssoRepository   sso.Repository
+		oidc            *OIDCConfig
+		mfa             MFAConfig
+		clock           clock.Clock
 	}
 )
 
-func NewService(repository user.Repository) Service {
-	return &service{repository}
+func NewService(
+	repository user.Repository,
+	tokenRepository Repository,
+	ssoRepository sso.Repository,
+	oidc *OIDCConfig,
+	mfa MFAConfig,
+	clock clock.Clock,
+) Service {
+	return &service{repository, tokenRepository, ssoRepository, oidc, mfa, clock}
 }
 
+// Authenticate logs the user in with the password. Failed logins slow down
+// and then lock further attempts of the user, except from the IPs the user
+// logged in from, of the user at the client IP and of the client IP.
 func (s service) Authenticate(authRequest *models.AuthRequest) (*models.AuthResponse, error) {
-	return s.authenticate(authRequest.Login, authRequest.Password, false)
+	user, err := s.repository.GetByLogin(authRequest.Login)
+	if err != nil {
+		log.Printf("Error getting user to login: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting user to login")
+	}
+
+	attempt, err := s.reserveLoginAttempt(authRequest.Login, user, authRequest.ClientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil || user.CheckPassword(authRequest.Password) != nil {
+		s.failLogin(models.AuthAuditLoginFailed, attempt)
+		return nil, apperrors.NewUserLoginError()
+	}
+
+	response, err := s.authenticateWithMFA(user)
+	if err != nil || response.MFARequired {
+		s.releaseLoginAttempt(attempt)
+	} else {
+		s.succeedLogin(attempt)
+	}
+	return response, err
 }
 
 func (s service) AuthenticateSSO(authRequest *models.AuthRequestSSO) (*models.AuthResponse, error) {
-	err := ValidateTokenSSO(authRequest.APPToken, authRequest.Login)
+	err := s.verifySSOToken(authRequest.APPToken, authRequest.Login)
 	if err != nil {
+		log.Printf("Rejected SSO login of %s: %s\n", authRequest.Login, err.Error())
 		return nil, apperrors.NewUserSSOLoginError()
 	}
 
-	return s.authenticate(authRequest.Login, "", true)
-}
-
-func (s service) authenticate(login string, password string, isSSO bool) (*models.AuthResponse, error) {
-	user, err := s.repository.GetByLogin(login)
+	user, err := s.repository.GetByLogin(authRequest.Login)
 	if err != nil {
 		log.Printf("Error getting user to login: %s\n", err.Error())
 		return nil, apperrors.NewInternalError("Internal error getting user to login")
@@ -47,13 +100,153 @@ func (s service) authenticate(login string, password string, isSSO bool) (*model
 		return nil, apperrors.NewUserLoginError()
 	}
 
-	if !isSSO {
-		err = user.CheckPassword(password)
+	return s.authenticateWithMFA(user)
+}
+
+// authenticateUser issues a token pair to the already authenticated user.
+func (s service) authenticateUser(user *models.User) (*models.AuthResponse, error) {
+	refreshToken, err := s.newRefreshToken(user.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tokenRepository.SaveRefreshToken(refreshToken.model)
+	if err != nil {
+		log.Printf("Error saving refresh token for user %s: %s\n", user.Login, err.Error())
+		return nil, apperrors.NewInternalError("Internal error generating token")
+	}
+
+	return s.newAuthResponse(user, refreshToken.value)
+}
+
+// Refresh exchanges a refresh token for a new token pair. Every refresh token
+// can be used only once: presenting a used token again means it leaked, so
+// the whole token family is revoked and the user must log in again.
+func (s service) Refresh(refreshRequest *models.AuthRequestRefresh) (*models.AuthResponse, error) {
+	current, err := s.tokenRepository.GetRefreshTokenByHash(hashToken(refreshRequest.RefreshToken))
+	if err != nil {
+		log.Printf("Error getting refresh token: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error refreshing token")
+	}
+
+	now := s.clock.Now().UTC()
+	if current == nil || current.ExpiresAt.Before(now) {
+		return nil, apperrors.NewInvalidRefreshTokenError()
+	}
+
+	if current.RevokedAt != nil {
+		s.revokeRefreshTokenFamily(current)
+		return nil, apperrors.NewInvalidRefreshTokenError()
+	}
+
+	user, err := s.repository.Get(current.UserID)
+	if err != nil {
+		log.Printf("Error getting user to refresh token: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error refreshing token")
+	}
+
+	if user == nil {
+		return nil, apperrors.NewInvalidRefreshTokenError()
+	}
+
+	next, err := s.newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tokenRepository.RotateRefreshToken(current, next.model, now)
+	if errors.Is(err, errRefreshTokenAlreadyUsed) {
+		s.revokeRefreshTokenFamily(current)
+		return nil, apperrors.NewInvalidRefreshTokenError()
+	}
+
+	if err != nil {
+		log.Printf("Error rotating refresh token: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error refreshing token")
+	}
+
+	return s.newAuthResponse(user, next.value)
+}
+
+// Logout revokes the access token used in the request and, when informed,
+// the refresh token family issued with it.
+func (s service) Logout(claims *JWTClaim, logoutRequest *models.LogoutRequest) error {
+	if claims.Id != "" {
+		err := s.tokenRepository.RevokeAccessToken(claims.Id, time.Unix(claims.ExpiresAt, 0).UTC())
+		if err != nil {
+			log.Printf("Error revoking access token: %s\n", err.Error())
+			return apperrors.NewInternalError("Internal error revoking token")
+		}
+
+		rememberRevokedToken(claims.Id)
+	}
+
+	if logoutRequest.RefreshToken != "" {
+		current, err := s.tokenRepository.GetRefreshTokenByHash(hashToken(logoutRequest.RefreshToken))
+		if err != nil {
+			log.Printf("Error getting refresh token: %s\n", err.Error())
+			return apperrors.NewInternalError("Internal error revoking token")
+		}
+
+		if current != nil && current.UserID == claims.ID {
+			s.revokeRefreshTokenFamily(current)
+		}
+	}
+
+	err := s.tokenRepository.DeleteExpiredTokens(s.clock.Now().UTC())
+	if err != nil {
+		log.Printf("Error deleting expired tokens: %s\n", err.Error())
+	}
+
+	return nil
+}
+
+// PublicKeys returns the keys that verify the access tokens, so other
+// services can verify them without sharing a secret.
+func (s service) PublicKeys() *models.JWKSDTO {
+	return PublicKeys()
+}
+
+type refreshToken struct {
+	value string
+	model *models.RefreshToken
+}
+
+func (s service) newRefreshToken(userID uint64, familyID string) (*refreshToken, error) {
+	value, err := generateRandomToken(32)
+	if err != nil {
+		log.Printf("Error generating refresh token: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error generating token")
+	}
+
+	if familyID == "" {
+		familyID, err = generateRandomToken(16)
 		if err != nil {
-			return nil, apperrors.NewUserLoginError()
+			log.Printf("Error generating refresh token: %s\n", err.Error())
+			return nil, apperrors.NewInternalError("Internal error generating token")
 		}
 	}
 
+	now := s.clock.Now().UTC()
+	model := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(value),
+		ExpiresAt: now.Add(constants.RefreshTokenExpirationTime),
+		CreatedAt: now,
+	}
+
+	return &refreshToken{value, &model}, nil
+}
+
+func (s service) revokeRefreshTokenFamily(token *models.RefreshToken) {
+	err := s.tokenRepository.RevokeRefreshTokenFamily(token.FamilyID, s.clock.Now().UTC())
+	if err != nil {
+		log.Printf("Error revoking refresh token family %s: %s\n", token.FamilyID, err.Error())
+	}
+}
+
+func (s service) newAuthResponse(user *models.User, refreshToken string) (*models.AuthResponse, error) {
 	token, err := GenerateJWT(user)
 	if err != nil {
 		log.Printf("Error generating token for user %s: %s\n", user.Login, err.Error())
@@ -62,8 +255,9 @@ func (s service) authenticate(login string, password string, isSSO bool) (*model
 
 	user.Password = ""
 	response := models.AuthResponse{
-		User:  user,
-		Token: token,
+		User:         user,
+		Token:        token,
+		RefreshToken: refreshToken,
 	}
 
 	return &response, nil
@@ -0,0 +1,600 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const ssoSecret = "0123456789abcdef0123456789abcdef"
+
+type fakeTokenRepository struct {
+	refreshTokens []models.RefreshToken
+	revokedTokens map[string]time.Time
+	// revokedTokenLoads counts the loads of the revoked tokens.
+	revokedTokenLoads int
+	usedSSOTokens     map[string]time.Time
+	signingKeys       []models.SigningKey
+	oidcLogins        map[string]models.OIDCLogin
+	oidcLinks         map[string]models.OIDCLink
+	identities        []models.UserIdentity
+	// users saves the users created with an identity.
+	users *testutil.UserRepository
+	// beforeSaveIdentity runs before an identity is saved, as a concurrent
+	// login would.
+	beforeSaveIdentity func()
+	mfa                map[uint64]models.UserMFA
+	recoveryCodes      []models.MFARecoveryCode
+	challenges         map[string]models.MFAChallenge
+	loginAttempts      map[string]models.LoginAttempt
+	trustedIPs         map[string]time.Time
+	audits             []models.AuthAudit
+	// mu guards the login attempts, audits and signing keys used
+	// concurrently, and signingKeyLock stands for the database lock.
+	mu             sync.Mutex
+	signingKeyLock sync.Mutex
+}
+
+func (r *fakeTokenRepository) SaveRefreshToken(token *models.RefreshToken) error {
+	token.ID = uint64(len(r.refreshTokens) + 1)
+	r.refreshTokens = append(r.refreshTokens, *token)
+	return nil
+}
+
+func (r *fakeTokenRepository) GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	for _, token := range r.refreshTokens {
+		if token.TokenHash == hash {
+			found := token
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeTokenRepository) RotateRefreshToken(old *models.RefreshToken, new *models.RefreshToken, now time.Time) error {
+	if err := r.SaveRefreshToken(new); err != nil {
+		return err
+	}
+
+	stored := &r.refreshTokens[old.ID-1]
+	stored.RevokedAt = &now
+	stored.ReplacedByID = &new.ID
+	return nil
+}
+
+func (r *fakeTokenRepository) RevokeRefreshTokenFamily(familyID string, now time.Time) error {
+	for i := range r.refreshTokens {
+		if r.refreshTokens[i].FamilyID == familyID && r.refreshTokens[i].RevokedAt == nil {
+			r.refreshTokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeTokenRepository) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	if r.revokedTokens == nil {
+		r.revokedTokens = map[string]time.Time{}
+	}
+	r.revokedTokens[jti] = expiresAt
+	return nil
+}
+
+func (r *fakeTokenRepository) GetRevokedAccessTokens(now time.Time) ([]string, error) {
+	r.revokedTokenLoads++
+	jtis := []string{}
+	for jti, expiresAt := range r.revokedTokens {
+		if expiresAt.After(now) {
+			jtis = append(jtis, jti)
+		}
+	}
+	return jtis, nil
+}
+
+func (r *fakeTokenRepository) UseSSOToken(applicationID uint64, jti string, expiresAt time.Time) (bool, error) {
+	key := fmt.Sprintf("%d:%s", applicationID, jti)
+	if _, ok := r.usedSSOTokens[key]; ok {
+		return true, nil
+	}
+	r.usedSSOTokens[key] = expiresAt
+	return false, nil
+}
+
+func (r *fakeTokenRepository) DeleteExpiredTokens(now time.Time) error { return nil }
+
+func (r *fakeTokenRepository) GetSigningKeys(now time.Time) (*[]models.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := []models.SigningKey{}
+	for _, key := range r.signingKeys {
+		if key.ExpiresAt.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	return &keys, nil
+}
+
+func (r *fakeTokenRepository) SaveSigningKey(key *models.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key.ID = uint64(len(r.signingKeys) + 1)
+	r.signingKeys = append(r.signingKeys, *key)
+	return nil
+}
+
+func (r *fakeTokenRepository) DeleteExpiredSigningKeys(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := []models.SigningKey{}
+	for _, key := range r.signingKeys {
+		if key.ExpiresAt.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	r.signingKeys = keys
+	return nil
+}
+
+func (r *fakeTokenRepository) WithSigningKeyLock(fn func() error) error {
+	r.signingKeyLock.Lock()
+	defer r.signingKeyLock.Unlock()
+
+	return fn()
+}
+
+func (r *fakeTokenRepository) SaveOIDCLogin(login *models.OIDCLogin) error {
+	if r.oidcLogins == nil {
+		r.oidcLogins = map[string]models.OIDCLogin{}
+	}
+	r.oidcLogins[login.StateHash] = *login
+	return nil
+}
+
+func (r *fakeTokenRepository) ConsumeOIDCLogin(stateHash string) (*models.OIDCLogin, error) {
+	login, ok := r.oidcLogins[stateHash]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.oidcLogins, stateHash)
+	return &login, nil
+}
+
+func (r *fakeTokenRepository) GetUserIdentity(issuer string, subject string) (*models.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			stored := identity
+			return &stored, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeTokenRepository) SaveUserIdentity(identity *models.UserIdentity) (bool, error) {
+	if r.beforeSaveIdentity != nil {
+		r.beforeSaveIdentity()
+		r.beforeSaveIdentity = nil
+	}
+
+	if existing, _ := r.GetUserIdentity(identity.Issuer, identity.Subject); existing != nil {
+		return false, nil
+	}
+
+	identity.ID = uint64(len(r.identities) + 1)
+	r.identities = append(r.identities, *identity)
+	return true, nil
+}
+
+// SaveUserWithIdentity saves nothing when the identity is already linked, as
+// the rolled back transaction does.
+func (r *fakeTokenRepository) SaveUserWithIdentity(user *models.User, identity *models.UserIdentity) (bool, error) {
+	if r.beforeSaveIdentity != nil {
+		r.beforeSaveIdentity()
+		r.beforeSaveIdentity = nil
+	}
+
+	if existing, _ := r.GetUserIdentity(identity.Issuer, identity.Subject); existing != nil {
+		return false, nil
+	}
+
+	if err := r.users.Save(user); err != nil {
+		return false, err
+	}
+	identity.UserID = user.ID
+	return r.SaveUserIdentity(identity)
+}
+
+func (r *fakeTokenRepository) SaveOIDCLink(link *models.OIDCLink) error {
+	if r.oidcLinks == nil {
+		r.oidcLinks = map[string]models.OIDCLink{}
+	}
+	r.oidcLinks[link.TokenHash] = *link
+	return nil
+}
+
+func (r *fakeTokenRepository) ConsumeOIDCLink(tokenHash string) (*models.OIDCLink, error) {
+	link, ok := r.oidcLinks[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.oidcLinks, tokenHash)
+	return &link, nil
+}
+
+func (r *fakeTokenRepository) GetMFA(userID uint64) (*models.UserMFA, error) {
+	mfa, ok := r.mfa[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &mfa, nil
+}
+
+func (r *fakeTokenRepository) SaveMFA(mfa *models.UserMFA) error {
+	if r.mfa == nil {
+		r.mfa = map[uint64]models.UserMFA{}
+	}
+	r.mfa[mfa.UserID] = *mfa
+	return nil
+}
+
+func (r *fakeTokenRepository) ConfirmMFA(mfa *models.UserMFA, codes []models.MFARecoveryCode) error {
+	_ = r.SaveMFA(mfa)
+	return r.ReplaceRecoveryCodes(mfa.UserID, codes)
+}
+
+func (r *fakeTokenRepository) DeleteMFA(userID uint64, now time.Time) error {
+	delete(r.mfa, userID)
+	_ = r.ReplaceRecoveryCodes(userID, nil)
+
+	for i := range r.refreshTokens {
+		if r.refreshTokens[i].UserID == userID && r.refreshTokens[i].RevokedAt == nil {
+			r.refreshTokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeTokenRepository) UseMFAStep(userID uint64, step int64) (bool, error) {
+	mfa, ok := r.mfa[userID]
+	if !ok || mfa.LastUsedStep >= step {
+		return false, nil
+	}
+	mfa.LastUsedStep = step
+	r.mfa[userID] = mfa
+	return true, nil
+}
+
+func (r *fakeTokenRepository) ReplaceRecoveryCodes(userID uint64, codes []models.MFARecoveryCode) error {
+	kept := []models.MFARecoveryCode{}
+	for _, code := range r.recoveryCodes {
+		if code.UserID != userID {
+			kept = append(kept, code)
+		}
+	}
+	r.recoveryCodes = append(kept, codes...)
+	return nil
+}
+
+func (r *fakeTokenRepository) UseRecoveryCode(userID uint64, hash string, now time.Time) (bool, error) {
+	for i, code := range r.recoveryCodes {
+		if code.UserID == userID && code.CodeHash == hash && code.UsedAt == nil {
+			r.recoveryCodes[i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeTokenRepository) SaveMFAChallenge(challenge *models.MFAChallenge) error {
+	if r.challenges == nil {
+		r.challenges = map[string]models.MFAChallenge{}
+	}
+	r.challenges[challenge.TokenHash] = *challenge
+	return nil
+}
+
+func (r *fakeTokenRepository) GetMFAChallenge(hash string) (*models.MFAChallenge, error) {
+	challenge, ok := r.challenges[hash]
+	if !ok {
+		return nil, nil
+	}
+	return &challenge, nil
+}
+
+func (r *fakeTokenRepository) FailMFAChallenge(hash string) error {
+	if challenge, ok := r.challenges[hash]; ok {
+		challenge.Attempts++
+		r.challenges[hash] = challenge
+	}
+	return nil
+}
+
+func (r *fakeTokenRepository) DeleteMFAChallenge(hash string) (bool, error) {
+	_, ok := r.challenges[hash]
+	delete(r.challenges, hash)
+	return ok, nil
+}
+
+func (r *fakeTokenRepository) ReserveLoginAttempts(
+	keys []string,
+	now time.Time,
+	since time.Time,
+	check func(attempts []models.LoginAttempt) error,
+) (*[]models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.loginAttempts == nil {
+		r.loginAttempts = map[string]models.LoginAttempt{}
+	}
+
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+	attempts := make([]models.LoginAttempt, len(sorted))
+	for i, key := range sorted {
+		attempt, ok := r.loginAttempts[key]
+		if !ok {
+			attempt = models.LoginAttempt{AttemptKey: key, LastFailureAt: now}
+		}
+		attempts[i] = attempt
+	}
+
+	if err := check(attempts); err != nil {
+		return nil, err
+	}
+
+	for i := range attempts {
+		if attempts[i].LastFailureAt.Before(since) {
+			attempts[i].Failures = 0
+		}
+		attempts[i].Failures++
+		attempts[i].LastFailureAt = now
+		r.loginAttempts[attempts[i].AttemptKey] = attempts[i]
+	}
+	return &attempts, nil
+}
+
+func (r *fakeTokenRepository) ReleaseLoginAttempts(keys ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range keys {
+		if attempt, ok := r.loginAttempts[key]; ok && attempt.Failures > 0 {
+			attempt.Failures--
+			r.loginAttempts[key] = attempt
+		}
+	}
+	return nil
+}
+
+func (r *fakeTokenRepository) LockLogin(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt := r.loginAttempts[key]
+	attempt.LockedUntil = &until
+	r.loginAttempts[key] = attempt
+	return nil
+}
+
+func (r *fakeTokenRepository) ResetLoginAttempts(keys ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range keys {
+		delete(r.loginAttempts, key)
+	}
+	return nil
+}
+
+func (r *fakeTokenRepository) ResetLoginAttemptsByPrefix(prefix string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key := range r.loginAttempts {
+		if strings.HasPrefix(key, prefix) {
+			delete(r.loginAttempts, key)
+		}
+	}
+	return nil
+}
+
+func (r *fakeTokenRepository) TrustLoginIP(userID uint64, ip string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.trustedIPs == nil {
+		r.trustedIPs = map[string]time.Time{}
+	}
+	r.trustedIPs[fmt.Sprintf("%d:%s", userID, ip)] = now
+	return nil
+}
+
+func (r *fakeTokenRepository) IsLoginIPTrusted(userID uint64, ip string, since time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lastLoginAt, ok := r.trustedIPs[fmt.Sprintf("%d:%s", userID, ip)]
+	return ok && !lastLoginAt.Before(since), nil
+}
+
+func (r *fakeTokenRepository) SaveAuthAudit(entry *models.AuthAudit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.audits = append(r.audits, *entry)
+	return nil
+}
+
+type ssoFixture struct {
+	service auth.Service
+	tokens  *fakeTokenRepository
+	now     time.Time
+}
+
+func newSSOFixture(apps ...models.SSOApplication) *ssoFixture {
+	return newSSOFixtureWithMFA(auth.MFAConfig{}, apps...)
+}
+
+func newSSOFixtureWithMFA(mfa auth.MFAConfig, apps ...models.SSOApplication) *ssoFixture {
+	now := time.Now().Truncate(time.Second)
+	users := &testutil.UserRepository{Users: map[string]*models.User{
+		"john": {ID: 1, Login: "john", Email: "john@test.com", Role: models.UserRoleMember},
+	}}
+	tokens := &fakeTokenRepository{usedSSOTokens: map[string]time.Time{}}
+	service := auth.NewService(users, tokens, &testutil.SSORepository{Apps: apps}, nil, mfa, &testutil.Clock{Current: now})
+	return &ssoFixture{service, tokens, now}
+}
+
+func (f *ssoFixture) claims(jti string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": "https://partner.test",
+		"sub": "john",
+		"aud": "list-manager",
+		"jti": jti,
+		"iat": f.now.Unix(),
+		"exp": f.now.Add(2 * time.Minute).Unix(),
+	}
+}
+
+func (f *ssoFixture) authenticate(token string) (*models.AuthResponse, error) {
+	return f.service.AuthenticateSSO(&models.AuthRequestSSO{Login: "john", APPToken: token})
+}
+
+func hmacApplication() models.SSOApplication {
+	secret := ssoSecret
+	return models.SSOApplication{
+		ID: 1, Issuer: "https://partner.test", Audience: "list-manager", Algorithm: "HS256",
+		Secret: &secret, Enabled: true,
+	}
+}
+
+func signHMAC(t *testing.T, claims jwt.MapClaims, secret string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	assert.Nil(t, err)
+	return token
+}
+
+func assertSSOLoginError(t *testing.T, err error) {
+	_, ok := err.(*apperrors.UserLoginError)
+	assert.True(t, ok, "expected an SSO login error, got %v", err)
+}
+
+func TestAuthenticateSSOWithHMACApplication(t *testing.T) {
+	fixture := newSSOFixture(hmacApplication())
+
+	response, err := fixture.authenticate(signHMAC(t, fixture.claims("a1"), ssoSecret))
+
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), response.User.ID)
+	assert.NotEmpty(t, response.Token)
+	assert.Contains(t, fixture.tokens.usedSSOTokens, "1:a1")
+}
+
+func TestAuthenticateSSORejectsReplayedToken(t *testing.T) {
+	fixture := newSSOFixture(hmacApplication())
+	token := signHMAC(t, fixture.claims("a1"), ssoSecret)
+
+	_, err := fixture.authenticate(token)
+	assert.Nil(t, err)
+
+	_, err = fixture.authenticate(token)
+	assertSSOLoginError(t, err)
+}
+
+func TestAuthenticateSSORejectsInvalidTokens(t *testing.T) {
+	disabled := hmacApplication()
+	disabled.ID = 2
+	disabled.Issuer = "https://disabled.test"
+	disabled.Enabled = false
+	fixture := newSSOFixture(hmacApplication(), disabled)
+
+	user := models.User{ID: 1, Login: "john", Role: models.UserRoleMember}
+	sessionToken, err := auth.GenerateJWT(&user)
+	assert.Nil(t, err)
+
+	tests := map[string]func(claims jwt.MapClaims) string{
+		"session token": func(claims jwt.MapClaims) string { return sessionToken },
+		"wrong secret": func(claims jwt.MapClaims) string {
+			return signHMAC(t, claims, "another-secret-another-secret-xx")
+		},
+		"unknown issuer": func(claims jwt.MapClaims) string {
+			claims["iss"] = "https://unknown.test"
+			return signHMAC(t, claims, ssoSecret)
+		},
+		"disabled application": func(claims jwt.MapClaims) string {
+			claims["iss"] = "https://disabled.test"
+			return signHMAC(t, claims, ssoSecret)
+		},
+		"wrong audience": func(claims jwt.MapClaims) string {
+			claims["aud"] = []string{"another-app"}
+			return signHMAC(t, claims, ssoSecret)
+		},
+		"other login": func(claims jwt.MapClaims) string {
+			claims["sub"] = "mary"
+			return signHMAC(t, claims, ssoSecret)
+		},
+		"missing jti": func(claims jwt.MapClaims) string {
+			delete(claims, "jti")
+			return signHMAC(t, claims, ssoSecret)
+		},
+		"expired": func(claims jwt.MapClaims) string {
+			claims["exp"] = fixture.now.Add(-time.Minute).Unix()
+			return signHMAC(t, claims, ssoSecret)
+		},
+		"valid for too long": func(claims jwt.MapClaims) string {
+			claims["exp"] = fixture.now.Add(time.Hour).Unix()
+			return signHMAC(t, claims, ssoSecret)
+		},
+	}
+
+	for name, sign := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := fixture.authenticate(sign(fixture.claims(name)))
+			assertSSOLoginError(t, err)
+		})
+	}
+}
+
+func TestAuthenticateSSOWithPublicKeyApplication(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.Nil(t, err)
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	app := models.SSOApplication{
+		ID: 1, Issuer: "https://partner.test", Audience: "list-manager", Algorithm: "RS256",
+		PublicKey: &publicKey, Enabled: true,
+	}
+	fixture := newSSOFixture(app)
+
+	claims := fixture.claims("r1")
+	claims["aud"] = []string{"other", "list-manager"}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+	assert.Nil(t, err)
+
+	_, err = fixture.authenticate(token)
+	assert.Nil(t, err)
+
+	// The public key is not a secret: tokens signed with it as an HMAC key
+	// must not pass for the application.
+	_, err = fixture.authenticate(signHMAC(t, fixture.claims("r2"), publicKey))
+	assertSSOLoginError(t, err)
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ssoClaims are the claims of a token issued by an SSO application to log
+// one of its users in. The times are checked by verifySSOToken, against the
+// clock of the service.
+type ssoClaims struct {
+	Issuer    string             `json:"iss"`
+	Subject   string             `json:"sub"`
+	Audience  models.SSOAudience `json:"aud"`
+	ExpiresAt int64              `json:"exp"`
+	IssuedAt  int64              `json:"iat"`
+	NotBefore int64              `json:"nbf"`
+	ID        string             `json:"jti"`
+}
+
+func (c ssoClaims) Valid() error {
+	return nil
+}
+
+// verifySSOToken checks the token issued to log the login in. The issuer of
+// the token selects the registered application whose key and algorithm
+// verify it, and the token must be addressed to the audience of the
+// application. Used tokens are remembered by jti until they expire, so each
+// one logs in only once.
+func (s service) verifySSOToken(signedToken string, login string) error {
+	var unverified ssoClaims
+	_, _, err := new(jwt.Parser).ParseUnverified(signedToken, &unverified)
+	if err != nil {
+		return err
+	}
+
+	app, err := s.ssoRepository.GetByIssuer(unverified.Issuer)
+	if err != nil {
+		return err
+	}
+
+	if app == nil || !app.Enabled {
+		return fmt.Errorf("unknown SSO application '%s'", unverified.Issuer)
+	}
+
+	key, err := app.VerificationKey()
+	if err != nil {
+		return err
+	}
+
+	var claims ssoClaims
+	parser := jwt.Parser{ValidMethods: []string{app.Algorithm}, SkipClaimsValidation: true}
+	_, err = parser.ParseWithClaims(signedToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		return key, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = checkSSOClaims(&claims, app, login, s.clock.Now()); err != nil {
+		return err
+	}
+
+	// The jti is kept while the token is accepted, including the leeway.
+	keepUntil := time.Unix(claims.ExpiresAt, 0).Add(constants.SSOTokenLeeway).UTC()
+	used, err := s.tokenRepository.UseSSOToken(app.ID, claims.ID, keepUntil)
+	if err != nil {
+		return err
+	}
+
+	if used {
+		return errors.New("SSO token already used")
+	}
+
+	return nil
+}
+
+func checkSSOClaims(claims *ssoClaims, app *models.SSOApplication, login string, now time.Time) error {
+	if claims.Issuer != app.Issuer {
+		return errors.New("invalid SSO token issuer")
+	}
+
+	if !claims.Audience.Contains(app.Audience) {
+		return errors.New("invalid SSO token audience")
+	}
+
+	if claims.Subject == "" || claims.Subject != login {
+		return errors.New("invalid login sso")
+	}
+
+	if claims.ID == "" || len(claims.ID) > 255 {
+		return errors.New("SSO token without a valid jti")
+	}
+
+	if claims.ExpiresAt == 0 {
+		return errors.New("SSO token without expiration")
+	}
+
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+	if !now.Before(expiresAt.Add(constants.SSOTokenLeeway)) {
+		return errors.New("SSO token expired")
+	}
+
+	if expiresAt.After(now.Add(constants.SSOTokenMaxLifetime + constants.SSOTokenLeeway)) {
+		return errors.New("SSO token valid for too long")
+	}
+
+	if claims.NotBefore != 0 && now.Add(constants.SSOTokenLeeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return errors.New("SSO token not valid yet")
+	}
+
+	if claims.IssuedAt != 0 && now.Add(constants.SSOTokenLeeway).Before(time.Unix(claims.IssuedAt, 0)) {
+		return errors.New("SSO token issued in the future")
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package authorization
+
+import (
+	"errors"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"gorm.io/gorm"
+)
+
+type (
+	Repository interface {
+		GetList(listID uint64) (*models.List, error)
+		GetMemberRole(listID uint64, userID uint64) (models.ListRole, error)
+		GetUserRole(userID uint64) (models.UserRole, error)
+	}
+
+	repository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r repository) GetList(listID uint64) (*models.List, error) {
+	var list models.List
+	err := r.db.Select("id", "user_id").First(&list, listID).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &list, err
+}
+
+func (r repository) GetMemberRole(listID uint64, userID uint64) (models.ListRole, error) {
+	var members []models.ListMember
+	err := r.db.Where(&models.ListMember{ListID: listID, UserID: userID}).Limit(1).Find(&members).Error
+
+	if err != nil || len(members) == 0 {
+		return models.ListRoleNone, err
+	}
+
+	return members[0].Role, nil
+}
+
+func (r repository) GetUserRole(userID uint64) (models.UserRole, error) {
+	var users []models.User
+	err := r.db.Select("role").Where("id = ?", userID).Limit(1).Find(&users).Error
+
+	if err != nil || len(users) == 0 {
+		return "", err
+	}
+
+	return users[0].Role, nil
+}
@@ -0,0 +1,91 @@
+package authorization
+
+import (
+	"fmt"
+	"log"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+type (
+	Service interface {
+		GetListRole(listID uint64, userID uint64) (models.ListRole, error)
+		CheckListPermission(listID uint64, userID uint64, required models.ListRole) error
+	}
+
+	service struct {
+		repository Repository
+	}
+)
+
+func NewService(repository Repository) Service {
+	return &service{repository}
+}
+
+// GetListRole resolves the role of the user in the list, either as its owner
+// or as an invited member. Lists without an owner are shared: anonymous users
+// can view them, any logged user can edit them and administrators manage them
+// as their owners. A zero userID means an anonymous request.
+func (s service) GetListRole(listID uint64, userID uint64) (models.ListRole, error) {
+	list, err := s.repository.GetList(listID)
+	if err != nil {
+		log.Printf("Error getting list to check permission: %s\n", err.Error())
+		return models.ListRoleNone, apperrors.NewInternalError("Internal error checking list permission")
+	}
+
+	if list == nil {
+		return models.ListRoleNone, apperrors.NewNotFoundError("list", listID)
+	}
+
+	if list.Owner == nil {
+		return s.getSharedListRole(userID)
+	}
+
+	if *list.Owner == userID {
+		return models.ListRoleOwner, nil
+	}
+
+	if userID == 0 {
+		return models.ListRoleNone, nil
+	}
+
+	role, err := s.repository.GetMemberRole(listID, userID)
+	if err != nil {
+		log.Printf("Error getting list member to check permission: %s\n", err.Error())
+		return models.ListRoleNone, apperrors.NewInternalError("Internal error checking list permission")
+	}
+
+	return role, nil
+}
+
+func (s service) getSharedListRole(userID uint64) (models.ListRole, error) {
+	if userID == 0 {
+		return models.ListRoleViewer, nil
+	}
+
+	role, err := s.repository.GetUserRole(userID)
+	if err != nil {
+		log.Printf("Error getting user role to check permission: %s\n", err.Error())
+		return models.ListRoleNone, apperrors.NewInternalError("Internal error checking list permission")
+	}
+
+	if role == models.UserRoleAdmin {
+		return models.ListRoleOwner, nil
+	}
+
+	return models.ListRoleEditor, nil
+}
+
+func (s service) CheckListPermission(listID uint64, userID uint64, required models.ListRole) error {
+	role, err := s.GetListRole(listID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !role.Includes(required) {
+		return apperrors.NewForbiddenError(fmt.Sprintf("%s role required on list %d", required, listID))
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package authorization_test
+
+import (
+	"errors"
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/authorization"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRepository struct {
+	lists   map[uint64]*models.List
+	members map[uint64]models.ListRole
+	users   map[uint64]models.UserRole
+	err     error
+}
+
+func (r fakeRepository) GetList(listID uint64) (*models.List, error) {
+	return r.lists[listID], r.err
+}
+
+func (r fakeRepository) GetMemberRole(listID uint64, userID uint64) (models.ListRole, error) {
+	return r.members[userID], r.err
+}
+
+func (r fakeRepository) GetUserRole(userID uint64) (models.UserRole, error) {
+	return r.users[userID], r.err
+}
+
+func newServiceToTest() authorization.Service {
+	owner := uint64(1)
+	return authorization.NewService(fakeRepository{
+		lists: map[uint64]*models.List{
+			10: {ID: 10, Owner: &owner},
+			20: {ID: 20},
+		},
+		members: map[uint64]models.ListRole{
+			3: models.ListRoleEditor,
+		},
+		users: map[uint64]models.UserRole{
+			2: models.UserRoleMember,
+			4: models.UserRoleAdmin,
+		},
+	})
+}
+
+func TestGetListRole(t *testing.T) {
+	service := newServiceToTest()
+
+	cases := []struct {
+		name     string
+		listID   uint64
+		userID   uint64
+		expected models.ListRole
+	}{
+		{"owner", 10, 1, models.ListRoleOwner},
+		{"stranger", 10, 2, models.ListRoleNone},
+		{"member", 10, 3, models.ListRoleEditor},
+		{"anonymous on owned list", 10, 0, models.ListRoleNone},
+		{"logged user on ownerless list", 20, 2, models.ListRoleEditor},
+		{"anonymous on ownerless list", 20, 0, models.ListRoleViewer},
+		{"administrator on ownerless list", 20, 4, models.ListRoleOwner},
+		{"administrator on owned list", 10, 4, models.ListRoleNone},
+	}
+
+	for _, c := range cases {
+		role, err := service.GetListRole(c.listID, c.userID)
+		assert.Nil(t, err, c.name)
+		assert.Equal(t, c.expected, role, c.name)
+	}
+}
+
+func TestCheckListPermissionForbidden(t *testing.T) {
+	service := newServiceToTest()
+
+	err := service.CheckListPermission(10, 2, models.ListRoleViewer)
+
+	assert.IsType(t, &apperrors.ForbiddenError{}, err)
+}
+
+func TestCheckListPermissionListNotFound(t *testing.T) {
+	service := newServiceToTest()
+
+	err := service.CheckListPermission(30, 1, models.ListRoleViewer)
+
+	assert.IsType(t, &apperrors.NotFoundError{}, err)
+}
+
+func TestCheckListPermissionRepositoryError(t *testing.T) {
+	service := authorization.NewService(fakeRepository{err: errors.New("error")})
+
+	err := service.CheckListPermission(10, 1, models.ListRoleViewer)
+
+	assert.IsType(t, &apperrors.InternalError{}, err)
+}
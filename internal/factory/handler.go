@@ -2,9 +2,15 @@ package factory
 
 import (
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/field"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/item"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/list"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/member"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/sso"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/tag"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/trash"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/user"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/workflow"
 )
 
 func NewAuthHandler(service auth.Service) auth.Handler {
@@ -22,3 +28,27 @@ func NewListHandler(service list.Service) list.Handler {
 func NewItemHandler(service item.Service) item.Handler {
 	return item.NewHandler(service)
 }
+
+func NewMemberHandler(service member.Service) member.Handler {
+	return member.NewHandler(service)
+}
+
+func NewTrashHandler(service trash.Service) trash.Handler {
+	return trash.NewHandler(service)
+}
+
+func NewFieldHandler(service field.Service) field.Handler {
+	return field.NewHandler(service)
+}
+
+func NewWorkflowHandler(service workflow.Service) workflow.Handler {
+	return workflow.NewHandler(service)
+}
+
+func NewTagHandler(service tag.Service) tag.Handler {
+	return tag.NewHandler(service)
+}
+
+func NewSSOHandler(service sso.Service) sso.Handler {
+	return sso.NewHandler(service)
+}
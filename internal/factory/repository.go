@@ -1,9 +1,17 @@
 package factory
 
 import (
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/authorization"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/field"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/item"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/list"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/member"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/sso"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/tag"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/trash"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/user"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/workflow"
 	"gorm.io/gorm"
 )
 
@@ -18,3 +26,35 @@ func NewListRepository(db *gorm.DB) list.Repository {
 func NewItemRepository(db *gorm.DB) item.Repository {
 	return item.NewRepository(db)
 }
+
+func NewAuthorizationRepository(db *gorm.DB) authorization.Repository {
+	return authorization.NewRepository(db)
+}
+
+func NewMemberRepository(db *gorm.DB) member.Repository {
+	return member.NewRepository(db)
+}
+
+func NewAuthRepository(db *gorm.DB) auth.Repository {
+	return auth.NewRepository(db)
+}
+
+func NewTrashRepository(db *gorm.DB) trash.Repository {
+	return trash.NewRepository(db)
+}
+
+func NewFieldRepository(db *gorm.DB) field.Repository {
+	return field.NewRepository(db)
+}
+
+func NewWorkflowRepository(db *gorm.DB) workflow.Repository {
+	return workflow.NewRepository(db)
+}
+
+func NewTagRepository(db *gorm.DB) tag.Repository {
+	return tag.NewRepository(db)
+}
+
+func NewSSORepository(db *gorm.DB) sso.Repository {
+	return sso.NewRepository(db)
+}
@@ -2,27 +2,83 @@ package factory
 
 import (
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/authorization"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/field"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/item"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/list"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/member"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/sso"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/tag"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/trash"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/user"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/workflow"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/clock"
 )
 
-func NewAuthService(repository user.Repository) auth.Service {
-	return auth.NewService(repository)
+func NewAuthService(
+	repository user.Repository,
+	tokenRepository auth.Repository,
+	ssoRepository sso.Repository,
+	oidc *auth.OIDCConfig,
+	mfa auth.MFAConfig,
+	clock clock.Clock,
+) auth.Service {
+	return auth.NewService(repository, tokenRepository, ssoRepository, oidc, mfa, clock)
 }
 
 func NewUserService(repository user.Repository) user.Service {
 	return user.NewService(repository)
 }
 
-func NewListService(repository list.Repository) list.Service {
-	return list.NewService(repository)
+func NewAuthorizationService(repository authorization.Repository) authorization.Service {
+	return authorization.NewService(repository)
+}
+
+func NewListService(repository list.Repository, authorizationService authorization.Service) list.Service {
+	return list.NewService(repository, authorizationService)
 }
 
 func NewItemService(
 	repository item.Repository,
-	listRepository list.Repository,
+	authorizationService authorization.Service,
 	userRepository user.Repository,
+	fieldRepository field.Repository,
+	workflowRepository workflow.Repository,
+	clock clock.Clock,
 ) item.Service {
-	return item.NewService(repository, listRepository, userRepository)
+	return item.NewService(
+		repository, authorizationService, userRepository, fieldRepository, workflowRepository, clock,
+	)
+}
+
+func NewMemberService(
+	repository member.Repository,
+	authorizationService authorization.Service,
+	userRepository user.Repository,
+) member.Service {
+	return member.NewService(repository, authorizationService, userRepository)
+}
+
+func NewTrashService(repository trash.Repository, authorizationService authorization.Service) trash.Service {
+	return trash.NewService(repository, authorizationService)
+}
+
+func NewFieldService(repository field.Repository, authorizationService authorization.Service) field.Service {
+	return field.NewService(repository, authorizationService)
+}
+
+func NewWorkflowService(repository workflow.Repository, authorizationService authorization.Service) workflow.Service {
+	return workflow.NewService(repository, authorizationService)
+}
+
+func NewTagService(
+	repository tag.Repository,
+	itemRepository item.Repository,
+	authorizationService authorization.Service,
+) tag.Service {
+	return tag.NewService(repository, itemRepository, authorizationService)
+}
+
+func NewSSOService(repository sso.Repository) sso.Service {
+	return sso.NewService(repository)
 }
@@ -3,6 +3,7 @@ package middlewares
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
@@ -36,7 +37,7 @@ func PublicAuthenticate() gin.HandlerFunc {
 	return func(context *gin.Context) {
 		token := context.GetHeader("Authorization")
 		if token != "" {
-			claims, err := auth.GetClaims(token)
+			claims, err := auth.ValidateToken(token)
 			if err == nil {
 				setClaimInContext(context, claims)
 			}
@@ -45,6 +46,42 @@ func PublicAuthenticate() gin.HandlerFunc {
 	}
 }
 
+// RequireRole must run after Authenticate and aborts the request unless the
+// logged user has one of the given roles.
+func RequireRole(roles ...models.UserRole) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		value, _ := context.Get(constants.CtxClaimsKey)
+		claims, ok := value.(*auth.JWTClaim)
+		if !ok || !claims.Role.In(roles...) {
+			err := errors.New("user not allowed to access this resource")
+			context.JSON(http.StatusForbidden, models.NewHttpError(err))
+			context.Abort()
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// RequireSelfOrRole must run after Authenticate and aborts the request unless
+// the user in the given route parameter is the logged user or the logged user
+// has one of the given roles.
+func RequireSelfOrRole(param string, roles ...models.UserRole) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		value, _ := context.Get(constants.CtxClaimsKey)
+		claims, ok := value.(*auth.JWTClaim)
+		if !ok || (strconv.FormatUint(claims.ID, 10) != context.Param(param) && !claims.Role.In(roles...)) {
+			err := errors.New("user not allowed to access this resource")
+			context.JSON(http.StatusForbidden, models.NewHttpError(err))
+			context.Abort()
+			return
+		}
+
+		context.Next()
+	}
+}
+
 func setClaimInContext(context *gin.Context, claim *auth.JWTClaim) {
 	context.Set(constants.CtxUserKey, claim.ID)
+	context.Set(constants.CtxClaimsKey, claim)
 }
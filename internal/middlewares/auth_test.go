@@ -0,0 +1,46 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/auth"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/middlewares"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRouterToTest serves GET /users/:id to the given logged user, as
+// Authenticate would.
+func newRouterToTest(claims *auth.JWTClaim) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	login := func(context *gin.Context) {
+		context.Set(constants.CtxUserKey, claims.ID)
+		context.Set(constants.CtxClaimsKey, claims)
+	}
+	ok := func(context *gin.Context) {
+		context.Status(http.StatusOK)
+	}
+	router.GET("/users/:id", login, middlewares.RequireSelfOrRole("id", models.UserRoleAdmin), ok)
+	return router
+}
+
+func getUser(router *gin.Engine, path string) int {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder.Code
+}
+
+func TestRequireSelfOrRole(t *testing.T) {
+	member := newRouterToTest(&auth.JWTClaim{ID: 2, Role: models.UserRoleMember})
+	assert.Equal(t, http.StatusOK, getUser(member, "/users/2"))
+	assert.Equal(t, http.StatusForbidden, getUser(member, "/users/3"))
+	assert.Equal(t, http.StatusForbidden, getUser(member, "/users/02"))
+
+	admin := newRouterToTest(&auth.JWTClaim{ID: 1, Role: models.UserRoleAdmin})
+	assert.Equal(t, http.StatusOK, getUser(admin, "/users/3"))
+}
@@ -0,0 +1,159 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration files must follow the pattern <version>_<name>.<up|down>.sql,
+// e.g. 0001_create_initial_tables.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  uint64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+func Load(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		matches := migrationFilePattern.FindStringSubmatch(file.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s", file.Name())
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names: %s and %s", version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+
+		migration.Checksum = checksum(migration.Up)
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// SplitStatements breaks a SQL script into single statements. Semicolons
+// inside quoted strings and comments are not treated as separators.
+func SplitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote rune
+	inLineComment, inBlockComment := false, false
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case inLineComment:
+			if char == '\n' {
+				inLineComment = false
+				current.WriteRune(char)
+			}
+			continue
+		case inBlockComment:
+			if char == '*' && next == '/' {
+				inBlockComment = false
+				i++
+			}
+			continue
+		case quote != 0:
+			current.WriteRune(char)
+			if char == '\\' && next != 0 {
+				current.WriteRune(next)
+				i++
+			} else if char == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case char == '-' && next == '-', char == '#':
+			inLineComment = true
+		case char == '/' && next == '*':
+			inBlockComment = true
+			i++
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+			current.WriteRune(char)
+		case char == ';':
+			statements = appendStatement(statements, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(char)
+		}
+	}
+
+	return appendStatement(statements, current.String())
+}
+
+func appendStatement(statements []string, statement string) []string {
+	command := strings.TrimSpace(statement)
+	if command == "" {
+		return statements
+	}
+	return append(statements, command)
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
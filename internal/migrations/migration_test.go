@@ -0,0 +1,71 @@
+package migrations_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `
+		-- create table; with comment
+		CREATE TABLE a (id INT);
+		/* block; comment */
+		INSERT INTO a VALUES ('x;y');
+		INSERT INTO a VALUES ("it\"s;");
+
+	`
+
+	statements := migrations.SplitStatements(script)
+
+	assert.Equal(t, []string{
+		"CREATE TABLE a (id INT)",
+		"INSERT INTO a VALUES ('x;y')",
+		`INSERT INTO a VALUES ("it\"s;")`,
+	}, statements)
+}
+
+func TestLoadSortsAndPairsMigrations(t *testing.T) {
+	dir := t.TempDir()
+	writeMigrationFile(t, dir, "0002_second.up.sql", "CREATE TABLE b (id INT);")
+	writeMigrationFile(t, dir, "0001_first.up.sql", "CREATE TABLE a (id INT);")
+	writeMigrationFile(t, dir, "0001_first.down.sql", "DROP TABLE a;")
+	writeMigrationFile(t, dir, "README.md", "ignored")
+
+	loaded, err := migrations.Load(dir)
+
+	assert.Nil(t, err)
+	assert.Len(t, loaded, 2)
+	assert.Equal(t, uint64(1), loaded[0].Version)
+	assert.Equal(t, "first", loaded[0].Name)
+	assert.Equal(t, "DROP TABLE a;", loaded[0].Down)
+	assert.Equal(t, uint64(2), loaded[1].Version)
+	assert.Equal(t, "", loaded[1].Down)
+	assert.Len(t, loaded[0].Checksum, 64)
+}
+
+func TestLoadMissingUpScriptError(t *testing.T) {
+	dir := t.TempDir()
+	writeMigrationFile(t, dir, "0001_first.down.sql", "DROP TABLE a;")
+
+	_, err := migrations.Load(dir)
+
+	assert.NotNil(t, err)
+}
+
+func TestLoadRunsTheBaselineReconciliationFirst(t *testing.T) {
+	loaded, err := migrations.Load("../../resources/database/migrations")
+
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), loaded[0].Version)
+	assert.Equal(t, "reconcile_baseline_schema", loaded[0].Name)
+	assert.Equal(t, uint64(1), loaded[1].Version)
+}
+
+func writeMigrationFile(t *testing.T, dir string, name string, content string) {
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	assert.Nil(t, err)
+}
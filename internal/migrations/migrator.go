@@ -0,0 +1,233 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	lockName           = "list_manager_schema_migrations"
+	lockTimeoutSeconds = 60
+
+	createSchemaMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT UNSIGNED NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at DATETIME NOT NULL,
+		CONSTRAINT pk_schema_migrations_version PRIMARY KEY (version)
+	)`
+)
+
+type (
+	Migrator interface {
+		Up() (int, error)
+		Down(steps int) (int, error)
+		Status() ([]MigrationStatus, error)
+	}
+
+	migrator struct {
+		db         *gorm.DB
+		migrations []Migration
+	}
+
+	SchemaMigration struct {
+		Version   uint64 `gorm:"primaryKey;autoIncrement:false"`
+		Name      string
+		Checksum  string
+		AppliedAt time.Time
+	}
+
+	MigrationStatus struct {
+		Version   uint64
+		Name      string
+		Applied   bool
+		AppliedAt *time.Time
+		Modified  bool
+	}
+)
+
+func NewMigrator(db *gorm.DB, migrations []Migration) Migrator {
+	return &migrator{db, migrations}
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Up applies every pending migration in version order and returns how many
+// were applied. It refuses to run if an applied migration was modified.
+func (m migrator) Up() (int, error) {
+	applied := 0
+	err := m.withLock(func(conn *gorm.DB) error {
+		records, err := m.appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		if err = m.checkIntegrity(records); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := records[migration.Version]; ok {
+				continue
+			}
+
+			log.Printf("Applying migration %d_%s\n", migration.Version, migration.Name)
+			if err = execScript(conn, migration.Up); err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			record := SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Checksum:  migration.Checksum,
+				AppliedAt: time.Now().UTC(),
+			}
+			if err = conn.Create(&record).Error; err != nil {
+				return err
+			}
+			applied++
+		}
+
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last applied migrations, at most steps of them.
+func (m migrator) Down(steps int) (int, error) {
+	reverted := 0
+	err := m.withLock(func(conn *gorm.DB) error {
+		records, err := m.appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		if err = m.checkIntegrity(records); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := records[migration.Version]; !ok {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s is not reversible", migration.Version, migration.Name)
+			}
+
+			log.Printf("Reverting migration %d_%s\n", migration.Version, migration.Name)
+			if err = execScript(conn, migration.Down); err != nil {
+				return fmt.Errorf("migration %d_%s rollback failed: %w", migration.Version, migration.Name, err)
+			}
+
+			if err = conn.Delete(&SchemaMigration{}, migration.Version).Error; err != nil {
+				return err
+			}
+			reverted++
+		}
+
+		return nil
+	})
+	return reverted, err
+}
+
+func (m migrator) Status() ([]MigrationStatus, error) {
+	if err := m.db.Exec(createSchemaMigrationsTable).Error; err != nil {
+		return nil, err
+	}
+
+	records, err := m.appliedMigrations(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i] = MigrationStatus{Version: migration.Version, Name: migration.Name}
+
+		record, ok := records[migration.Version]
+		if !ok {
+			continue
+		}
+
+		appliedAt := record.AppliedAt
+		status[i].Applied = true
+		status[i].AppliedAt = &appliedAt
+		status[i].Modified = record.Checksum != migration.Checksum
+	}
+
+	return status, nil
+}
+
+func (m migrator) appliedMigrations(conn *gorm.DB) (map[uint64]SchemaMigration, error) {
+	var records []SchemaMigration
+	if err := conn.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[uint64]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func (m migrator) checkIntegrity(records map[uint64]SchemaMigration) error {
+	known := make(map[uint64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, record := range records {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("applied migration %d_%s not found in migrations directory", version, record.Name)
+		}
+
+		if migration.Checksum != record.Checksum {
+			return fmt.Errorf("applied migration %d_%s was modified (checksum mismatch)", version, record.Name)
+		}
+	}
+
+	return nil
+}
+
+// withLock runs fn on a single connection holding a MySQL named lock, so two
+// instances starting at the same time never migrate concurrently.
+func (m migrator) withLock(fn func(conn *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		var acquired sql.NullInt64
+		err := conn.Raw("SELECT GET_LOCK(?, ?)", lockName, lockTimeoutSeconds).Scan(&acquired).Error
+		if err != nil {
+			return err
+		}
+
+		if !acquired.Valid || acquired.Int64 != 1 {
+			return errors.New("timeout acquiring schema migration lock")
+		}
+		defer conn.Exec("SELECT RELEASE_LOCK(?)", lockName)
+
+		if err = conn.Exec(createSchemaMigrationsTable).Error; err != nil {
+			return err
+		}
+
+		return fn(conn)
+	})
+}
+
+func execScript(conn *gorm.DB, script string) error {
+	for _, statement := range SplitStatements(script) {
+		if err := conn.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
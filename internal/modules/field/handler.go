@@ -0,0 +1,157 @@
+package field
+
+import (
+	"errors"
+	"net/http"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Handler interface {
+		Save(c *gin.Context)
+		GetByList(c *gin.Context)
+		Update(c *gin.Context)
+		Delete(c *gin.Context)
+	}
+
+	handler struct {
+		service Service
+	}
+)
+
+func NewHandler(service Service) Handler {
+	return &handler{service}
+}
+
+func (h handler) Save(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	fieldDTO, err := getFieldFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	if fieldDTO.Type == "" {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("type cannot be empty")))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	field := models.CustomField{ListID: listID, Name: fieldDTO.Name, Type: fieldDTO.Type, Options: fieldDTO.Options}
+	err = h.service.Save(&field, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, models.NewCustomFieldDTO(&field))
+}
+
+func (h handler) GetByList(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	fields, err := h.service.GetListFields(listID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewCustomFieldsDTO(fields))
+}
+
+// Update renames the field and, for select fields, replaces its options.
+func (h handler) Update(c *gin.Context) {
+	listID, fieldID, httpErr := getListIDAndFieldIDFromRequest(c)
+	if httpErr != nil {
+		c.IndentedJSON(http.StatusBadRequest, httpErr)
+		return
+	}
+
+	fieldDTO, err := getFieldFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	field := models.CustomField{
+		ID:      fieldID,
+		ListID:  listID,
+		Name:    fieldDTO.Name,
+		Type:    fieldDTO.Type,
+		Options: fieldDTO.Options,
+	}
+	updated, err := h.service.Update(&field, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewCustomFieldDTO(updated))
+}
+
+func (h handler) Delete(c *gin.Context) {
+	listID, fieldID, httpErr := getListIDAndFieldIDFromRequest(c)
+	if httpErr != nil {
+		c.IndentedJSON(http.StatusBadRequest, httpErr)
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	err := h.service.Delete(listID, fieldID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func getFieldFromRequest(c *gin.Context) (*models.CustomFieldDTO, error) {
+	var field models.CustomFieldDTO
+
+	if err := c.BindJSON(&field); err != nil {
+		return nil, errors.New("invalid body request format")
+	}
+
+	if field.Name == "" {
+		return nil, errors.New("name cannot be empty")
+	}
+
+	return &field, nil
+}
+
+func getListIDAndFieldIDFromRequest(c *gin.Context) (uint64, uint64, *models.HttpError) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		httpErr := models.NewHttpError(err)
+		return 0, 0, &httpErr
+	}
+
+	fieldID, err := utils.GetIDFromRequest(c, "field_id")
+	if err != nil {
+		httpErr := models.NewHttpError(err)
+		return 0, 0, &httpErr
+	}
+
+	return listID, fieldID, nil
+}
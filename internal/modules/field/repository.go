@@ -0,0 +1,85 @@
+package field
+
+import (
+	"errors"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"gorm.io/gorm"
+)
+
+type (
+	Repository interface {
+		Save(field *models.CustomField) error
+		Get(id uint64) (*models.CustomField, error)
+		GetByName(listID uint64, name string) (*models.CustomField, error)
+		GetListFields(listID uint64) (*[]models.CustomField, error)
+		Update(field *models.CustomField) error
+		Delete(id uint64) error
+		IsOptionInUse(fieldID uint64, options []string) (bool, error)
+	}
+
+	repository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r repository) Save(field *models.CustomField) error {
+	return r.db.Create(field).Error
+}
+
+func (r repository) Get(id uint64) (*models.CustomField, error) {
+	var field models.CustomField
+	err := r.db.First(&field, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &field, err
+}
+
+func (r repository) GetByName(listID uint64, name string) (*models.CustomField, error) {
+	var field models.CustomField
+	err := r.db.Where("list_id = ? AND name = ?", listID, name).Take(&field).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &field, err
+}
+
+// GetListFields returns the fields of the list in the order they were
+// created.
+func (r repository) GetListFields(listID uint64) (*[]models.CustomField, error) {
+	var fields []models.CustomField
+	err := r.db.Where("list_id = ?", listID).Order("id").Find(&fields).Error
+	return &fields, err
+}
+
+// Update writes the name and the options of the field. The type of a field
+// never changes, so the stored values stay valid.
+func (r repository) Update(field *models.CustomField) error {
+	return r.db.Model(field).Select("name", "options").Updates(field).Error
+}
+
+// Delete removes the field, and with it its values on every item.
+func (r repository) Delete(id uint64) error {
+	return r.db.Delete(&models.CustomField{}, id).Error
+}
+
+// IsOptionInUse tells if any item has one of the options as the value of the
+// select field.
+func (r repository) IsOptionInUse(fieldID uint64, options []string) (bool, error) {
+	var exists bool
+	err := r.db.Model(&models.ItemFieldValue{}).
+		Select("count(*) > 0").
+		Where("field_id = ? AND text_value IN ?", fieldID, options).
+		Find(&exists).
+		Error
+	return exists, err
+}
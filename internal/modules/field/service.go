@@ -0,0 +1,240 @@
+package field
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/authorization"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+type (
+	Service interface {
+		Save(field *models.CustomField, userID uint64) error
+		GetListFields(listID uint64, userID uint64) (*[]models.CustomField, error)
+		Update(field *models.CustomField, userID uint64) (*models.CustomField, error)
+		Delete(listID uint64, fieldID uint64, userID uint64) error
+	}
+
+	service struct {
+		repository           Repository
+		authorizationService authorization.Service
+	}
+)
+
+func NewService(repository Repository, authorizationService authorization.Service) Service {
+	return &service{repository, authorizationService}
+}
+
+// Save creates a custom field on the list, which requires the admin role on
+// the list.
+func (s service) Save(field *models.CustomField, userID uint64) error {
+	err := s.authorizationService.CheckListPermission(field.ListID, userID, models.ListRoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	if !models.FieldTypes[field.Type] {
+		return apperrors.NewObjectInInvalidStateError(fmt.Sprintf("invalid field type '%s'", field.Type))
+	}
+
+	fields, err := s.repository.GetListFields(field.ListID)
+	if err != nil {
+		log.Printf("Error getting list fields: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error getting list fields")
+	}
+
+	if len(*fields) >= constants.MaxListFields {
+		return apperrors.NewObjectInInvalidStateError(
+			fmt.Sprintf("a list cannot have more than %d fields", constants.MaxListFields),
+		)
+	}
+
+	err = s.validateField(field)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.Save(field)
+	if err != nil {
+		log.Printf("Error saving field: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error saving field")
+	}
+
+	return nil
+}
+
+func (s service) GetListFields(listID uint64, userID uint64) (*[]models.CustomField, error) {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := s.repository.GetListFields(listID)
+	if err != nil {
+		log.Printf("Error getting list fields: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting list fields")
+	}
+
+	return fields, nil
+}
+
+// Update renames the field and replaces the options of a select field.
+// Options still used by items cannot be removed and the type never changes.
+func (s service) Update(field *models.CustomField, userID uint64) (*models.CustomField, error) {
+	stored, err := s.getManagedField(field.ListID, field.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if field.Type != "" && field.Type != stored.Type {
+		return nil, apperrors.NewObjectInInvalidStateError("the type of a field cannot be changed")
+	}
+
+	stored.Name = field.Name
+	removed := []string{}
+	if field.Options != nil {
+		kept := make(map[string]bool, len(field.Options))
+		for _, option := range field.Options {
+			kept[strings.TrimSpace(option)] = true
+		}
+
+		for _, option := range stored.Options {
+			if !kept[option] {
+				removed = append(removed, option)
+			}
+		}
+		stored.Options = field.Options
+	}
+
+	err = s.validateField(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(removed) > 0 {
+		inUse, err := s.repository.IsOptionInUse(stored.ID, removed)
+		if err != nil {
+			log.Printf("Error checking field options: %s\n", err.Error())
+			return nil, apperrors.NewInternalError("Internal error checking field options")
+		}
+
+		if inUse {
+			return nil, apperrors.NewObjectInInvalidStateError("options in use by items cannot be removed")
+		}
+	}
+
+	err = s.repository.Update(stored)
+	if err != nil {
+		log.Printf("Error updating field: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error updating field")
+	}
+
+	return stored, nil
+}
+
+// Delete removes the field and its values from every item of the list.
+func (s service) Delete(listID uint64, fieldID uint64, userID uint64) error {
+	_, err := s.getManagedField(listID, fieldID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.Delete(fieldID)
+	if err != nil {
+		log.Printf("Error deleting field: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error deleting field")
+	}
+
+	return nil
+}
+
+func (s service) getManagedField(listID uint64, fieldID uint64, userID uint64) (*models.CustomField, error) {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	field, err := s.repository.Get(fieldID)
+	if err != nil {
+		log.Printf("Error getting field: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting field")
+	}
+
+	if field == nil || field.ListID != listID {
+		return nil, apperrors.NewNotFoundError("field", fieldID)
+	}
+
+	return field, nil
+}
+
+// validateField normalizes the name and the options of the field and checks
+// that the name is unique in the list.
+func (s service) validateField(field *models.CustomField) error {
+	field.Name = strings.TrimSpace(field.Name)
+	if field.Name == "" {
+		return apperrors.NewObjectInInvalidStateError("name cannot be empty")
+	}
+
+	if len(field.Name) > 64 {
+		return apperrors.NewObjectInInvalidStateError("name cannot exceed 64 characters")
+	}
+
+	err := validateOptions(field)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.repository.GetByName(field.ListID, field.Name)
+	if err != nil {
+		log.Printf("Error getting field by name: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error getting field by name")
+	}
+
+	if existing != nil && existing.ID != field.ID {
+		return apperrors.NewObjectInInvalidStateError(fmt.Sprintf("field '%s' already exists", field.Name))
+	}
+
+	return nil
+}
+
+func validateOptions(field *models.CustomField) error {
+	if field.Type != models.FieldTypeSelect {
+		if len(field.Options) > 0 {
+			return apperrors.NewObjectInInvalidStateError("only select fields have options")
+		}
+		field.Options = nil
+		return nil
+	}
+
+	if len(field.Options) == 0 {
+		return apperrors.NewObjectInInvalidStateError("select fields must have options")
+	}
+
+	if len(field.Options) > constants.MaxFieldOptions {
+		return apperrors.NewObjectInInvalidStateError(
+			fmt.Sprintf("a field cannot have more than %d options", constants.MaxFieldOptions),
+		)
+	}
+
+	options := make(models.FieldOptions, len(field.Options))
+	added := make(map[string]bool, len(field.Options))
+	for i, option := range field.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || len(option) > 64 {
+			return apperrors.NewObjectInInvalidStateError("options must have from 1 to 64 characters")
+		}
+
+		if added[option] {
+			return apperrors.NewObjectInInvalidStateError(fmt.Sprintf("duplicated option '%s'", option))
+		}
+		added[option] = true
+		options[i] = option
+	}
+
+	field.Options = options
+	return nil
+}
@@ -0,0 +1,158 @@
+package field_test
+
+import (
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/field"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRepository struct {
+	fields      map[uint64]*models.CustomField
+	optionInUse string
+	saved       []models.CustomField
+	deleted     []uint64
+}
+
+func (r *fakeRepository) Save(field *models.CustomField) error {
+	field.ID = uint64(len(r.fields) + 1)
+	r.saved = append(r.saved, *field)
+	return nil
+}
+
+func (r *fakeRepository) Get(id uint64) (*models.CustomField, error) {
+	if field, ok := r.fields[id]; ok {
+		stored := *field
+		return &stored, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) GetByName(listID uint64, name string) (*models.CustomField, error) {
+	for _, field := range r.fields {
+		if field.ListID == listID && field.Name == name {
+			return field, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) GetListFields(listID uint64) (*[]models.CustomField, error) {
+	fields := []models.CustomField{}
+	for _, field := range r.fields {
+		if field.ListID == listID {
+			fields = append(fields, *field)
+		}
+	}
+	return &fields, nil
+}
+
+func (r *fakeRepository) Update(field *models.CustomField) error {
+	r.saved = append(r.saved, *field)
+	return nil
+}
+
+func (r *fakeRepository) Delete(id uint64) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func (r *fakeRepository) IsOptionInUse(fieldID uint64, options []string) (bool, error) {
+	for _, option := range options {
+		if option == r.optionInUse {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newServiceToTest() (field.Service, *fakeRepository) {
+	repository := &fakeRepository{
+		fields: map[uint64]*models.CustomField{
+			1: {ID: 1, ListID: 10, Name: "status", Type: models.FieldTypeSelect, Options: models.FieldOptions{"new", "done"}},
+			2: {ID: 2, ListID: 10, Name: "quantity", Type: models.FieldTypeNumber},
+		},
+		optionInUse: "done",
+	}
+	authorizationService := testutil.AuthorizationService{
+		ListRoles: map[uint64]models.ListRole{10: models.ListRoleAdmin, 20: models.ListRoleEditor},
+	}
+	return field.NewService(repository, authorizationService), repository
+}
+
+func TestSaveNormalizesOptions(t *testing.T) {
+	service, repository := newServiceToTest()
+	newField := models.CustomField{
+		ListID:  10,
+		Name:    " size ",
+		Type:    models.FieldTypeSelect,
+		Options: models.FieldOptions{" S", "M "},
+	}
+
+	err := service.Save(&newField, 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "size", repository.saved[0].Name)
+	assert.Equal(t, models.FieldOptions{"S", "M"}, repository.saved[0].Options)
+}
+
+func TestSaveValidatesField(t *testing.T) {
+	service, repository := newServiceToTest()
+	invalid := []models.CustomField{
+		{ListID: 10, Name: "weight", Type: "weight"},
+		{ListID: 10, Name: "quantity", Type: models.FieldTypeNumber},
+		{ListID: 10, Name: "size", Type: models.FieldTypeSelect},
+		{ListID: 10, Name: "size", Type: models.FieldTypeSelect, Options: models.FieldOptions{"S", "S"}},
+		{ListID: 10, Name: "notes", Type: models.FieldTypeText, Options: models.FieldOptions{"a"}},
+	}
+
+	for _, newField := range invalid {
+		err := service.Save(&newField, 1)
+
+		assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, err, newField.Name)
+	}
+	assert.Empty(t, repository.saved)
+}
+
+func TestSaveRequiresListAdmin(t *testing.T) {
+	service, repository := newServiceToTest()
+	newField := models.CustomField{ListID: 20, Name: "notes", Type: models.FieldTypeText}
+
+	err := service.Save(&newField, 1)
+
+	assert.IsType(t, &apperrors.ForbiddenError{}, err)
+	assert.Empty(t, repository.saved)
+}
+
+func TestUpdateKeepsTypeAndOptionsInUse(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	_, err := service.Update(&models.CustomField{ID: 2, ListID: 10, Name: "quantity", Type: models.FieldTypeText}, 1)
+	assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, err)
+
+	_, err = service.Update(&models.CustomField{ID: 1, ListID: 10, Name: "status", Options: models.FieldOptions{"new"}}, 1)
+	assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, err)
+
+	updated, err := service.Update(&models.CustomField{ID: 1, ListID: 10, Name: "stage", Options: models.FieldOptions{"done", "late"}}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "stage", updated.Name)
+	assert.Equal(t, models.FieldOptions{"done", "late"}, repository.saved[0].Options)
+}
+
+func TestDeleteFieldOfAnotherList(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	err := service.Delete(20, 1, 1)
+	assert.IsType(t, &apperrors.ForbiddenError{}, err)
+
+	repository.fields[3] = &models.CustomField{ID: 3, ListID: 30, Name: "owner", Type: models.FieldTypeUser}
+	err = service.Delete(10, 3, 1)
+	assert.IsType(t, &apperrors.NotFoundError{}, err)
+
+	err = service.Delete(10, 2, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{2}, repository.deleted)
+}
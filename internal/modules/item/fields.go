@@ -0,0 +1,133 @@
+package item
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+// newFieldValue converts a value informed in a request to the column of the
+// field type. Empty texts and nil values return nil, which clears the field.
+func newFieldValue(field *models.CustomField, raw interface{}) (*models.ItemFieldValue, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	value := models.ItemFieldValue{FieldID: field.ID}
+	switch field.Type {
+	case models.FieldTypeText:
+		text, ok := raw.(string)
+		if !ok {
+			return nil, invalidFieldValueError(field, "a text")
+		}
+
+		if text == "" {
+			return nil, nil
+		}
+
+		if len(text) > constants.MaxFieldTextLength {
+			return nil, apperrors.NewObjectInInvalidStateError(
+				fmt.Sprintf("field '%s' cannot exceed %d characters", field.Name, constants.MaxFieldTextLength),
+			)
+		}
+		value.TextValue = &text
+	case models.FieldTypeNumber:
+		number, ok := raw.(float64)
+		if !ok {
+			return nil, invalidFieldValueError(field, "a number")
+		}
+		value.NumberValue = &number
+	case models.FieldTypeDate:
+		text, _ := raw.(string)
+		date, err := time.Parse(models.FieldDateLayout, text)
+		if err != nil {
+			return nil, invalidFieldValueError(field, "a date in the YYYY-MM-DD format")
+		}
+		value.DateValue = &date
+	case models.FieldTypeSelect:
+		option, _ := raw.(string)
+		if !hasOption(field, option) {
+			return nil, invalidFieldValueError(field, "one of its options")
+		}
+		value.TextValue = &option
+	case models.FieldTypeCheckbox:
+		checked, ok := raw.(bool)
+		if !ok {
+			return nil, invalidFieldValueError(field, "true or false")
+		}
+		value.BoolValue = &checked
+	case models.FieldTypeUser:
+		number, ok := raw.(float64)
+		if !ok || number < 1 || number != math.Trunc(number) {
+			return nil, invalidFieldValueError(field, "a user id")
+		}
+		userID := uint64(number)
+		value.UserValue = &userID
+	}
+
+	return &value, nil
+}
+
+// parseFieldFilterValue converts the value of a custom field filter informed
+// in the query string to the type of the field. Ranges are only accepted for
+// number and date fields.
+func parseFieldFilterValue(field *models.CustomField, operator models.FieldOperator, raw string) (interface{}, error) {
+	if operator != models.FieldOperatorEqual &&
+		field.Type != models.FieldTypeNumber && field.Type != models.FieldTypeDate {
+		return nil, apperrors.NewObjectInInvalidStateError(
+			fmt.Sprintf("field '%s' can only be filtered by value", field.Name),
+		)
+	}
+
+	switch field.Type {
+	case models.FieldTypeNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, invalidFieldValueError(field, "a number")
+		}
+		return number, nil
+	case models.FieldTypeDate:
+		if _, err := time.Parse(models.FieldDateLayout, raw); err != nil {
+			return nil, invalidFieldValueError(field, "a date in the YYYY-MM-DD format")
+		}
+		return raw, nil
+	case models.FieldTypeCheckbox:
+		checked, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, invalidFieldValueError(field, "true or false")
+		}
+		return checked, nil
+	case models.FieldTypeUser:
+		userID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, invalidFieldValueError(field, "a user id")
+		}
+		return userID, nil
+	default:
+		if strings.TrimSpace(raw) == "" {
+			return nil, invalidFieldValueError(field, "a text")
+		}
+		return raw, nil
+	}
+}
+
+func hasOption(field *models.CustomField, option string) bool {
+	for _, candidate := range field.Options {
+		if candidate == option {
+			return true
+		}
+	}
+	return false
+}
+
+func invalidFieldValueError(field *models.CustomField, expected string) error {
+	return apperrors.NewObjectInInvalidStateError(
+		fmt.Sprintf("the value of field '%s' must be %s", field.Name, expected),
+	)
+}
@@ -2,9 +2,15 @@ package item
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
 	"github.com/gin-gonic/gin"
@@ -16,11 +22,28 @@ type (
 		GetByList(c *gin.Context)
 		Update(c *gin.Context)
 		Delete(c *gin.Context)
+		Complete(c *gin.Context)
+		Reopen(c *gin.Context)
+		GetOccurrences(c *gin.Context)
+		Move(c *gin.Context)
+		Transfer(c *gin.Context)
+		Copy(c *gin.Context)
+		TransferMany(c *gin.Context)
+		CopyMany(c *gin.Context)
+		Board(c *gin.Context)
+		NextUp(c *gin.Context)
+		Assigned(c *gin.Context)
+		Assign(c *gin.Context)
+		Unassign(c *gin.Context)
+		Watch(c *gin.Context)
+		Unwatch(c *gin.Context)
 	}
 
 	handler struct {
 		service Service
 	}
+
+	transferFunc func(listID uint64, itemIDs []uint64, targetListID uint64, userID uint64) (*[]models.Item, error)
 )
 
 func NewHandler(service Service) Handler {
@@ -42,7 +65,9 @@ func (h handler) Save(c *gin.Context) {
 
 	item := models.NewItemFromDTO(itemDTO)
 	item.ListID = listID
-	err = h.service.Save(item)
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	err = h.service.Save(item, userID)
 	if err != nil {
 		apperrors.HandleServiceError(c, err)
 		return
@@ -58,13 +83,136 @@ func (h handler) GetByList(c *gin.Context) {
 		return
 	}
 
-	items, err := h.service.GetItemsFromList(listID)
+	filter, err := getItemFilterFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	filter.ListID = listID
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	page, err := h.service.GetItemsFromList(filter, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewItemPageDTO(page))
+}
+
+// Board returns the items of the list grouped by status, with up to limit
+// items per status.
+func (h handler) Board(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	limit, err := utils.GetPageSizeFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	board, err := h.service.GetBoard(listID, userID, limit)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewBoardDTO(board))
+}
+
+// NextUp returns the open items assigned to the user across all the lists
+// the user can access, in the order they should be done.
+func (h handler) NextUp(c *gin.Context) {
+	limit, err := utils.GetPageSizeFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	items, err := h.service.GetNextUp(userID, limit)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewUserItemsDTO(items))
+}
+
+// Assigned returns the items assigned to the user across all the lists the
+// user can access.
+func (h handler) Assigned(c *gin.Context) {
+	filter, err := getAssignedFilterFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	filter.UserID = c.GetUint64(constants.CtxUserKey)
+
+	page, err := h.service.GetAssigned(filter)
 	if err != nil {
 		apperrors.HandleServiceError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, models.NewItemsDTO(items))
+	c.IndentedJSON(http.StatusOK, models.NewUserItemPageDTO(page))
+}
+
+func (h handler) Assign(c *gin.Context) {
+	listID, itemID, httpErr := getListIDAndItemIDFromRequest(c)
+	if httpErr != nil {
+		c.IndentedJSON(http.StatusBadRequest, httpErr)
+		return
+	}
+
+	var assigneeDTO models.ItemAssigneeDTO
+	if err := c.BindJSON(&assigneeDTO); err != nil || assigneeDTO.UserID == 0 {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("user_id is required")))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	item, err := h.service.Assign(listID, itemID, assigneeDTO.UserID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewItemDTO(item))
+}
+
+func (h handler) Unassign(c *gin.Context) {
+	listID, itemID, httpErr := getListIDAndItemIDFromRequest(c)
+	if httpErr != nil {
+		c.IndentedJSON(http.StatusBadRequest, httpErr)
+		return
+	}
+
+	assigneeID, err := utils.GetIDFromRequest(c, "user_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	item, err := h.service.Unassign(listID, itemID, assigneeID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewItemDTO(item))
 }
 
 func (h handler) Update(c *gin.Context) {
@@ -84,7 +232,9 @@ func (h handler) Update(c *gin.Context) {
 	item.ID = itemID
 	item.ListID = listID
 
-	err = h.service.Update(item)
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	err = h.service.Update(item, userID)
 	if err != nil {
 		apperrors.HandleServiceError(c, err)
 		return
@@ -100,7 +250,15 @@ func (h handler) Delete(c *gin.Context) {
 		return
 	}
 
-	err := h.service.Delete(listID, itemID)
+	cascade, err := utils.GetBoolFromRequest(c, "cascade")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	err = h.service.Delete(listID, itemID, userID, cascade)
 	if err != nil {
 		apperrors.HandleServiceError(c, err)
 		return
@@ -109,6 +267,176 @@ func (h handler) Delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+func (h handler) Complete(c *gin.Context) {
+	h.changeItem(c, h.service.Complete)
+}
+
+func (h handler) Reopen(c *gin.Context) {
+	h.changeItem(c, h.service.Reopen)
+}
+
+func (h handler) Watch(c *gin.Context) {
+	h.changeItem(c, h.service.Watch)
+}
+
+func (h handler) Unwatch(c *gin.Context) {
+	h.changeItem(c, h.service.Unwatch)
+}
+
+// changeItem applies a change made by the user to the item and responds with
+// the changed item.
+func (h handler) changeItem(
+	c *gin.Context,
+	change func(listID uint64, itemID uint64, userID uint64) (*models.Item, error),
+) {
+	listID, itemID, httpErr := getListIDAndItemIDFromRequest(c)
+	if httpErr != nil {
+		c.IndentedJSON(http.StatusBadRequest, httpErr)
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	item, err := change(listID, itemID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewItemDTO(item))
+}
+
+func (h handler) GetOccurrences(c *gin.Context) {
+	listID, itemID, httpErr := getListIDAndItemIDFromRequest(c)
+	if httpErr != nil {
+		c.IndentedJSON(http.StatusBadRequest, httpErr)
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	occurrences, err := h.service.GetOccurrences(listID, itemID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewItemOccurrencesDTO(occurrences))
+}
+
+// Move places the item right after the after_id item or right before the
+// before_id item. When both are informed the item goes between them.
+func (h handler) Move(c *gin.Context) {
+	listID, itemID, httpErr := getListIDAndItemIDFromRequest(c)
+	if httpErr != nil {
+		c.IndentedJSON(http.StatusBadRequest, httpErr)
+		return
+	}
+
+	var moveDTO models.ItemMoveDTO
+	if err := c.BindJSON(&moveDTO); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("invalid body request format")))
+		return
+	}
+
+	if moveDTO.AfterID == nil && moveDTO.BeforeID == nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("after_id or before_id must be informed")))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	item, err := h.service.Move(listID, itemID, userID, &moveDTO)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewItemDTO(item))
+}
+
+// Transfer moves the item to the list informed in the body.
+func (h handler) Transfer(c *gin.Context) {
+	h.transferOne(c, h.service.Transfer, http.StatusOK)
+}
+
+// Copy copies the item to the list informed in the body.
+func (h handler) Copy(c *gin.Context) {
+	h.transferOne(c, h.service.Copy, http.StatusCreated)
+}
+
+// TransferMany moves the items informed in the body to another list.
+func (h handler) TransferMany(c *gin.Context) {
+	h.transferMany(c, h.service.Transfer, http.StatusOK)
+}
+
+// CopyMany copies the items informed in the body to another list.
+func (h handler) CopyMany(c *gin.Context) {
+	h.transferMany(c, h.service.Copy, http.StatusCreated)
+}
+
+func (h handler) transferOne(c *gin.Context, transfer transferFunc, status int) {
+	listID, itemID, httpErr := getListIDAndItemIDFromRequest(c)
+	if httpErr != nil {
+		c.IndentedJSON(http.StatusBadRequest, httpErr)
+		return
+	}
+
+	transferDTO, err := getTransferFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	items, err := transfer(listID, []uint64{itemID}, transferDTO.ListID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(status, models.NewItemDTO(&(*items)[0]))
+}
+
+func (h handler) transferMany(c *gin.Context, transfer transferFunc, status int) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	transferDTO, err := getTransferFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	items, err := transfer(listID, transferDTO.ItemIDs, transferDTO.ListID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(status, models.NewItemsDTO(items))
+}
+
+func getTransferFromRequest(c *gin.Context) (*models.ItemTransferDTO, error) {
+	var transfer models.ItemTransferDTO
+
+	if err := c.BindJSON(&transfer); err != nil {
+		return nil, errors.New("invalid body request format")
+	}
+
+	if transfer.ListID == 0 {
+		return nil, errors.New("list id cannot be null")
+	}
+
+	return &transfer, nil
+}
+
 func getItemFromRequest(c *gin.Context) (*models.ItemDTO, error) {
 	var item models.ItemDTO
 
@@ -119,6 +447,206 @@ func getItemFromRequest(c *gin.Context) (*models.ItemDTO, error) {
 	return &item, validateItemDTO(&item)
 }
 
+func getItemFilterFromRequest(c *gin.Context) (*models.ItemFilter, error) {
+	filter := models.ItemFilter{
+		Title:       c.Query("title"),
+		Description: c.Query("description"),
+		Status:      models.ItemStatus(c.Query("status")),
+		Due:         models.ItemDue(c.Query("due")),
+		Sort:        c.DefaultQuery("sort", "position"),
+		Order:       models.SortOrder(c.DefaultQuery("order", string(models.SortAsc))),
+	}
+
+	if _, ok := models.ParseFieldSort(filter.Sort); !ok && !models.ItemSortFields[filter.Sort] {
+		return nil, fmt.Errorf("invalid sort field '%s'", filter.Sort)
+	}
+
+	if filter.Order != models.SortAsc && filter.Order != models.SortDesc {
+		return nil, errors.New("order must be asc or desc")
+	}
+
+	switch filter.Status {
+	case models.ItemStatusAll, models.ItemStatusOpen, models.ItemStatusDone:
+	default:
+		return nil, errors.New("status must be open or done")
+	}
+
+	switch filter.Due {
+	case models.ItemDueAny, models.ItemDueOverdue, models.ItemDueToday:
+	default:
+		return nil, errors.New("due must be overdue or today")
+	}
+
+	if timezone := c.Query("tz"); timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone '%s'", timezone)
+		}
+		filter.Timezone = location
+	}
+
+	// user_id is the deprecated name of assignee_id, from when items had a
+	// single responsible user.
+	assigneeParam := "assignee_id"
+	if c.Query(assigneeParam) == "" {
+		assigneeParam = "user_id"
+	}
+
+	if assigneeID := c.Query(assigneeParam); assigneeID != "" {
+		id, err := strconv.ParseUint(assigneeID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s", assigneeParam)
+		}
+		filter.AssigneeID = &id
+	}
+
+	if priority := models.ItemPriority(c.Query("priority")); priority != "" {
+		if !priority.IsValid() {
+			return nil, errors.New("priority must be none, low, medium, high or urgent")
+		}
+		filter.Priority = priority
+	}
+
+	if statusID := c.Query("status_id"); statusID != "" {
+		id, err := strconv.ParseUint(statusID, 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid status_id")
+		}
+		filter.StatusID = &id
+	}
+
+	tagIDs, tagMatch, err := getTagFilterFromRequest(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.TagIDs = tagIDs
+	filter.TagMatch = tagMatch
+
+	fieldFilters, err := getFieldFiltersFromRequest(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.Fields = fieldFilters
+
+	tree, err := utils.GetBoolFromRequest(c, "tree")
+	if err != nil {
+		return nil, err
+	}
+	filter.Tree = tree
+
+	limit, err := utils.GetPageSizeFromRequest(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		filter.Cursor, err = models.DecodePageCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &filter, nil
+}
+
+func getAssignedFilterFromRequest(c *gin.Context) (*models.AssignedFilter, error) {
+	filter := models.AssignedFilter{
+		Status: models.ItemStatus(c.Query("status")),
+		Sort:   c.DefaultQuery("sort", "id"),
+		Order:  models.SortOrder(c.DefaultQuery("order", string(models.SortAsc))),
+	}
+
+	if !models.AssignedSortFields[filter.Sort] {
+		return nil, fmt.Errorf("invalid sort field '%s'", filter.Sort)
+	}
+
+	if filter.Order != models.SortAsc && filter.Order != models.SortDesc {
+		return nil, errors.New("order must be asc or desc")
+	}
+
+	switch filter.Status {
+	case models.ItemStatusAll, models.ItemStatusOpen, models.ItemStatusDone:
+	default:
+		return nil, errors.New("status must be open or done")
+	}
+
+	limit, err := utils.GetPageSizeFromRequest(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		filter.Cursor, err = models.DecodePageCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &filter, nil
+}
+
+// getTagFilterFromRequest reads the comma separated tags parameter and how
+// they must match: any (default) or all of them.
+func getTagFilterFromRequest(c *gin.Context) ([]uint64, models.TagMatch, error) {
+	match := models.TagMatch(c.DefaultQuery("tag_match", string(models.TagMatchAny)))
+	if match != models.TagMatchAny && match != models.TagMatchAll {
+		return nil, "", errors.New("tag_match must be any or all")
+	}
+
+	value := c.Query("tags")
+	if value == "" {
+		return nil, match, nil
+	}
+
+	ids := []uint64{}
+	added := map[uint64]bool{}
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, "", errors.New("invalid tags")
+		}
+
+		if !added[id] {
+			added[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, match, nil
+}
+
+// getFieldFiltersFromRequest reads the custom field filters, informed by
+// field id as field[id]=value, field_min[id]=value and field_max[id]=value.
+func getFieldFiltersFromRequest(c *gin.Context) ([]models.FieldFilter, error) {
+	params := []struct {
+		name     string
+		operator models.FieldOperator
+	}{
+		{"field", models.FieldOperatorEqual},
+		{"field_min", models.FieldOperatorMin},
+		{"field_max", models.FieldOperatorMax},
+	}
+
+	filters := []models.FieldFilter{}
+	for _, param := range params {
+		for key, value := range c.QueryMap(param.name) {
+			fieldID, err := strconv.ParseUint(key, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field id '%s'", key)
+			}
+			filters = append(filters, models.FieldFilter{FieldID: fieldID, Operator: param.operator, Raw: value})
+		}
+	}
+
+	sort.SliceStable(filters, func(i, j int) bool {
+		return filters[i].FieldID < filters[j].FieldID
+	})
+
+	return filters, nil
+}
+
 func getListIDAndItemIDFromRequest(c *gin.Context) (uint64, uint64, *models.HttpError) {
 	var listID, itemID uint64
 
@@ -142,8 +670,8 @@ func validateItemDTO(item *models.ItemDTO) error {
 		return errors.New("title cannot be empty")
 	}
 
-	if item.UserID == nil {
-		return errors.New("user id cannot be null")
+	if item.Priority != "" && !item.Priority.IsValid() {
+		return errors.New("priority must be none, low, medium, high or urgent")
 	}
 
 	return nil
@@ -1,16 +1,57 @@
 package item
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/rank"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// userItemColumns are the columns of models.UserItem.
+const userItemColumns = "item.*, (SELECT title FROM list WHERE list.id = item.list_id) AS list_title"
+
+var (
+	errAnchorNotFound = errors.New("anchor item not found in list")
+	errItemChanged    = errors.New("item changed while being transferred")
+	errWIPLimit       = errors.New("status reached its WIP limit")
+	errOccurrenceDone = errors.New("occurrence already completed")
 )
 
 type (
 	Repository interface {
 		Save(item *models.Item) error
-		GetItemsFromList(listID uint64) (*[]models.Item, error)
+		Get(id uint64) (*models.Item, error)
+		GetItemsInList(listID uint64, ids []uint64) (*[]models.Item, error)
+		GetDescendants(ids []uint64) (*[]models.Item, error)
+		GetTags(ids []uint64, userID uint64) (map[uint64][]models.Tag, error)
+		GetFieldValues(ids []uint64) (map[uint64][]models.ItemFieldValue, error)
+		GetItemsByStatus(statusID uint64, limit int) (*[]models.Item, error)
+		CountByStatus(listID uint64) (map[uint64]int64, error)
+		FindItems(filter *models.ItemFilter) (*[]models.Item, int64, error)
+		FindNextUp(userID uint64, now time.Time, limit int) (*[]models.UserItem, error)
+		FindAssigned(filter *models.AssignedFilter) (*[]models.UserItem, int64, error)
+		GetAssignees(ids []uint64) (map[uint64][]uint64, error)
+		GetWatchers(ids []uint64) (map[uint64][]uint64, error)
+		Assign(itemID uint64, userID uint64) error
+		Unassign(itemID uint64, userID uint64) error
+		Watch(itemID uint64, userID uint64) error
+		Unwatch(itemID uint64, userID uint64) error
 		Update(item *models.Item) error
-		Delete(id uint64) error
+		SetCompletion(item *models.Item) error
+		CompleteOccurrence(item *models.Item) error
+		GetOccurrences(itemID uint64) (*[]models.ItemOccurrence, error)
+		CountOccurrences(itemID uint64) (int64, error)
+		Move(item *models.Item, afterID *uint64, beforeID *uint64) error
+		Transfer(items *[]models.Item, targetListID uint64) error
+		Copy(items *[]models.Item, targetListID uint64) error
+		Delete(id uint64, descendantIDs []uint64, deletedBy uint64) error
 		IsItemInList(listID uint64, itemID uint64) (bool, error)
 	}
 
@@ -23,22 +64,613 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db}
 }
 
+// Save appends the item to the end of its list. It fails with errWIPLimit
+// when the status of the item is full.
 func (r repository) Save(item *models.Item) error {
-	return r.db.Create(item).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if item.StatusID != nil {
+			if err := checkWIPLimit(tx, *item.StatusID, 0, 1); err != nil {
+				return err
+			}
+		}
+
+		positions, err := nextPositions(tx, item.ListID, 1)
+		if err != nil {
+			return err
+		}
+
+		item.Position = positions[0]
+		if err = tx.Create(item).Error; err != nil {
+			return err
+		}
+
+		if err = replaceAssignees(tx, item); err != nil {
+			return err
+		}
+
+		if err = replaceFieldValues(tx, item); err != nil {
+			return err
+		}
+
+		return replacePendingReminders(tx, item)
+	})
 }
 
-func (r repository) GetItemsFromList(listID uint64) (*[]models.Item, error) {
+// FindItems returns one page of items matching the filter, fetching one extra
+// row so the caller knows if there is a next page, and the total of matching
+// items regardless of pagination.
+func (r repository) FindItems(filter *models.ItemFilter) (*[]models.Item, int64, error) {
+	var total int64
+	err := applyItemFilter(r.db.Model(&models.Item{}), filter).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	column := filter.Sort
+	if filter.SortField != nil {
+		column = fieldSortColumn(filter.SortField)
+	}
+
+	query := utils.ApplyKeysetPagination(
+		applyItemFilter(r.db, filter), column, filter.Order, filter.Cursor, filter.Limit,
+	)
+
 	var items []models.Item
-	err := r.db.Where(&models.Item{ListID: listID}).Find(&items).Error
+	err = query.Find(&items).Error
+	return &items, total, err
+}
+
+// FindNextUp returns the open items assigned to the user in the lists the
+// user can access: overdue items first, then by due date, with items without
+// one last, priority and position.
+func (r repository) FindNextUp(userID uint64, now time.Time, limit int) (*[]models.UserItem, error) {
+	var items []models.UserItem
+	err := r.assignedItems(userID).
+		Select(userItemColumns).
+		Where("completed_at IS NULL").
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "due_at < ? DESC, due_at IS NULL, due_at, priority DESC, position, id",
+			Vars:               []interface{}{now},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&items).
+		Error
 	return &items, err
 }
 
+// FindAssigned returns one page of the items assigned to the user in the
+// lists the user can access, fetching one extra row so the caller knows if
+// there is a next page, and the total of matching items.
+func (r repository) FindAssigned(filter *models.AssignedFilter) (*[]models.UserItem, int64, error) {
+	var total int64
+	err := r.applyAssignedFilter(filter).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := utils.ApplyKeysetPagination(
+		r.applyAssignedFilter(filter), filter.Sort, filter.Order, filter.Cursor, filter.Limit,
+	)
+
+	var items []models.UserItem
+	err = query.Select(userItemColumns).Find(&items).Error
+	return &items, total, err
+}
+
+func (r repository) applyAssignedFilter(filter *models.AssignedFilter) *gorm.DB {
+	query := r.assignedItems(filter.UserID)
+
+	switch filter.Status {
+	case models.ItemStatusOpen:
+		query = query.Where("completed_at IS NULL")
+	case models.ItemStatusDone:
+		query = query.Where("completed_at IS NOT NULL")
+	}
+
+	return query
+}
+
+// assignedItems selects the items assigned to the user in the lists the user
+// owns or is a member of, leaving the lists in the trash out.
+func (r repository) assignedItems(userID uint64) *gorm.DB {
+	newDB := r.db.Session(&gorm.Session{NewDB: true})
+	assigned := newDB.Model(&models.ItemAssignee{}).Select("item_id").Where("user_id = ?", userID)
+	members := newDB.Model(&models.ListMember{}).Select("list_id").Where("user_id = ?", userID)
+	lists := newDB.Model(&models.List{}).
+		Select("id").
+		Where(newDB.Where("user_id = ?", userID).Or("id IN (?)", members))
+
+	return r.db.Model(&models.Item{}).Where("id IN (?) AND list_id IN (?)", assigned, lists)
+}
+
+// GetAssignees returns the ids of the assignees of the items, by item id.
+func (r repository) GetAssignees(ids []uint64) (map[uint64][]uint64, error) {
+	var assignees []models.ItemAssignee
+	err := r.db.Where("item_id IN ?", ids).Order("created_at, user_id").Find(&assignees).Error
+
+	byItem := make(map[uint64][]uint64, len(ids))
+	for _, assignee := range assignees {
+		byItem[assignee.ItemID] = append(byItem[assignee.ItemID], assignee.UserID)
+	}
+	return byItem, err
+}
+
+// GetWatchers returns the ids of the watchers of the items, by item id.
+func (r repository) GetWatchers(ids []uint64) (map[uint64][]uint64, error) {
+	var watchers []models.ItemWatcher
+	err := r.db.Where("item_id IN ?", ids).Order("created_at, user_id").Find(&watchers).Error
+
+	byItem := make(map[uint64][]uint64, len(ids))
+	for _, watcher := range watchers {
+		byItem[watcher.ItemID] = append(byItem[watcher.ItemID], watcher.UserID)
+	}
+	return byItem, err
+}
+
+// Assign adds the user to the assignees of the item. Assigning a user twice
+// changes nothing.
+func (r repository) Assign(itemID uint64, userID uint64) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ItemAssignee{ItemID: itemID, UserID: userID}).
+		Error
+}
+
+func (r repository) Unassign(itemID uint64, userID uint64) error {
+	return r.db.Delete(&models.ItemAssignee{}, "item_id = ? AND user_id = ?", itemID, userID).Error
+}
+
+// Watch adds the user to the watchers of the item. Watching an item twice
+// changes nothing.
+func (r repository) Watch(itemID uint64, userID uint64) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ItemWatcher{ItemID: itemID, UserID: userID}).
+		Error
+}
+
+func (r repository) Unwatch(itemID uint64, userID uint64) error {
+	return r.db.Delete(&models.ItemWatcher{}, "item_id = ? AND user_id = ?", itemID, userID).Error
+}
+
+func (r repository) Get(id uint64) (*models.Item, error) {
+	var item models.Item
+	err := r.db.First(&item, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &item, err
+}
+
+// Update persists the item. A change of status fails with errWIPLimit when
+// the new status is full, and also persists the completion of the item,
+// which follows its status. The completed occurrence of a recurring item is
+// recorded as well.
 func (r repository) Update(item *models.Item) error {
-	return r.db.Model(item).Updates(item).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := recordOccurrence(tx, item); err != nil {
+			return err
+		}
+
+		if err := changeStatus(tx, item); err != nil {
+			return err
+		}
+
+		// The position is only changed by Move, so a concurrent reorder is
+		// not overwritten by a stale value.
+		if err := tx.Model(item).Omit("position").Updates(item).Error; err != nil {
+			return err
+		}
+
+		// Updates skips nil fields, so the ones that can be cleared are
+		// written explicitly.
+		clearable := map[string]interface{}{"parent_id": item.ParentID, "recurrence": item.Recurrence}
+		if err := tx.Model(item).Updates(clearable).Error; err != nil {
+			return err
+		}
+
+		if err := replaceAssignees(tx, item); err != nil {
+			return err
+		}
+
+		if err := replaceFieldValues(tx, item); err != nil {
+			return err
+		}
+
+		return replacePendingReminders(tx, item)
+	})
+}
+
+// GetItemsInList returns the items of the list with the given ids, ignoring
+// the ids of items that are not in the list.
+func (r repository) GetItemsInList(listID uint64, ids []uint64) (*[]models.Item, error) {
+	var items []models.Item
+	err := r.db.Where("list_id = ? AND id IN ?", listID, ids).Find(&items).Error
+	return &items, err
+}
+
+// GetDescendants returns the sub-items of the given items at every level,
+// level by level and ordered by position within each level.
+func (r repository) GetDescendants(ids []uint64) (*[]models.Item, error) {
+	descendants := []models.Item{}
+	for depth := 1; depth < constants.MaxItemDepth && len(ids) > 0; depth++ {
+		var children []models.Item
+		err := r.db.Where("parent_id IN ?", ids).Order("position").Find(&children).Error
+		if err != nil {
+			return nil, err
+		}
+
+		ids = make([]uint64, len(children))
+		for i, child := range children {
+			ids[i] = child.ID
+		}
+		descendants = append(descendants, children...)
+	}
+
+	return &descendants, nil
+}
+
+// GetTags returns the tags of each of the items that the user can see, list
+// tags and the personal tags of the user, ordered by name.
+func (r repository) GetTags(ids []uint64, userID uint64) (map[uint64][]models.Tag, error) {
+	var rows []struct {
+		ItemID uint64
+		models.Tag
+	}
+
+	err := r.db.Model(&models.Tag{}).
+		Select("item_tag.item_id, tag.*").
+		Joins("JOIN item_tag ON item_tag.tag_id = tag.id").
+		Where("item_tag.item_id IN ?", ids).
+		Where("tag.user_id IS NULL OR tag.user_id = ?", userID).
+		Order("tag.name").
+		Find(&rows).
+		Error
+
+	tags := make(map[uint64][]models.Tag)
+	for _, row := range rows {
+		tags[row.ItemID] = append(tags[row.ItemID], row.Tag)
+	}
+	return tags, err
+}
+
+// GetFieldValues returns the custom field values of each of the items.
+func (r repository) GetFieldValues(ids []uint64) (map[uint64][]models.ItemFieldValue, error) {
+	var rows []models.ItemFieldValue
+	err := r.db.Where("item_id IN ?", ids).Order("field_id").Find(&rows).Error
+
+	values := make(map[uint64][]models.ItemFieldValue)
+	for _, row := range rows {
+		values[row.ItemID] = append(values[row.ItemID], row)
+	}
+	return values, err
+}
+
+// GetItemsByStatus returns the first items of the status, by position.
+func (r repository) GetItemsByStatus(statusID uint64, limit int) (*[]models.Item, error) {
+	var items []models.Item
+	err := r.db.Where("status_id = ?", statusID).Order("position").Limit(limit).Find(&items).Error
+	return &items, err
+}
+
+// CountByStatus returns how many items of the list are in each status.
+func (r repository) CountByStatus(listID uint64) (map[uint64]int64, error) {
+	var rows []struct {
+		StatusID uint64
+		Count    int64
+	}
+
+	err := r.db.Model(&models.Item{}).
+		Select("status_id, count(*) AS count").
+		Where("list_id = ? AND status_id IS NOT NULL", listID).
+		Group("status_id").
+		Find(&rows).
+		Error
+
+	counts := make(map[uint64]int64, len(rows))
+	for _, row := range rows {
+		counts[row.StatusID] = row.Count
+	}
+	return counts, err
+}
+
+// Transfer moves the items to the end of the target list, keeping their
+// relative order, in the first status of the target list that matches their
+// completion. Tags and custom field values of the former lists are removed
+// from the items, and so are the assignees and watchers not kept in their
+// AssigneeIDs and WatcherIDs.
+// Nothing is moved if any of the items left its list in the meantime, and it
+// fails with errWIPLimit when a status of the target list cannot take them.
+func (r repository) Transfer(items *[]models.Item, targetListID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		positions, err := nextPositions(tx, targetListID, len(*items))
+		if err != nil {
+			return err
+		}
+
+		openStatusID, err := initialStatus(tx, targetListID, false)
+		if err != nil {
+			return err
+		}
+
+		doneStatusID, err := initialStatus(tx, targetListID, true)
+		if err != nil {
+			return err
+		}
+
+		adding := map[uint64]int64{}
+		for i := range *items {
+			item := &(*items)[i]
+			item.StatusID = openStatusID
+			if item.CompletedAt != nil {
+				item.StatusID = doneStatusID
+			}
+
+			if item.StatusID != nil {
+				adding[*item.StatusID]++
+			}
+		}
+
+		if err = checkWIPLimits(tx, adding); err != nil {
+			return err
+		}
+
+		for i := range *items {
+			item := &(*items)[i]
+
+			result := tx.Model(&models.Item{}).
+				Where("id = ? AND list_id = ?", item.ID, item.ListID).
+				Updates(map[string]interface{}{
+					"list_id":   targetListID,
+					"parent_id": item.ParentID,
+					"status_id": item.StatusID,
+					"position":  positions[i],
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				return errItemChanged
+			}
+
+			if err = keepOnly(tx, &models.ItemAssignee{}, item.ID, item.AssigneeIDs); err != nil {
+				return err
+			}
+
+			if err = keepOnly(tx, &models.ItemWatcher{}, item.ID, item.WatcherIDs); err != nil {
+				return err
+			}
+
+			item.ListID = targetListID
+			item.Position = positions[i]
+		}
+
+		ids := make([]uint64, len(*items))
+		for i, item := range *items {
+			ids[i] = item.ID
+		}
+
+		otherListTags := tx.Model(&models.Tag{}).Select("id").Where("list_id <> ?", targetListID)
+		err = tx.Delete(&models.ItemTag{}, "item_id IN ? AND tag_id IN (?)", ids, otherListTags).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.ItemFieldValue{}, "item_id IN ?", ids).Error
+	})
+}
+
+// Copy creates copies of the items, with their pending reminders, AssigneeIDs
+// and personal tags, at the end of the target list and in its first open
+// status. The ids and positions of the copies are set on items. Parents must
+// come before their sub-items, which are linked to the copies of their
+// parents. It fails with errWIPLimit when the status cannot take the copies.
+func (r repository) Copy(items *[]models.Item, targetListID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		positions, err := nextPositions(tx, targetListID, len(*items))
+		if err != nil {
+			return err
+		}
+
+		statusID, err := initialStatus(tx, targetListID, false)
+		if err != nil {
+			return err
+		}
+
+		if statusID != nil {
+			if err = checkWIPLimit(tx, *statusID, 0, int64(len(*items))); err != nil {
+				return err
+			}
+		}
+
+		copies := make(map[uint64]uint64, len(*items))
+		for i := range *items {
+			item := &(*items)[i]
+			originalID := item.ID
+
+			if item.ParentID != nil {
+				parentID, ok := copies[*item.ParentID]
+				if !ok {
+					return fmt.Errorf("parent of item %d copied after it", originalID)
+				}
+				item.ParentID = &parentID
+			}
+
+			item.ID = 0
+			item.ListID = targetListID
+			item.StatusID = statusID
+			item.Position = positions[i]
+
+			if err = tx.Create(item).Error; err != nil {
+				return err
+			}
+
+			if err = replacePendingReminders(tx, item); err != nil {
+				return err
+			}
+
+			err = tx.Exec(
+				`INSERT INTO item_tag (item_id, tag_id)
+				SELECT ?, item_tag.tag_id FROM item_tag JOIN tag ON tag.id = item_tag.tag_id
+				WHERE item_tag.item_id = ? AND tag.user_id IS NOT NULL`,
+				item.ID, originalID,
+			).Error
+			if err != nil {
+				return err
+			}
+
+			if err = replaceAssignees(tx, item); err != nil {
+				return err
+			}
+			copies[originalID] = item.ID
+		}
+
+		return nil
+	})
+}
+
+// SetCompletion persists the completion fields, including null values when
+// the item is reopened.
+func (r repository) SetCompletion(item *models.Item) error {
+	return r.db.Model(item).
+		Select("completed_at", "completed_by").
+		Updates(map[string]interface{}{"completed_at": item.CompletedAt, "completed_by": item.CompletedBy}).
+		Error
+}
+
+// CompleteOccurrence records the completed occurrence of a recurring item
+// and persists its new due date, completion and reminders.
+func (r repository) CompleteOccurrence(item *models.Item) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := recordOccurrence(tx, item); err != nil {
+			return err
+		}
+
+		err := tx.Model(item).
+			Updates(map[string]interface{}{
+				"due_at":       item.DueAt,
+				"completed_at": item.CompletedAt,
+				"completed_by": item.CompletedBy,
+			}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return replacePendingReminders(tx, item)
+	})
+}
+
+// GetOccurrences returns the completed occurrences of the item, the most
+// recent first.
+func (r repository) GetOccurrences(itemID uint64) (*[]models.ItemOccurrence, error) {
+	var occurrences []models.ItemOccurrence
+	err := r.db.Where("item_id = ?", itemID).Order("completed_at DESC, id DESC").Find(&occurrences).Error
+	return &occurrences, err
+}
+
+func (r repository) CountOccurrences(itemID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ItemOccurrence{}).Where("item_id = ?", itemID).Count(&count).Error
+	return count, err
+}
+
+// Move gives the item a position between its new neighbors. The anchors and
+// the neighbors are read with row locks, so concurrent moves next to the same
+// item are serialized and each one sees the position given by the other.
+func (r repository) Move(item *models.Item, afterID *uint64, beforeID *uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		locked := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Model(&models.Item{}).
+			Where("list_id = ? AND id <> ?", item.ListID, item.ID).
+			Session(&gorm.Session{})
+
+		var lower, upper string
+		if afterID != nil {
+			position, err := getAnchorPosition(locked, *afterID)
+			if err != nil {
+				return err
+			}
+			lower = position
+		}
+
+		if beforeID != nil {
+			position, err := getAnchorPosition(locked, *beforeID)
+			if err != nil {
+				return err
+			}
+			upper = position
+		}
+
+		var neighbor []string
+		if afterID != nil && beforeID == nil {
+			err := locked.Where("position > ?", lower).Order("position ASC").Limit(1).Pluck("position", &neighbor).Error
+			if err != nil {
+				return err
+			}
+			if len(neighbor) > 0 {
+				upper = neighbor[0]
+			}
+		}
+
+		if beforeID != nil && afterID == nil {
+			err := locked.Where("position < ?", upper).Order("position DESC").Limit(1).Pluck("position", &neighbor).Error
+			if err != nil {
+				return err
+			}
+			if len(neighbor) > 0 {
+				lower = neighbor[0]
+			}
+		}
+
+		position, err := rank.Between(lower, upper)
+		if err != nil {
+			return err
+		}
+
+		item.Position = position
+		if err = tx.Model(item).Update("position", position).Error; err != nil {
+			return err
+		}
+
+		if !rank.NeedsRebalance(position) {
+			return nil
+		}
+
+		positions, err := rebalancePositions(tx, item.ListID)
+		if err != nil {
+			return err
+		}
+
+		item.Position = positions[item.ID]
+		return nil
+	})
 }
 
-func (r repository) Delete(id uint64) error {
-	return r.db.Delete(&models.Item{}, id).Error
+// Delete moves the item and its sub-items to the trash. The sub-items are
+// restored together with the item. Reminders are kept so they can be
+// delivered again after a restore.
+func (r repository) Delete(id uint64, descendantIDs []uint64, deletedBy uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
+		if len(descendantIDs) > 0 {
+			err := tx.Model(&models.Item{}).
+				Where("id IN ?", descendantIDs).
+				Updates(map[string]interface{}{"deleted_at": now, "deleted_by": deletedBy, "deleted_with_parent": true}).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Item{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": now, "deleted_by": deletedBy}).
+			Error
+	})
 }
 
 func (r repository) IsItemInList(listID uint64, itemID uint64) (bool, error) {
@@ -50,3 +682,383 @@ func (r repository) IsItemInList(listID uint64, itemID uint64) (bool, error) {
 		Error
 	return exists, err
 }
+
+// nextPositions returns count increasing positions after the last item of the
+// list. The last item is locked so concurrent inserts get distinct positions.
+// The positions of the list are spread again when the new ones grow too long.
+func nextPositions(tx *gorm.DB, listID uint64, count int) ([]string, error) {
+	positions, err := appendPositions(tx, listID, count)
+	if err != nil || count == 0 || !rank.NeedsRebalance(positions[count-1]) {
+		return positions, err
+	}
+
+	if _, err = rebalancePositions(tx, listID); err != nil {
+		return nil, err
+	}
+	return appendPositions(tx, listID, count)
+}
+
+func appendPositions(tx *gorm.DB, listID uint64, count int) ([]string, error) {
+	var last []string
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Model(&models.Item{}).
+		Where("list_id = ?", listID).
+		Order("position DESC").
+		Limit(1).
+		Pluck("position", &last).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	lower := ""
+	if len(last) > 0 {
+		lower = last[0]
+	}
+
+	positions := make([]string, count)
+	for i := range positions {
+		if lower, err = rank.Between(lower, ""); err != nil {
+			return nil, err
+		}
+		positions[i] = lower
+	}
+
+	return positions, nil
+}
+
+// rebalancePositions spreads again the positions of the items of the list,
+// trash included, keeping their order, and returns them by item id. Every
+// item of the list is locked while they change.
+func rebalancePositions(tx *gorm.DB, listID uint64) (map[uint64]string, error) {
+	var ids []uint64
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Model(&models.Item{}).
+		Where("list_id = ?", listID).
+		Order("position, id").
+		Pluck("id", &ids).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[uint64]string, len(ids))
+	for i, position := range rank.Spread(len(ids)) {
+		err = tx.Unscoped().Model(&models.Item{}).Where("id = ?", ids[i]).Update("position", position).Error
+		if err != nil {
+			return nil, err
+		}
+		positions[ids[i]] = position
+	}
+
+	return positions, nil
+}
+
+// changeStatus persists a change of status of the item together with its
+// completion. The item is locked so concurrent changes see each other's
+// status, and the new status must have room for the item.
+func changeStatus(tx *gorm.DB, item *models.Item) error {
+	if item.StatusID == nil {
+		return nil
+	}
+
+	var stored models.Item
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("status_id").Take(&stored, item.ID).Error
+	if err != nil {
+		return err
+	}
+
+	if stored.StatusID != nil && *stored.StatusID == *item.StatusID {
+		return nil
+	}
+
+	if err = checkWIPLimit(tx, *item.StatusID, item.ID, 1); err != nil {
+		return err
+	}
+
+	return tx.Model(item).
+		Updates(map[string]interface{}{
+			"status_id":    item.StatusID,
+			"completed_at": item.CompletedAt,
+			"completed_by": item.CompletedBy,
+		}).
+		Error
+}
+
+// recordOccurrence saves the occurrence completed by the change of the item,
+// if any. The item is locked and the occurrence must still be the current
+// one, so completing it twice concurrently fails with errOccurrenceDone.
+func recordOccurrence(tx *gorm.DB, item *models.Item) error {
+	if item.Occurrence == nil {
+		return nil
+	}
+
+	var stored models.Item
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("due_at", "completed_at").Take(&stored, item.ID).Error
+	if err != nil {
+		return err
+	}
+
+	if stored.CompletedAt != nil || stored.DueAt == nil || !stored.DueAt.Equal(item.Occurrence.DueAt) {
+		return errOccurrenceDone
+	}
+
+	return tx.Create(item.Occurrence).Error
+}
+
+// checkWIPLimit fails with errWIPLimit when the status cannot take adding more
+// items within its WIP limit, not counting the given item. The status is
+// locked, so concurrent moves into it are serialized.
+func checkWIPLimit(tx *gorm.DB, statusID uint64, itemID uint64, adding int64) error {
+	var status models.ListStatus
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("wip_limit").Take(&status, statusID).Error
+	if err != nil || status.WIPLimit == nil {
+		return err
+	}
+
+	var count int64
+	err = tx.Model(&models.Item{}).Where("status_id = ? AND id <> ?", statusID, itemID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+
+	if count+adding > int64(*status.WIPLimit) {
+		return errWIPLimit
+	}
+
+	return nil
+}
+
+// checkWIPLimits checks the WIP limits of the statuses taking the given
+// number of items, locking them in the order of their ids.
+func checkWIPLimits(tx *gorm.DB, adding map[uint64]int64) error {
+	statusIDs := make([]uint64, 0, len(adding))
+	for statusID := range adding {
+		statusIDs = append(statusIDs, statusID)
+	}
+	sort.Slice(statusIDs, func(i, j int) bool { return statusIDs[i] < statusIDs[j] })
+
+	for _, statusID := range statusIDs {
+		if err := checkWIPLimit(tx, statusID, 0, adding[statusID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initialStatus returns the first open or done status of the list, nil when
+// the list has none.
+func initialStatus(tx *gorm.DB, listID uint64, done bool) (*uint64, error) {
+	var ids []uint64
+	err := tx.Model(&models.ListStatus{}).
+		Where("list_id = ? AND done = ?", listID, done).
+		Order("position").
+		Limit(1).
+		Pluck("id", &ids).
+		Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	return &ids[0], nil
+}
+
+func getAnchorPosition(query *gorm.DB, id uint64) (string, error) {
+	var position []string
+	err := query.Where("id = ?", id).Pluck("position", &position).Error
+	if err != nil {
+		return "", err
+	}
+
+	if len(position) == 0 {
+		return "", errAnchorNotFound
+	}
+
+	return position[0], nil
+}
+
+func applyItemFilter(db *gorm.DB, filter *models.ItemFilter) *gorm.DB {
+	query := db.Where("list_id = ?", filter.ListID)
+
+	if filter.Tree {
+		query = query.Where("parent_id IS NULL")
+	}
+
+	if filter.AssigneeID != nil {
+		assigned := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.ItemAssignee{}).
+			Select("item_id").
+			Where("user_id = ?", *filter.AssigneeID)
+		query = query.Where("id IN (?)", assigned)
+	}
+
+	if filter.StatusID != nil {
+		query = query.Where("status_id = ?", *filter.StatusID)
+	}
+
+	if filter.Priority != "" {
+		query = query.Where("priority = ?", filter.Priority)
+	}
+
+	// Personal tags of other users match no item, so they cannot be used to
+	// find out what those users tagged.
+	if len(filter.TagIDs) > 0 {
+		tagged := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.ItemTag{}).
+			Select("item_tag.item_id").
+			Joins("JOIN tag ON tag.id = item_tag.tag_id").
+			Where("item_tag.tag_id IN ?", filter.TagIDs).
+			Where("tag.user_id IS NULL OR tag.user_id = ?", filter.UserID)
+		if filter.TagMatch == models.TagMatchAll {
+			tagged = tagged.Group("item_tag.item_id").Having("COUNT(*) = ?", len(filter.TagIDs))
+		}
+		query = query.Where("id IN (?)", tagged)
+	}
+
+	for _, fieldFilter := range filter.Fields {
+		query = applyFieldFilter(db, query, &fieldFilter)
+	}
+
+	if filter.Title != "" {
+		query = query.Where("title LIKE ?", "%"+utils.EscapeLike(filter.Title)+"%")
+	}
+
+	if filter.Description != "" {
+		query = query.Where("description LIKE ?", "%"+utils.EscapeLike(filter.Description)+"%")
+	}
+
+	if filter.DueFrom != nil {
+		query = query.Where("due_at >= ?", *filter.DueFrom)
+	}
+
+	if filter.DueBefore != nil {
+		query = query.Where("due_at < ?", *filter.DueBefore)
+	}
+
+	switch filter.Status {
+	case models.ItemStatusOpen:
+		query = query.Where("completed_at IS NULL")
+	case models.ItemStatusDone:
+		query = query.Where("completed_at IS NOT NULL")
+	}
+
+	return query
+}
+
+// applyFieldFilter keeps the items whose value of the custom field matches
+// the filter. Text fields match by a part of the value, the other types by
+// the exact value or by a range. An unchecked checkbox matches items without
+// a value as well.
+func applyFieldFilter(db *gorm.DB, query *gorm.DB, filter *models.FieldFilter) *gorm.DB {
+	column := filter.Field.Type.Column()
+	values := db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.ItemFieldValue{}).
+		Select("item_id").
+		Where("field_id = ?", filter.Field.ID)
+
+	switch {
+	case filter.Operator == models.FieldOperatorMin:
+		values = values.Where(fmt.Sprintf("%s >= ?", column), filter.Value)
+	case filter.Operator == models.FieldOperatorMax:
+		values = values.Where(fmt.Sprintf("%s <= ?", column), filter.Value)
+	case filter.Field.Type == models.FieldTypeText:
+		values = values.Where("text_value LIKE ?", "%"+utils.EscapeLike(filter.Value.(string))+"%")
+	case filter.Field.Type == models.FieldTypeCheckbox && filter.Value == false:
+		return query.Where("id NOT IN (?)", values.Where("bool_value = ?", true))
+	default:
+		values = values.Where(fmt.Sprintf("%s = ?", column), filter.Value)
+	}
+
+	return query.Where("id IN (?)", values)
+}
+
+// fieldSortColumn returns the expression items are sorted by when sorting by
+// a custom field. Items without a value get the empty sort value of the type,
+// so they have a place in the keyset pagination.
+func fieldSortColumn(field *models.CustomField) string {
+	empty := "''"
+	switch field.Type {
+	case models.FieldTypeNumber:
+		empty = "-1.7976931348623157e308"
+	case models.FieldTypeDate:
+		empty = "'0001-01-01'"
+	case models.FieldTypeCheckbox:
+		empty = "FALSE"
+	case models.FieldTypeUser:
+		empty = "0"
+	}
+
+	return fmt.Sprintf(
+		"COALESCE((SELECT %s FROM item_field_value WHERE item_field_value.item_id = item.id AND item_field_value.field_id = %d), %s)",
+		field.Type.Column(), field.ID, empty,
+	)
+}
+
+// replaceFieldValues swaps the custom field values of the item by the ones
+// set on it. Nil values mean nothing changed.
+func replaceFieldValues(tx *gorm.DB, item *models.Item) error {
+	if item.FieldValues == nil {
+		return nil
+	}
+
+	err := tx.Delete(&models.ItemFieldValue{}, "item_id = ?", item.ID).Error
+	if err != nil || len(item.FieldValues) == 0 {
+		return err
+	}
+
+	for i := range item.FieldValues {
+		item.FieldValues[i].ItemID = item.ID
+	}
+	return tx.Create(&item.FieldValues).Error
+}
+
+// replaceAssignees swaps the assignees of the item by the informed ones. Nil
+// assignees mean nothing changed.
+func replaceAssignees(tx *gorm.DB, item *models.Item) error {
+	if item.AssigneeIDs == nil {
+		return nil
+	}
+
+	err := tx.Delete(&models.ItemAssignee{}, "item_id = ?", item.ID).Error
+	if err != nil || len(item.AssigneeIDs) == 0 {
+		return err
+	}
+
+	assignees := make([]models.ItemAssignee, len(item.AssigneeIDs))
+	for i, userID := range item.AssigneeIDs {
+		assignees[i] = models.ItemAssignee{ItemID: item.ID, UserID: userID}
+	}
+	return tx.Create(&assignees).Error
+}
+
+// keepOnly removes the assignees or watchers, given by model, of the item
+// that are not among the informed users. Nil users mean nothing changed.
+func keepOnly(tx *gorm.DB, model interface{}, itemID uint64, userIDs []uint64) error {
+	if userIDs == nil {
+		return nil
+	}
+
+	if len(userIDs) == 0 {
+		return tx.Delete(model, "item_id = ?", itemID).Error
+	}
+
+	return tx.Delete(model, "item_id = ? AND user_id NOT IN ?", itemID, userIDs).Error
+}
+
+// replacePendingReminders swaps the reminders not sent yet by the ones
+// computed for the item. Nil reminders mean nothing changed.
+func replacePendingReminders(tx *gorm.DB, item *models.Item) error {
+	if item.Reminders == nil {
+		return nil
+	}
+
+	err := tx.Delete(&models.ItemReminder{}, "item_id = ? and sent_at is null", item.ID).Error
+	if err != nil || len(item.Reminders) == 0 {
+		return err
+	}
+
+	for i := range item.Reminders {
+		item.Reminders[i].ItemID = item.ID
+	}
+	return tx.Create(&item.Reminders).Error
+}
@@ -0,0 +1,556 @@
+package item_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/item"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/rank"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type ItemRepositoryTestSuite struct {
+	suite.Suite
+	t          *testing.T
+	repository item.Repository
+	sqlMock    sqlmock.Sqlmock
+}
+
+func (s *ItemRepositoryTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	assert.Nil(s.T(), err)
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+	})
+	assert.Nil(s.T(), err)
+
+	s.repository = item.NewRepository(gdb)
+	s.t = s.T()
+	s.sqlMock = mock
+}
+
+type ItemRepositoryFindTestSuite struct {
+	ItemRepositoryTestSuite
+}
+
+func TestItemRepositoryFindTestSuite(t *testing.T) {
+	suite.Run(t, new(ItemRepositoryFindTestSuite))
+}
+
+func (s ItemRepositoryFindTestSuite) TestFindItemsFirstPage() {
+	userID := uint64(2)
+	filter := models.ItemFilter{ListID: 1, AssigneeID: &userID, Title: "milk", Sort: "title", Order: models.SortAsc, Limit: 2}
+
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT count(*) FROM `item` WHERE list_id = ? AND id IN (SELECT `item_id` FROM `item_assignee` WHERE user_id = ?) AND title LIKE ?",
+	)).WithArgs(1, 2, "%milk%").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	rows := sqlmock.NewRows([]string{"id", "status_id", "list_id", "title"}).
+		AddRow(5, 2, 1, "milk a").
+		AddRow(3, 2, 1, "milk b").
+		AddRow(4, 2, 1, "milk c")
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT * FROM `item` WHERE list_id = ? AND id IN (SELECT `item_id` FROM `item_assignee` WHERE user_id = ?) AND title LIKE ? AND `item`.`deleted_at` IS NULL ORDER BY title ASC,id ASC LIMIT 3",
+	)).WithArgs(1, 2, "%milk%").WillReturnRows(rows)
+
+	items, total, err := s.repository.FindItems(&filter)
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, int64(3), total)
+	assert.Len(s.t, *items, 3)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryFindTestSuite) TestFindItemsAfterCursor() {
+	cursor := models.PageCursor{Sort: "title", Value: "milk b", ID: 3}
+	filter := models.ItemFilter{ListID: 1, Sort: "title", Order: models.SortDesc, Limit: 2, Cursor: &cursor}
+
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `item` WHERE list_id = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	rows := sqlmock.NewRows([]string{"id", "status_id", "list_id", "title"}).AddRow(5, 2, 1, "milk a")
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT * FROM `item` WHERE list_id = ? AND (title < ? OR (title = ? AND id < ?)) AND `item`.`deleted_at` IS NULL ORDER BY title DESC,id DESC LIMIT 3",
+	)).WithArgs(1, "milk b", "milk b", 3).WillReturnRows(rows)
+
+	items, total, err := s.repository.FindItems(&filter)
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, int64(3), total)
+	assert.Len(s.t, *items, 1)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryFindTestSuite) TestFindItemsWithAllTags() {
+	filter := models.ItemFilter{
+		ListID: 1, UserID: 3, TagIDs: []uint64{5, 6}, TagMatch: models.TagMatchAll, Sort: "id", Order: models.SortAsc, Limit: 2,
+	}
+	tagged := "id IN (SELECT item_tag.item_id FROM `item_tag` JOIN tag ON tag.id = item_tag.tag_id " +
+		"WHERE item_tag.tag_id IN (?,?) AND (tag.user_id IS NULL OR tag.user_id = ?) " +
+		"GROUP BY `item_tag`.`item_id` HAVING COUNT(*) = ?)"
+
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `item` WHERE list_id = ? AND "+tagged)).
+		WithArgs(1, 5, 6, 3, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	rows := sqlmock.NewRows([]string{"id", "list_id", "title"}).AddRow(8, 1, "tent")
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT * FROM `item` WHERE list_id = ? AND "+tagged+" AND `item`.`deleted_at` IS NULL ORDER BY id ASC,id ASC LIMIT 3",
+	)).WithArgs(1, 5, 6, 3, 2).WillReturnRows(rows)
+
+	items, total, err := s.repository.FindItems(&filter)
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, int64(1), total)
+	assert.Len(s.t, *items, 1)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryFindTestSuite) TestFindItemsByCustomField() {
+	quantity := models.CustomField{ID: 7, ListID: 1, Name: "quantity", Type: models.FieldTypeNumber}
+	cursor := models.PageCursor{Sort: "field:7", Value: 2.5, ID: 3}
+	filter := models.ItemFilter{
+		ListID:    1,
+		Fields:    []models.FieldFilter{{FieldID: 7, Operator: models.FieldOperatorMin, Field: &quantity, Value: 1.0}},
+		Sort:      "field:7",
+		SortField: &quantity,
+		Order:     models.SortAsc,
+		Limit:     2,
+		Cursor:    &cursor,
+	}
+	matching := "list_id = ? AND id IN (SELECT `item_id` FROM `item_field_value` WHERE field_id = ? AND number_value >= ?)"
+	column := "COALESCE((SELECT number_value FROM item_field_value " +
+		"WHERE item_field_value.item_id = item.id AND item_field_value.field_id = 7), -1.7976931348623157e308)"
+
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `item` WHERE "+matching)).
+		WithArgs(1, 7, 1.0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	rows := sqlmock.NewRows([]string{"id", "list_id", "title"}).AddRow(6, 1, "rope")
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT * FROM `item` WHERE "+matching+" AND ("+column+" > ? OR ("+column+" = ? AND id > ?)) "+
+			"AND `item`.`deleted_at` IS NULL ORDER BY "+column+" ASC,id ASC LIMIT 3",
+	)).WithArgs(1, 7, 1.0, 2.5, 2.5, 3).WillReturnRows(rows)
+
+	items, total, err := s.repository.FindItems(&filter)
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, int64(4), total)
+	assert.Len(s.t, *items, 1)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+type ItemRepositoryMoveTestSuite struct {
+	ItemRepositoryTestSuite
+}
+
+func TestItemRepositoryMoveTestSuite(t *testing.T) {
+	suite.Run(t, new(ItemRepositoryMoveTestSuite))
+}
+
+func (s ItemRepositoryMoveTestSuite) TestMoveAfterAnchor() {
+	afterID := uint64(4)
+	moved := models.Item{ID: 9, ListID: 1, Position: "z"}
+	neighbors := "SELECT `position` FROM `item` WHERE (list_id = ? AND id <> ?) AND "
+
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(neighbors+"id = ? AND `item`.`deleted_at` IS NULL FOR UPDATE")).
+		WithArgs(1, 9, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("a"))
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		neighbors+"position > ? AND `item`.`deleted_at` IS NULL ORDER BY position ASC LIMIT 1 FOR UPDATE",
+	)).WithArgs(1, 9, "a").WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("b"))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `item` SET `position`=? WHERE `item`.`deleted_at` IS NULL AND `id` = ?")).
+		WithArgs("ai", 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectCommit()
+
+	err := s.repository.Move(&moved, &afterID, nil)
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, "ai", moved.Position)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryMoveTestSuite) TestMoveSpreadsLongPositions() {
+	afterID := uint64(4)
+	moved := models.Item{ID: 9, ListID: 1, Position: "z"}
+	long := "a" + strings.Repeat("z", rank.RebalanceLength)
+	neighbors := "SELECT `position` FROM `item` WHERE (list_id = ? AND id <> ?) AND "
+
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(neighbors+"id = ? AND `item`.`deleted_at` IS NULL FOR UPDATE")).
+		WithArgs(1, 9, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(long))
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(neighbors+"position > ?")).
+		WithArgs(1, 9, long).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("b"))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `item` SET `position`=?")).
+		WithArgs(long+"i", 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT `id` FROM `item` WHERE list_id = ? ORDER BY position, id FOR UPDATE")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(9).AddRow(5))
+	for _, update := range []struct {
+		position string
+		id       uint64
+	}{{"9i", 4}, {"ii", 9}, {"ri", 5}} {
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `item` SET `position`=? WHERE id = ?")).
+			WithArgs(update.position, update.id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	s.sqlMock.ExpectCommit()
+
+	err := s.repository.Move(&moved, &afterID, nil)
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, "ii", moved.Position)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryMoveTestSuite) TestMoveAnchorNotFound() {
+	beforeID := uint64(4)
+	moved := models.Item{ID: 9, ListID: 1}
+
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT `position` FROM `item`")).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}))
+	s.sqlMock.ExpectRollback()
+
+	err := s.repository.Move(&moved, nil, &beforeID)
+
+	assert.NotNil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+type ItemRepositoryTransferTestSuite struct {
+	ItemRepositoryTestSuite
+}
+
+func TestItemRepositoryTransferTestSuite(t *testing.T) {
+	suite.Run(t, new(ItemRepositoryTransferTestSuite))
+}
+
+func (s ItemRepositoryTransferTestSuite) expectNextPosition(listID uint64, last string) {
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT `position` FROM `item` WHERE list_id = ? ORDER BY position DESC LIMIT 1 FOR UPDATE",
+	)).WithArgs(listID).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(last))
+}
+
+func (s ItemRepositoryTransferTestSuite) expectInitialStatuses(listID uint64, open *uint64, done *uint64) {
+	query := "SELECT `id` FROM `list_status` WHERE list_id = ? AND done = ? ORDER BY position LIMIT 1"
+	for _, status := range []struct {
+		done bool
+		id   *uint64
+	}{{false, open}, {true, done}} {
+		rows := sqlmock.NewRows([]string{"id"})
+		if status.id != nil {
+			rows.AddRow(*status.id)
+		}
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(listID, status.done).WillReturnRows(rows)
+	}
+}
+
+func (s ItemRepositoryTransferTestSuite) TestTransferSuccess() {
+	parentID, openStatusID := uint64(3), uint64(8)
+	completedAt := time.Date(2022, 9, 20, 12, 0, 0, 0, time.UTC)
+	items := []models.Item{{ID: 3, ListID: 1}, {ID: 4, ListID: 1, ParentID: &parentID, CompletedAt: &completedAt}}
+	update := "UPDATE `item` SET `list_id`=?,`parent_id`=?,`position`=?,`status_id`=? " +
+		"WHERE (id = ? AND list_id = ?) AND `item`.`deleted_at` IS NULL"
+
+	s.sqlMock.ExpectBegin()
+	s.expectNextPosition(2, "m")
+	s.expectInitialStatuses(2, &openStatusID, nil)
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT `wip_limit` FROM `list_status` WHERE `list_status`.`id` = ? LIMIT 1 FOR UPDATE",
+	)).WithArgs(8).WillReturnRows(sqlmock.NewRows([]string{"wip_limit"}).AddRow(nil))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(update)).WithArgs(2, nil, "t", 8, 3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(update)).WithArgs(2, 3, "x", nil, 4, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(
+		"DELETE FROM `item_tag` WHERE item_id IN (?,?) AND tag_id IN (SELECT `id` FROM `tag` WHERE list_id <> ?)",
+	)).WithArgs(3, 4, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `item_field_value` WHERE item_id IN (?,?)")).
+		WithArgs(3, 4).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.sqlMock.ExpectCommit()
+
+	err := s.repository.Transfer(&items, 2)
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, uint64(2), items[1].ListID)
+	assert.Equal(s.t, "x", items[1].Position)
+	assert.Equal(s.t, &openStatusID, items[0].StatusID)
+	assert.Nil(s.t, items[1].StatusID)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryTransferTestSuite) TestTransferDropsPeopleNotKept() {
+	items := []models.Item{{ID: 3, ListID: 1, AssigneeIDs: []uint64{5, 6}, WatcherIDs: []uint64{}}}
+
+	s.sqlMock.ExpectBegin()
+	s.expectNextPosition(2, "m")
+	s.expectInitialStatuses(2, nil, nil)
+	s.sqlMock.ExpectExec("UPDATE `item`").WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `item_assignee` WHERE item_id = ? AND user_id NOT IN (?,?)")).
+		WithArgs(3, 5, 6).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `item_watcher` WHERE item_id = ?")).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.sqlMock.ExpectExec("DELETE FROM `item_tag`").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectExec("DELETE FROM `item_field_value`").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectCommit()
+
+	err := s.repository.Transfer(&items, 2)
+
+	assert.Nil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryTransferTestSuite) TestTransferIntoFullStatus() {
+	openStatusID, doneStatusID := uint64(8), uint64(9)
+	completedAt := time.Date(2022, 9, 20, 12, 0, 0, 0, time.UTC)
+	items := []models.Item{{ID: 3, ListID: 1}, {ID: 4, ListID: 1}, {ID: 5, ListID: 1, CompletedAt: &completedAt}}
+
+	s.sqlMock.ExpectBegin()
+	s.expectNextPosition(2, "m")
+	s.expectInitialStatuses(2, &openStatusID, &doneStatusID)
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT `wip_limit` FROM `list_status` WHERE `list_status`.`id` = ? LIMIT 1 FOR UPDATE",
+	)).WithArgs(8).WillReturnRows(sqlmock.NewRows([]string{"wip_limit"}).AddRow(3))
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT count(*) FROM `item` WHERE (status_id = ? AND id <> ?) AND `item`.`deleted_at` IS NULL",
+	)).WithArgs(8, 0).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	s.sqlMock.ExpectRollback()
+
+	err := s.repository.Transfer(&items, 2)
+
+	assert.NotNil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryTransferTestSuite) TestTransferRollsBackWhenItemChanged() {
+	items := []models.Item{{ID: 3, ListID: 1}, {ID: 4, ListID: 1}}
+
+	s.sqlMock.ExpectBegin()
+	s.expectNextPosition(2, "m")
+	s.expectInitialStatuses(2, nil, nil)
+	s.sqlMock.ExpectExec("UPDATE `item`").WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec("UPDATE `item`").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectRollback()
+
+	err := s.repository.Transfer(&items, 2)
+
+	assert.NotNil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryFindTestSuite) TestGetDescendantsLevelByLevel() {
+	query := "SELECT * FROM `item` WHERE parent_id IN (?) AND `item`.`deleted_at` IS NULL ORDER BY position"
+
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "title"}).AddRow(2, 1, "socks"))
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "title"}).AddRow(3, 2, "wool socks"))
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "title"}))
+
+	descendants, err := s.repository.GetDescendants([]uint64{1})
+
+	assert.Nil(s.t, err)
+	assert.Len(s.t, *descendants, 2)
+	assert.Equal(s.t, uint64(3), (*descendants)[1].ID)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+type ItemRepositoryStatusTestSuite struct {
+	ItemRepositoryTestSuite
+}
+
+func TestItemRepositoryStatusTestSuite(t *testing.T) {
+	suite.Run(t, new(ItemRepositoryStatusTestSuite))
+}
+
+func (s ItemRepositoryStatusTestSuite) expectStatusChange(limit int, count int) {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT `status_id` FROM `item` WHERE `item`.`id` = ? AND `item`.`deleted_at` IS NULL LIMIT 1 FOR UPDATE",
+	)).WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"status_id"}).AddRow(5))
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT `wip_limit` FROM `list_status` WHERE `list_status`.`id` = ? LIMIT 1 FOR UPDATE",
+	)).WithArgs(6).WillReturnRows(sqlmock.NewRows([]string{"wip_limit"}).AddRow(limit))
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT count(*) FROM `item` WHERE (status_id = ? AND id <> ?) AND `item`.`deleted_at` IS NULL",
+	)).WithArgs(6, 9).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func (s ItemRepositoryStatusTestSuite) TestUpdateIntoFullStatus() {
+	statusID := uint64(6)
+	item := models.Item{ID: 9, ListID: 1, StatusID: &statusID}
+
+	s.expectStatusChange(2, 2)
+	s.sqlMock.ExpectRollback()
+
+	err := s.repository.Update(&item)
+
+	assert.NotNil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryStatusTestSuite) TestUpdateStatusWritesCompletion() {
+	statusID, userID := uint64(6), uint64(2)
+	completedAt := time.Date(2022, 9, 20, 12, 0, 0, 0, time.UTC)
+	item := models.Item{ID: 9, ListID: 1, StatusID: &statusID, CompletedAt: &completedAt, CompletedBy: &userID}
+
+	s.expectStatusChange(3, 2)
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(
+		"UPDATE `item` SET `completed_at`=?,`completed_by`=?,`status_id`=? WHERE `item`.`deleted_at` IS NULL AND `id` = ?",
+	)).WithArgs(completedAt, 2, 6, 9).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec("UPDATE `item`").WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec("UPDATE `item`").WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectCommit()
+
+	err := s.repository.Update(&item)
+
+	assert.Nil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+type ItemRepositoryOccurrenceTestSuite struct {
+	ItemRepositoryTestSuite
+}
+
+func TestItemRepositoryOccurrenceTestSuite(t *testing.T) {
+	suite.Run(t, new(ItemRepositoryOccurrenceTestSuite))
+}
+
+func (s ItemRepositoryOccurrenceTestSuite) expectLockedItem(dueAt time.Time) {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT `due_at`,`completed_at` FROM `item` WHERE `item`.`id` = ? AND `item`.`deleted_at` IS NULL LIMIT 1 FOR UPDATE",
+	)).WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"due_at", "completed_at"}).AddRow(dueAt, nil))
+}
+
+func (s ItemRepositoryOccurrenceTestSuite) TestCompleteOccurrence() {
+	userID := uint64(2)
+	dueAt := time.Date(2022, 9, 20, 12, 0, 0, 0, time.UTC)
+	nextDueAt := dueAt.AddDate(0, 0, 7)
+	completedAt := time.Date(2022, 9, 21, 8, 0, 0, 0, time.UTC)
+	item := models.Item{
+		ID:        9,
+		ListID:    1,
+		DueAt:     &nextDueAt,
+		Reminders: []models.ItemReminder{},
+		Occurrence: &models.ItemOccurrence{
+			ItemID: 9, DueAt: dueAt, CompletedAt: completedAt, CompletedBy: &userID,
+		},
+	}
+
+	s.expectLockedItem(dueAt)
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(
+		"INSERT INTO `item_occurrence` (`item_id`,`due_at`,`completed_at`,`completed_by`) VALUES (?,?,?,?)",
+	)).WithArgs(9, dueAt, completedAt, 2).WillReturnResult(sqlmock.NewResult(4, 1))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(
+		"UPDATE `item` SET `completed_at`=?,`completed_by`=?,`due_at`=? WHERE `item`.`deleted_at` IS NULL AND `id` = ?",
+	)).WithArgs(nil, nil, nextDueAt, 9).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(
+		"DELETE FROM `item_reminder` WHERE item_id = ? and sent_at is null",
+	)).WithArgs(9).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectCommit()
+
+	err := s.repository.CompleteOccurrence(&item)
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, uint64(4), item.Occurrence.ID)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryOccurrenceTestSuite) TestCompleteOccurrenceTwice() {
+	dueAt := time.Date(2022, 9, 20, 12, 0, 0, 0, time.UTC)
+	nextDueAt := dueAt.AddDate(0, 0, 7)
+	item := models.Item{
+		ID:         9,
+		ListID:     1,
+		DueAt:      &nextDueAt,
+		Occurrence: &models.ItemOccurrence{ItemID: 9, DueAt: dueAt},
+	}
+
+	s.expectLockedItem(nextDueAt)
+	s.sqlMock.ExpectRollback()
+
+	err := s.repository.CompleteOccurrence(&item)
+
+	assert.NotNil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryFindTestSuite) TestFindNextUp() {
+	now := time.Date(2022, 9, 20, 12, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "list_id", "title", "priority", "list_title"}).
+		AddRow(5, 1, "pay bills", 3, "home").
+		AddRow(8, 2, "review", 0, "work")
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT item.*, (SELECT title FROM list WHERE list.id = item.list_id) AS list_title FROM `item` "+
+			"WHERE (id IN (SELECT `item_id` FROM `item_assignee` WHERE user_id = ?) "+
+			"AND list_id IN (SELECT `id` FROM `list` WHERE (user_id = ? OR id IN (SELECT `list_id` FROM `list_member` WHERE user_id = ?)) "+
+			"AND `list`.`deleted_at` IS NULL)) "+
+			"AND completed_at IS NULL AND `item`.`deleted_at` IS NULL "+
+			"ORDER BY due_at < ? DESC, due_at IS NULL, due_at, priority DESC, position, id "+
+			"LIMIT 10",
+	)).WithArgs(2, 2, 2, now).WillReturnRows(rows)
+
+	items, err := s.repository.FindNextUp(2, now, 10)
+
+	assert.Nil(s.t, err)
+	assert.Len(s.t, *items, 2)
+	assert.Equal(s.t, models.ItemPriorityHigh, (*items)[0].Priority)
+	assert.Equal(s.t, "home", (*items)[0].ListTitle)
+	assert.Equal(s.t, models.ItemPriorityNone, (*items)[1].Priority)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryFindTestSuite) TestGetAssignees() {
+	rows := sqlmock.NewRows([]string{"item_id", "user_id"}).
+		AddRow(5, 2).
+		AddRow(5, 3).
+		AddRow(8, 2)
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT * FROM `item_assignee` WHERE item_id IN (?,?) ORDER BY created_at, user_id",
+	)).WithArgs(5, 8).WillReturnRows(rows)
+
+	assignees, err := s.repository.GetAssignees([]uint64{5, 8})
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, []uint64{2, 3}, assignees[5])
+	assert.Equal(s.t, []uint64{2}, assignees[8])
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ItemRepositoryFindTestSuite) TestAssignIgnoresDuplicates() {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(
+		"INSERT INTO `item_assignee` (`item_id`,`user_id`,`created_at`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `item_id`=`item_id`",
+	)).WithArgs(5, 2, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectCommit()
+
+	err := s.repository.Assign(5, 2)
+
+	assert.Nil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
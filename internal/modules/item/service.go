@@ -1,49 +1,115 @@
 package item
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"time"
 
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
-	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/list"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/authorization"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/field"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/user"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/workflow"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/clock"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/rank"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/rrule"
 )
 
 type (
 	Service interface {
-		Save(item *models.Item) error
-		GetItemsFromList(listID uint64) (*[]models.Item, error)
-		Update(user *models.Item) error
-		Delete(listID uint64, itemID uint64) error
+		Save(item *models.Item, userID uint64) error
+		GetItemsFromList(filter *models.ItemFilter, userID uint64) (*models.ItemPage, error)
+		Update(item *models.Item, userID uint64) error
+		Delete(listID uint64, itemID uint64, userID uint64, cascade bool) error
+		Complete(listID uint64, itemID uint64, userID uint64) (*models.Item, error)
+		Reopen(listID uint64, itemID uint64, userID uint64) (*models.Item, error)
+		GetOccurrences(listID uint64, itemID uint64, userID uint64) (*[]models.ItemOccurrence, error)
+		Move(listID uint64, itemID uint64, userID uint64, move *models.ItemMoveDTO) (*models.Item, error)
+		Transfer(listID uint64, itemIDs []uint64, targetListID uint64, userID uint64) (*[]models.Item, error)
+		Copy(listID uint64, itemIDs []uint64, targetListID uint64, userID uint64) (*[]models.Item, error)
+		GetBoard(listID uint64, userID uint64, limit int) (*models.Board, error)
+		GetNextUp(userID uint64, limit int) (*[]models.UserItem, error)
+		GetAssigned(filter *models.AssignedFilter) (*models.UserItemPage, error)
+		Assign(listID uint64, itemID uint64, assigneeID uint64, userID uint64) (*models.Item, error)
+		Unassign(listID uint64, itemID uint64, assigneeID uint64, userID uint64) (*models.Item, error)
+		Watch(listID uint64, itemID uint64, userID uint64) (*models.Item, error)
+		Unwatch(listID uint64, itemID uint64, userID uint64) (*models.Item, error)
 	}
 
 	service struct {
-		repository     Repository
-		listRepository list.Repository
-		userRepository user.Repository
+		repository           Repository
+		authorizationService authorization.Service
+		userRepository       user.Repository
+		fieldRepository      field.Repository
+		workflowRepository   workflow.Repository
+		clock                clock.Clock
 	}
 )
 
 func NewService(
 	repository Repository,
-	listRepository list.Repository,
+	authorizationService authorization.Service,
 	userRepository user.Repository,
+	fieldRepository field.Repository,
+	workflowRepository workflow.Repository,
+	clock clock.Clock,
 ) Service {
-	return &service{repository, listRepository, userRepository}
+	return &service{repository, authorizationService, userRepository, fieldRepository, workflowRepository, clock}
 }
 
-func (s service) Save(item *models.Item) error {
-	err := s.checkIfListExists(item.ListID)
+func (s service) Save(item *models.Item, userID uint64) error {
+	err := s.authorizationService.CheckListPermission(item.ListID, userID, models.ListRoleEditor)
 	if err != nil {
 		return err
 	}
 
-	err = s.checkIfUserExists(*item.UserID)
+	item.AssigneeIDs, err = s.checkAssignees(item.ListID, item.AssigneeIDs)
+	if err != nil {
+		return err
+	}
+
+	if item.ParentID != nil && *item.ParentID == 0 {
+		item.ParentID = nil
+	}
+
+	if item.Priority == "" {
+		item.Priority = models.ItemPriorityNone
+	}
+
+	err = s.checkParent(item)
+	if err != nil {
+		return err
+	}
+
+	err = s.prepareStatus(item, nil, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.prepareRecurrence(item)
+	if err != nil {
+		return err
+	}
+
+	err = s.prepareDueDate(item)
+	if err != nil {
+		return err
+	}
+
+	err = s.prepareFieldValues(item, nil)
 	if err != nil {
 		return err
 	}
 
 	err = s.repository.Save(item)
+	if errors.Is(err, errWIPLimit) {
+		return apperrors.NewObjectInInvalidStateError("the status of the item reached its WIP limit")
+	}
+
 	if err != nil {
 		log.Printf("Error saving item: %s\n", err.Error())
 		return apperrors.NewInternalError("Internal error saving item")
@@ -52,47 +118,163 @@ func (s service) Save(item *models.Item) error {
 	return nil
 }
 
-func (s service) GetItemsFromList(listID uint64) (*[]models.Item, error) {
-	list, err := s.listRepository.Get(listID)
+func (s service) GetItemsFromList(filter *models.ItemFilter, userID uint64) (*models.ItemPage, error) {
+	err := s.authorizationService.CheckListPermission(filter.ListID, userID, models.ListRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Cursor != nil && filter.Cursor.Sort != filter.Sort {
+		return nil, apperrors.NewObjectInInvalidStateError("cursor does not match the requested sort")
+	}
+
+	filter.UserID = userID
+	s.applyDueFilter(filter)
+
+	err = s.resolveFieldFilters(filter)
 	if err != nil {
-		log.Printf("Error getting list: %s\n", err.Error())
+		return nil, err
+	}
+
+	items, total, err := s.repository.FindItems(filter)
+	if err != nil {
+		log.Printf("Error getting items from list: %s\n", err.Error())
 		return nil, apperrors.NewInternalError("Internal error getting items from list")
 	}
 
-	if list == nil {
-		return nil, apperrors.NewNotFoundError("list", listID)
+	page := models.ItemPage{Items: *items, Total: total}
+	hasNextPage := len(page.Items) > filter.Limit
+	if hasNextPage {
+		page.Items = page.Items[:filter.Limit]
+	}
+
+	err = s.attachSubItems(page.Items, filter.Tree)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.attachTags(page.Items, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.attachFieldValues(page.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.attachPeople(page.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasNextPage {
+		last := page.Items[len(page.Items)-1]
+		value := last.SortValue(filter.Sort, filter.SortField)
+		cursor := models.PageCursor{Sort: filter.Sort, Value: value, ID: last.ID}.Encode()
+		page.NextCursor = &cursor
 	}
 
-	return s.repository.GetItemsFromList(list.ID)
+	return &page, nil
 }
 
-func (s service) Update(item *models.Item) error {
-	err := s.checkIfItemExistsInList(item.ListID, item.ID)
+func (s service) Update(item *models.Item, userID uint64) error {
+	stored, err := s.getItemFromList(item.ListID, item.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	item.AssigneeIDs, err = s.checkAssignees(item.ListID, item.AssigneeIDs)
+	if err != nil {
+		return err
+	}
+
+	mergeStoredItem(item, stored)
+
+	err = s.checkParent(item)
+	if err != nil {
+		return err
+	}
+
+	err = s.prepareStatus(item, stored, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.prepareRecurrence(item)
+	if err != nil {
+		return err
+	}
+
+	// Moving a recurring item to a done status completes its current
+	// occurrence only.
+	if stored.CompletedAt == nil && item.CompletedAt != nil && item.Recurrence != nil {
+		err = s.completeOccurrence(item, *item.CompletedAt, item.CompletedBy)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.prepareDueDate(item)
 	if err != nil {
 		return err
 	}
 
-	err = s.checkIfUserExists(*item.UserID)
+	values, err := s.repository.GetFieldValues([]uint64{item.ID})
+	if err != nil {
+		log.Printf("Error getting item field values: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error getting item field values")
+	}
+
+	err = s.prepareFieldValues(item, values[item.ID])
 	if err != nil {
 		return err
 	}
 
 	err = s.repository.Update(item)
+	if errors.Is(err, errWIPLimit) {
+		return apperrors.NewObjectInInvalidStateError("the new status of the item reached its WIP limit")
+	}
+
+	if errors.Is(err, errOccurrenceDone) {
+		return apperrors.NewObjectInInvalidStateError("the occurrence of the item was already completed")
+	}
+
 	if err != nil {
 		log.Printf("Error updating item: %s\n", err.Error())
 		return apperrors.NewInternalError("Internal error updating item")
 	}
 
-	return nil
+	if item.FieldValues == nil {
+		item.FieldValues = values[item.ID]
+	}
+
+	return s.attachPeopleToItem(item)
 }
 
-func (s service) Delete(listID uint64, itemID uint64) error {
-	err := s.checkIfItemExistsInList(listID, itemID)
+// Delete moves the item to the trash. An item with sub-items can only be
+// deleted with cascade, which sends its sub-items to the trash as well.
+func (s service) Delete(listID uint64, itemID uint64, userID uint64, cascade bool) error {
+	err := s.checkIfItemExistsInList(listID, itemID, userID)
+	if err != nil {
+		return err
+	}
+
+	descendants, err := s.getDescendants([]uint64{itemID})
 	if err != nil {
 		return err
 	}
 
-	err = s.repository.Delete(itemID)
+	if len(*descendants) > 0 && !cascade {
+		return apperrors.NewObjectInInvalidStateError("item has sub-items, use cascade=true to delete them")
+	}
+
+	descendantIDs := make([]uint64, len(*descendants))
+	for i, descendant := range *descendants {
+		descendantIDs[i] = descendant.ID
+	}
+
+	err = s.repository.Delete(itemID, descendantIDs, userID)
 	if err != nil {
 		log.Printf("Error deletting item: %s\n", err.Error())
 		return apperrors.NewInternalError("Internal error deleting item")
@@ -101,45 +283,1292 @@ func (s service) Delete(listID uint64, itemID uint64) error {
 	return nil
 }
 
-func (s service) checkIfItemExistsInList(listID uint64, itemID uint64) error {
-	err := s.checkIfListExists(listID)
+// Complete marks the item as done by the user. Completing an item that is
+// already done keeps the original completion data. Items of lists with a
+// workflow are completed by moving them to a done status instead. Completing
+// a recurring item completes its current occurrence and keeps it open with
+// the due date of the next one.
+func (s service) Complete(listID uint64, itemID uint64, userID uint64) (*models.Item, error) {
+	item, err := s.getItemFromList(listID, itemID, userID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err = s.checkNoWorkflow(listID); err != nil {
+		return nil, err
+	}
+
+	if item.CompletedAt != nil {
+		return item, nil
+	}
+
+	now := s.clock.Now().UTC()
+	var completedBy *uint64
+	if userID != 0 {
+		completedBy = &userID
+	}
+
+	if item.Recurrence == nil {
+		item.CompletedAt = &now
+		item.CompletedBy = completedBy
+		return item, s.setCompletion(item)
+	}
+
+	if err = s.completeOccurrence(item, now, completedBy); err != nil {
+		return nil, err
+	}
+
+	if err = s.prepareDueDate(item); err != nil {
+		return nil, err
+	}
+
+	err = s.repository.CompleteOccurrence(item)
+	if errors.Is(err, errOccurrenceDone) {
+		return nil, apperrors.NewObjectInInvalidStateError("the occurrence of the item was already completed")
 	}
 
-	isItemOnList, err := s.repository.IsItemInList(listID, itemID)
 	if err != nil {
-		return apperrors.NewInternalError("Internal error checking item in list")
+		log.Printf("Error completing item occurrence: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error completing item occurrence")
 	}
 
-	if !isItemOnList {
-		return apperrors.NewItemNotFoundInListError(itemID, listID)
+	return item, nil
+}
+
+func (s service) Reopen(listID uint64, itemID uint64, userID uint64) (*models.Item, error) {
+	item, err := s.getItemFromList(listID, itemID, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	if err = s.checkNoWorkflow(listID); err != nil {
+		return nil, err
+	}
+
+	if item.CompletedAt == nil {
+		return item, nil
+	}
+
+	item.CompletedAt = nil
+	item.CompletedBy = nil
+
+	return item, s.setCompletion(item)
 }
 
-func (s service) checkIfUserExists(userID uint64) error {
-	exists, err := s.userRepository.Exists(userID)
+// GetOccurrences returns the history of completed occurrences of the item,
+// the most recent first, with their due dates in the timezone of the item.
+func (s service) GetOccurrences(listID uint64, itemID uint64, userID uint64) (*[]models.ItemOccurrence, error) {
+	item, err := s.getVisibleItemFromList(listID, itemID, userID)
 	if err != nil {
-		return apperrors.NewInternalError("Internal error checking if user exists")
+		return nil, err
 	}
 
-	if !exists {
-		return apperrors.NewNotFoundError("user_id", userID)
+	occurrences, err := s.repository.GetOccurrences(itemID)
+	if err != nil {
+		log.Printf("Error getting item occurrences: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting item occurrences")
 	}
 
-	return nil
+	location := item.DueLocation()
+	for i := range *occurrences {
+		(*occurrences)[i].DueAt = (*occurrences)[i].DueAt.In(location)
+	}
+
+	return occurrences, nil
 }
 
-func (s service) checkIfListExists(listID uint64) error {
-	exists, err := s.listRepository.Exists(listID)
+// Move changes the position of the item in its list, placing it right after
+// the after item and/or right before the before item.
+func (s service) Move(listID uint64, itemID uint64, userID uint64, move *models.ItemMoveDTO) (*models.Item, error) {
+	item, err := s.getItemFromList(listID, itemID, userID)
 	if err != nil {
-		return apperrors.NewInternalError("Internal error checking if list exists")
+		return nil, err
 	}
 
-	if !exists {
-		return apperrors.NewNotFoundError("list", listID)
+	for _, anchorID := range []*uint64{move.AfterID, move.BeforeID} {
+		if anchorID == nil {
+			continue
+		}
+
+		if *anchorID == itemID {
+			return nil, apperrors.NewObjectInInvalidStateError("item cannot be moved relative to itself")
+		}
+
+		if err = s.checkIfItemIsInList(listID, *anchorID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.repository.Move(item, move.AfterID, move.BeforeID)
+	if errors.Is(err, rank.ErrInvalidRange) {
+		return nil, apperrors.NewObjectInInvalidStateError("after_id must come before before_id")
+	}
+
+	if errors.Is(err, errAnchorNotFound) {
+		return nil, apperrors.NewObjectInInvalidStateError("anchor item was removed from the list")
+	}
+
+	if err != nil {
+		log.Printf("Error moving item: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error moving item")
+	}
+
+	return item, nil
+}
+
+// Transfer moves the items and their sub-items to the end of another list.
+// The user must be able to edit both lists and either all items are moved or
+// none is. Items whose parent is not moved become root items, and assignees
+// and watchers who cannot access the other list are dropped.
+func (s service) Transfer(listID uint64, itemIDs []uint64, targetListID uint64, userID uint64) (*[]models.Item, error) {
+	items, err := s.getItemsToTransfer(listID, itemIDs, targetListID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.keepTargetListPeople(*items, targetListID); err != nil {
+		return nil, err
+	}
+
+	err = s.repository.Transfer(items, targetListID)
+	if errors.Is(err, errItemChanged) {
+		return nil, apperrors.NewObjectInInvalidStateError("items changed during the transfer, try again")
+	}
+
+	if errors.Is(err, errWIPLimit) {
+		return nil, apperrors.NewObjectInInvalidStateError("the status of the target list reached its WIP limit")
+	}
+
+	if err != nil {
+		log.Printf("Error transferring items: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error transferring items")
+	}
+
+	return items, nil
+}
+
+// Copy creates open copies of the items and their sub-items at the end of
+// another list, assigned to the assignees who can access it. The user must be
+// able to edit both lists.
+func (s service) Copy(listID uint64, itemIDs []uint64, targetListID uint64, userID uint64) (*[]models.Item, error) {
+	items, err := s.getItemsToTransfer(listID, itemIDs, targetListID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.keepTargetListPeople(*items, targetListID); err != nil {
+		return nil, err
+	}
+
+	for i := range *items {
+		item := &(*items)[i]
+		item.CompletedAt = nil
+		item.CompletedBy = nil
+		item.CreatedAt = time.Time{}
+
+		if err = s.prepareDueDate(item); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.repository.Copy(items, targetListID)
+	if errors.Is(err, errWIPLimit) {
+		return nil, apperrors.NewObjectInInvalidStateError("the status of the target list reached its WIP limit")
+	}
+
+	if err != nil {
+		log.Printf("Error copying items: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error copying items")
+	}
+
+	return items, nil
+}
+
+// GetBoard returns the items of the list grouped by status, in the workflow
+// order, with up to limit items per status.
+func (s service) GetBoard(listID uint64, userID uint64, limit int) (*models.Board, error) {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := s.getListStatuses(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(statuses) == 0 {
+		return nil, apperrors.NewObjectInInvalidStateError("list has no statuses")
+	}
+
+	counts, err := s.repository.CountByStatus(listID)
+	if err != nil {
+		log.Printf("Error counting items by status: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error counting items by status")
+	}
+
+	board := models.Board{Columns: make([]models.BoardColumn, len(statuses))}
+	for i, status := range statuses {
+		items, err := s.repository.GetItemsByStatus(status.ID, limit)
+		if err != nil {
+			log.Printf("Error getting items by status: %s\n", err.Error())
+			return nil, apperrors.NewInternalError("Internal error getting items by status")
+		}
+
+		if err = s.attachSubItems(*items, false); err != nil {
+			return nil, err
+		}
+
+		if err = s.attachTags(*items, userID); err != nil {
+			return nil, err
+		}
+
+		if err = s.attachFieldValues(*items); err != nil {
+			return nil, err
+		}
+
+		if err = s.attachPeople(*items); err != nil {
+			return nil, err
+		}
+
+		board.Columns[i] = models.BoardColumn{Status: status, Count: counts[status.ID], Items: *items}
+	}
+
+	return &board, nil
+}
+
+// GetNextUp answers what the user should do now: the open items assigned to
+// the user across the accessible lists, overdue items first, then by due
+// date, priority and position.
+func (s service) GetNextUp(userID uint64, limit int) (*[]models.UserItem, error) {
+	now := s.clock.Now().UTC()
+	nextUp, err := s.repository.FindNextUp(userID, now, limit)
+	if err != nil {
+		log.Printf("Error getting next up items: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting next up items")
+	}
+
+	if err = s.attachUserItemDetails(*nextUp, userID, now); err != nil {
+		return nil, err
+	}
+
+	return nextUp, nil
+}
+
+// GetAssigned returns the items assigned to the user across the lists the
+// user can access, paginated.
+func (s service) GetAssigned(filter *models.AssignedFilter) (*models.UserItemPage, error) {
+	if filter.Cursor != nil && filter.Cursor.Sort != filter.Sort {
+		return nil, apperrors.NewObjectInInvalidStateError("cursor does not match the requested sort")
+	}
+
+	items, total, err := s.repository.FindAssigned(filter)
+	if err != nil {
+		log.Printf("Error getting assigned items: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting assigned items")
+	}
+
+	page := models.UserItemPage{Items: *items, Total: total}
+	hasNextPage := len(page.Items) > filter.Limit
+	if hasNextPage {
+		page.Items = page.Items[:filter.Limit]
+	}
+
+	if err = s.attachUserItemDetails(page.Items, filter.UserID, s.clock.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	if hasNextPage {
+		last := page.Items[len(page.Items)-1]
+		value := last.SortValue(filter.Sort, nil)
+		cursor := models.PageCursor{Sort: filter.Sort, Value: value, ID: last.ID}.Encode()
+		page.NextCursor = &cursor
+	}
+
+	return &page, nil
+}
+
+// Assign adds a user who can access the list of the item to its assignees.
+func (s service) Assign(listID uint64, itemID uint64, assigneeID uint64, userID uint64) (*models.Item, error) {
+	item, err := s.getItemFromList(listID, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.checkAssignees(listID, []uint64{assigneeID}); err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.Assign(itemID, assigneeID); err != nil {
+		log.Printf("Error assigning item: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error assigning item")
+	}
+
+	return item, s.attachPeopleToItem(item)
+}
+
+func (s service) Unassign(listID uint64, itemID uint64, assigneeID uint64, userID uint64) (*models.Item, error) {
+	item, err := s.getItemFromList(listID, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.Unassign(itemID, assigneeID); err != nil {
+		log.Printf("Error unassigning item: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error unassigning item")
+	}
+
+	return item, s.attachPeopleToItem(item)
+}
+
+// Watch makes the user a watcher of the item. Anyone who can view the list
+// can watch its items.
+func (s service) Watch(listID uint64, itemID uint64, userID uint64) (*models.Item, error) {
+	item, err := s.getVisibleItemFromList(listID, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.Watch(itemID, userID); err != nil {
+		log.Printf("Error watching item: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error watching item")
+	}
+
+	return item, s.attachPeopleToItem(item)
+}
+
+func (s service) Unwatch(listID uint64, itemID uint64, userID uint64) (*models.Item, error) {
+	item, err := s.getVisibleItemFromList(listID, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.Unwatch(itemID, userID); err != nil {
+		log.Printf("Error unwatching item: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error unwatching item")
+	}
+
+	return item, s.attachPeopleToItem(item)
+}
+
+// checkAssignees removes the repeated assignees and checks that each one is
+// an existing user who can access the list.
+func (s service) checkAssignees(listID uint64, assigneeIDs []uint64) ([]uint64, error) {
+	if assigneeIDs == nil {
+		return nil, nil
+	}
+
+	unique := make([]uint64, 0, len(assigneeIDs))
+	seen := make(map[uint64]bool, len(assigneeIDs))
+	for _, assigneeID := range assigneeIDs {
+		if seen[assigneeID] {
+			continue
+		}
+		seen[assigneeID] = true
+
+		if err := s.checkIfUserExists(assigneeID); err != nil {
+			return nil, err
+		}
+
+		role, err := s.authorizationService.GetListRole(listID, assigneeID)
+		if err != nil {
+			return nil, err
+		}
+
+		if role == models.ListRoleNone {
+			return nil, apperrors.NewObjectInInvalidStateError(
+				fmt.Sprintf("user %d cannot access list %d", assigneeID, listID),
+			)
+		}
+		unique = append(unique, assigneeID)
+	}
+
+	return unique, nil
+}
+
+// keepTargetListPeople sets on the items their assignees and watchers who can
+// access the target list.
+func (s service) keepTargetListPeople(items []models.Item, targetListID uint64) error {
+	if err := s.attachPeople(items); err != nil {
+		return err
+	}
+
+	canAccess := map[uint64]bool{}
+	keep := func(userIDs []uint64) ([]uint64, error) {
+		kept := []uint64{}
+		for _, userID := range userIDs {
+			allowed, ok := canAccess[userID]
+			if !ok {
+				role, err := s.authorizationService.GetListRole(targetListID, userID)
+				if err != nil {
+					return nil, err
+				}
+				allowed = role != models.ListRoleNone
+				canAccess[userID] = allowed
+			}
+
+			if allowed {
+				kept = append(kept, userID)
+			}
+		}
+		return kept, nil
+	}
+
+	for i := range items {
+		var err error
+		if items[i].AssigneeIDs, err = keep(items[i].AssigneeIDs); err != nil {
+			return err
+		}
+
+		if items[i].WatcherIDs, err = keep(items[i].WatcherIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attachUserItemDetails fills what is shown of the items of a user: their
+// sub-item progress, tags, custom fields, assignees and watchers, and whether
+// they are overdue.
+func (s service) attachUserItemDetails(userItems []models.UserItem, userID uint64, now time.Time) error {
+	items := make([]models.Item, len(userItems))
+	for i, item := range userItems {
+		items[i] = item.Item
+	}
+
+	if err := s.attachSubItems(items, false); err != nil {
+		return err
+	}
+
+	if err := s.attachTags(items, userID); err != nil {
+		return err
+	}
+
+	if err := s.attachFieldValues(items); err != nil {
+		return err
+	}
+
+	if err := s.attachPeople(items); err != nil {
+		return err
+	}
+
+	for i := range userItems {
+		item := &userItems[i]
+		item.Item = items[i]
+		item.Overdue = item.CompletedAt == nil && item.DueAt != nil && item.DueAt.Before(now)
+	}
+
+	return nil
+}
+
+// prepareStatus validates the status of the item against the workflow of its
+// list and makes the completion of the item follow it. New items start in
+// the first status when none is informed, and items only move to the
+// statuses allowed by their current status.
+func (s service) prepareStatus(item *models.Item, stored *models.Item, userID uint64) error {
+	statuses, err := s.getListStatuses(item.ListID)
+	if err != nil {
+		return err
+	}
+
+	if item.StatusID == nil {
+		if stored != nil || len(statuses) == 0 {
+			return nil
+		}
+		item.StatusID = &statuses[0].ID
+	}
+
+	byID := make(map[uint64]*models.ListStatus, len(statuses))
+	for i := range statuses {
+		byID[statuses[i].ID] = &statuses[i]
+	}
+
+	target, ok := byID[*item.StatusID]
+	if !ok {
+		return apperrors.NewNotFoundError("status", *item.StatusID)
+	}
+
+	if stored != nil && stored.StatusID != nil {
+		if *stored.StatusID == target.ID {
+			return nil
+		}
+
+		current, ok := byID[*stored.StatusID]
+		if ok && !current.Allows(target.ID) {
+			return apperrors.NewObjectInInvalidStateError(
+				fmt.Sprintf("item cannot move from '%s' to '%s'", current.Name, target.Name),
+			)
+		}
+	}
+
+	if !target.Done {
+		item.CompletedAt = nil
+		item.CompletedBy = nil
+	} else if item.CompletedAt == nil {
+		now := s.clock.Now().UTC()
+		item.CompletedAt = &now
+		if userID != 0 {
+			item.CompletedBy = &userID
+		}
+	}
+
+	return nil
+}
+
+func (s service) checkNoWorkflow(listID uint64) error {
+	statuses, err := s.getListStatuses(listID)
+	if err != nil {
+		return err
+	}
+
+	if len(statuses) > 0 {
+		return apperrors.NewObjectInInvalidStateError("list has a workflow, change the status of the item instead")
+	}
+
+	return nil
+}
+
+func (s service) getListStatuses(listID uint64) ([]models.ListStatus, error) {
+	statuses, err := s.workflowRepository.GetListStatuses(listID)
+	if err != nil {
+		log.Printf("Error getting list statuses: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting list statuses")
+	}
+
+	return *statuses, nil
+}
+
+// prepareRecurrence validates the recurrence rule of the item and stores it
+// in its canonical form. An empty rule makes the item non recurring.
+func (s service) prepareRecurrence(item *models.Item) error {
+	if item.Recurrence == nil {
+		return nil
+	}
+
+	if *item.Recurrence == "" {
+		item.Recurrence = nil
+		return nil
+	}
+
+	rule, err := rrule.Parse(*item.Recurrence)
+	if err != nil {
+		return apperrors.NewObjectInInvalidStateError(err.Error())
+	}
+
+	if item.DueAt == nil {
+		return apperrors.NewObjectInInvalidStateError("recurrence requires a due date")
+	}
+
+	recurrence := rule.String()
+	item.Recurrence = &recurrence
+	return nil
+}
+
+// completeOccurrence records the completion of the current occurrence of a
+// recurring item and moves the item to the next occurrence, open and, in
+// lists with a workflow, back in the first open status. The item stays
+// completed when the series has ended.
+func (s service) completeOccurrence(item *models.Item, completedAt time.Time, completedBy *uint64) error {
+	rule, err := rrule.Parse(*item.Recurrence)
+	if err != nil {
+		return apperrors.NewObjectInInvalidStateError(err.Error())
+	}
+
+	count, err := s.repository.CountOccurrences(item.ID)
+	if err != nil {
+		log.Printf("Error counting item occurrences: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error counting item occurrences")
+	}
+
+	item.Occurrence = &models.ItemOccurrence{
+		ItemID:      item.ID,
+		DueAt:       *item.DueAt,
+		CompletedAt: completedAt,
+		CompletedBy: completedBy,
+	}
+	item.CompletedAt = &completedAt
+	item.CompletedBy = completedBy
+
+	next, ok := rule.Next(item.DueAt.In(item.DueLocation()), int(count)+1)
+	if !ok {
+		return nil
+	}
+
+	if item.StatusID != nil {
+		statuses, err := s.getListStatuses(item.ListID)
+		if err != nil {
+			return err
+		}
+
+		var openStatusID *uint64
+		for i := range statuses {
+			if !statuses[i].Done {
+				openStatusID = &statuses[i].ID
+				break
+			}
+		}
+
+		if openStatusID == nil {
+			return nil
+		}
+		item.StatusID = openStatusID
+	}
+
+	nextDueAt := next.UTC()
+	item.DueAt = &nextDueAt
+	item.CompletedAt = nil
+	item.CompletedBy = nil
+	return nil
+}
+
+// prepareDueDate validates the due date settings, normalizes the due date to
+// UTC and computes the reminders to be scheduled. Reminders that would fire
+// in the past are skipped.
+func (s service) prepareDueDate(item *models.Item) error {
+	if item.DueTimezone != nil {
+		if _, err := time.LoadLocation(*item.DueTimezone); err != nil || *item.DueTimezone == "" {
+			return apperrors.NewObjectInInvalidStateError(fmt.Sprintf("invalid timezone '%s'", *item.DueTimezone))
+		}
+	}
+
+	for _, offset := range item.ReminderOffsets {
+		if offset < 0 {
+			return apperrors.NewObjectInInvalidStateError("reminder offsets cannot be negative")
+		}
+	}
+
+	if len(item.ReminderOffsets) > 0 && item.DueAt == nil {
+		return apperrors.NewObjectInInvalidStateError("reminders require a due date")
+	}
+
+	item.Reminders = []models.ItemReminder{}
+	if item.DueAt == nil {
+		return nil
+	}
+
+	dueAt := item.DueAt.UTC()
+	item.DueAt = &dueAt
+
+	if item.CompletedAt != nil {
+		return nil
+	}
+
+	now := s.clock.Now()
+	for _, offset := range item.ReminderOffsets {
+		remindAt := dueAt.Add(-time.Duration(offset) * time.Minute)
+		if remindAt.Before(now) {
+			continue
+		}
+		item.Reminders = append(item.Reminders, models.ItemReminder{RemindAt: remindAt})
+	}
+
+	return nil
+}
+
+// prepareFieldValues validates the custom field values informed for the item
+// and merges them into the stored ones. Fields not informed keep their stored
+// values and nil values clear them. Nothing changes when no value was
+// informed.
+func (s service) prepareFieldValues(item *models.Item, stored []models.ItemFieldValue) error {
+	if item.Fields == nil {
+		return nil
+	}
+
+	fields, err := s.getListFields(item.ListID)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[uint64]models.ItemFieldValue, len(stored)+len(item.Fields))
+	for _, value := range stored {
+		values[value.FieldID] = value
+	}
+
+	for fieldID, raw := range item.Fields {
+		field, ok := fields[fieldID]
+		if !ok {
+			return apperrors.NewNotFoundError("field", fieldID)
+		}
+
+		value, err := newFieldValue(field, raw)
+		if err != nil {
+			return err
+		}
+
+		if value == nil {
+			delete(values, fieldID)
+			continue
+		}
+
+		if value.UserValue != nil {
+			if err = s.checkIfUserExists(*value.UserValue); err != nil {
+				return err
+			}
+		}
+		values[fieldID] = *value
+	}
+
+	item.FieldValues = make([]models.ItemFieldValue, 0, len(values))
+	for _, value := range values {
+		item.FieldValues = append(item.FieldValues, value)
+	}
+	sort.Slice(item.FieldValues, func(i, j int) bool {
+		return item.FieldValues[i].FieldID < item.FieldValues[j].FieldID
+	})
+
+	return nil
+}
+
+// resolveFieldFilters loads the custom fields the listing is filtered or
+// sorted by and converts the filter values to the types of the fields.
+func (s service) resolveFieldFilters(filter *models.ItemFilter) error {
+	sortFieldID, sortByField := models.ParseFieldSort(filter.Sort)
+	if len(filter.Fields) == 0 && !sortByField {
+		return nil
+	}
+
+	fields, err := s.getListFields(filter.ListID)
+	if err != nil {
+		return err
+	}
+
+	if sortByField {
+		field, ok := fields[sortFieldID]
+		if !ok {
+			return apperrors.NewNotFoundError("field", sortFieldID)
+		}
+		filter.SortField = field
+	}
+
+	for i := range filter.Fields {
+		fieldFilter := &filter.Fields[i]
+		field, ok := fields[fieldFilter.FieldID]
+		if !ok {
+			return apperrors.NewNotFoundError("field", fieldFilter.FieldID)
+		}
+
+		fieldFilter.Field = field
+		fieldFilter.Value, err = parseFieldFilterValue(field, fieldFilter.Operator, fieldFilter.Raw)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s service) getListFields(listID uint64) (map[uint64]*models.CustomField, error) {
+	fields, err := s.fieldRepository.GetListFields(listID)
+	if err != nil {
+		log.Printf("Error getting list fields: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting list fields")
+	}
+
+	byID := make(map[uint64]*models.CustomField, len(*fields))
+	for i := range *fields {
+		byID[(*fields)[i].ID] = &(*fields)[i]
+	}
+	return byID, nil
+}
+
+// applyDueFilter translates the overdue and due today filters into a due date
+// range. "Today" is evaluated in the timezone informed by the client.
+func (s service) applyDueFilter(filter *models.ItemFilter) {
+	now := s.clock.Now()
+
+	switch filter.Due {
+	case models.ItemDueOverdue:
+		filter.DueBefore = &now
+		filter.Status = models.ItemStatusOpen
+	case models.ItemDueToday:
+		location := filter.Timezone
+		if location == nil {
+			location = time.UTC
+		}
+
+		local := now.In(location)
+		start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location).UTC()
+		end := start.AddDate(0, 0, 1)
+		filter.DueFrom = &start
+		filter.DueBefore = &end
+	}
+}
+
+// mergeStoredItem keeps the stored values of the optional fields not sent in
+// an update request.
+func mergeStoredItem(item *models.Item, stored *models.Item) {
+	if item.ParentID == nil {
+		item.ParentID = stored.ParentID
+	} else if *item.ParentID == 0 {
+		item.ParentID = nil
+	}
+
+	if item.StatusID == nil {
+		item.StatusID = stored.StatusID
+	}
+
+	if item.Description == nil {
+		item.Description = stored.Description
+	}
+
+	if item.DueAt == nil {
+		item.DueAt = stored.DueAt
+	}
+
+	if item.DueTimezone == nil {
+		item.DueTimezone = stored.DueTimezone
+	}
+
+	if item.ReminderOffsets == nil {
+		item.ReminderOffsets = stored.ReminderOffsets
+	}
+
+	if item.Recurrence == nil {
+		item.Recurrence = stored.Recurrence
+	}
+
+	if item.Priority == "" {
+		item.Priority = stored.Priority
+	}
+
+	item.CompletedAt = stored.CompletedAt
+	item.CompletedBy = stored.CompletedBy
+	item.Position = stored.Position
+}
+
+func (s service) setCompletion(item *models.Item) error {
+	err := s.repository.SetCompletion(item)
+	if err != nil {
+		log.Printf("Error updating item completion: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error updating item completion")
+	}
+
+	return nil
+}
+
+func (s service) getItemFromList(listID uint64, itemID uint64, userID uint64) (*models.Item, error) {
+	err := s.checkIfItemExistsInList(listID, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.repository.Get(itemID)
+	if err != nil {
+		log.Printf("Error getting item: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting item")
+	}
+
+	if item == nil {
+		return nil, apperrors.NewItemNotFoundInListError(itemID, listID)
+	}
+
+	return item, s.attachPeopleToItem(item)
+}
+
+// getVisibleItemFromList returns the item for users who can view its list,
+// while getItemFromList requires editing it.
+func (s service) getVisibleItemFromList(listID uint64, itemID uint64, userID uint64) (*models.Item, error) {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkIfItemIsInList(listID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.repository.Get(itemID)
+	if err != nil {
+		log.Printf("Error getting item: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting item")
+	}
+
+	if item == nil {
+		return nil, apperrors.NewItemNotFoundInListError(itemID, listID)
+	}
+
+	return item, s.attachPeopleToItem(item)
+}
+
+func (s service) checkIfItemExistsInList(listID uint64, itemID uint64, userID uint64) error {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleEditor)
+	if err != nil {
+		return err
+	}
+
+	return s.checkIfItemIsInList(listID, itemID)
+}
+
+func (s service) checkIfItemIsInList(listID uint64, itemID uint64) error {
+	isItemOnList, err := s.repository.IsItemInList(listID, itemID)
+	if err != nil {
+		return apperrors.NewInternalError("Internal error checking item in list")
+	}
+
+	if !isItemOnList {
+		return apperrors.NewItemNotFoundInListError(itemID, listID)
+	}
+
+	return nil
+}
+
+// getItemsToTransfer checks the permissions on both lists and returns the
+// items in the order they were requested.
+func (s service) getItemsToTransfer(
+	listID uint64,
+	itemIDs []uint64,
+	targetListID uint64,
+	userID uint64,
+) (*[]models.Item, error) {
+	if len(itemIDs) == 0 {
+		return nil, apperrors.NewObjectInInvalidStateError("item_ids cannot be empty")
+	}
+
+	if len(itemIDs) > constants.MaxBulkItems {
+		return nil, apperrors.NewObjectInInvalidStateError(
+			fmt.Sprintf("cannot handle more than %d items at once", constants.MaxBulkItems),
+		)
+	}
+
+	if targetListID == listID {
+		return nil, apperrors.NewObjectInInvalidStateError("target list must be different from the item list")
+	}
+
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.authorizationService.CheckListPermission(targetListID, userID, models.ListRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.repository.GetItemsInList(listID, itemIDs)
+	if err != nil {
+		log.Printf("Error getting items: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting items")
+	}
+
+	byID := make(map[uint64]models.Item, len(*stored))
+	for _, item := range *stored {
+		byID[item.ID] = item
+	}
+
+	items := make([]models.Item, 0, len(itemIDs))
+	requested := make(map[uint64]bool, len(itemIDs))
+	for _, id := range itemIDs {
+		if requested[id] {
+			continue
+		}
+		requested[id] = true
+
+		item, ok := byID[id]
+		if !ok {
+			return nil, apperrors.NewItemNotFoundInListError(id, listID)
+		}
+		items = append(items, item)
+	}
+
+	descendants, err := s.getDescendants(itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, descendant := range *descendants {
+		if !requested[descendant.ID] {
+			requested[descendant.ID] = true
+			items = append(items, descendant)
+		}
+	}
+
+	return sortParentsFirst(items), nil
+}
+
+// sortParentsFirst detaches the items whose parent is not in the slice and
+// orders the items so every parent comes before its sub-items, keeping the
+// relative order of the items of the same level.
+func sortParentsFirst(items []models.Item) *[]models.Item {
+	parents := make(map[uint64]*uint64, len(items))
+	for _, item := range items {
+		parents[item.ID] = item.ParentID
+	}
+
+	for i := range items {
+		if parentID := items[i].ParentID; parentID != nil {
+			if _, ok := parents[*parentID]; !ok {
+				items[i].ParentID = nil
+				parents[items[i].ID] = nil
+			}
+		}
+	}
+
+	levels := make(map[uint64]int, len(items))
+	for _, item := range items {
+		for parentID := item.ParentID; parentID != nil; parentID = parents[*parentID] {
+			levels[item.ID]++
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return levels[items[i].ID] < levels[items[j].ID]
+	})
+
+	return &items
+}
+
+// checkParent validates that the parent is an item of the same list, that the
+// item is not moved under its own sub-items and that the tree does not exceed
+// the maximum depth.
+func (s service) checkParent(item *models.Item) error {
+	if item.ParentID == nil {
+		return nil
+	}
+
+	height := 1
+	if item.ID != 0 {
+		descendants, err := s.getDescendants([]uint64{item.ID})
+		if err != nil {
+			return err
+		}
+		height += treeHeight(item.ID, *descendants)
+	}
+
+	depth := 0
+	for ancestorID := item.ParentID; ancestorID != nil; {
+		if *ancestorID == item.ID {
+			return apperrors.NewObjectInInvalidStateError("item cannot be a sub-item of itself or of its sub-items")
+		}
+
+		ancestor, err := s.repository.Get(*ancestorID)
+		if err != nil {
+			log.Printf("Error getting parent item: %s\n", err.Error())
+			return apperrors.NewInternalError("Internal error getting parent item")
+		}
+
+		if ancestor == nil || ancestor.ListID != item.ListID {
+			return apperrors.NewItemNotFoundInListError(*ancestorID, item.ListID)
+		}
+
+		depth++
+		if depth+height > constants.MaxItemDepth {
+			return apperrors.NewObjectInInvalidStateError(
+				fmt.Sprintf("items cannot be nested more than %d levels deep", constants.MaxItemDepth),
+			)
+		}
+		ancestorID = ancestor.ParentID
+	}
+
+	return nil
+}
+
+// attachSubItems fills the progress of the items that have sub-items and, for
+// tree listings, nests the sub-items under their parents.
+func (s service) attachSubItems(items []models.Item, tree bool) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	descendants, err := s.getDescendants(ids)
+	if err != nil {
+		return err
+	}
+
+	children := make(map[uint64][]models.Item)
+	for _, descendant := range *descendants {
+		children[*descendant.ParentID] = append(children[*descendant.ParentID], descendant)
+	}
+
+	for i := range items {
+		fillSubItems(&items[i], children, tree, map[uint64]bool{}, 1)
+	}
+
+	return nil
+}
+
+// attachTags fills the tags of the items and of their nested sub-items that
+// the user can see.
+func (s service) attachTags(items []models.Item, userID uint64) error {
+	pending, ids := flattenItems(items)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	tags, err := s.repository.GetTags(ids, userID)
+	if err != nil {
+		log.Printf("Error getting item tags: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error getting item tags")
+	}
+
+	for _, item := range pending {
+		item.Tags = tags[item.ID]
+	}
+
+	return nil
+}
+
+// attachFieldValues fills the custom field values of the items and of their
+// nested sub-items.
+func (s service) attachFieldValues(items []models.Item) error {
+	pending, ids := flattenItems(items)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	values, err := s.repository.GetFieldValues(ids)
+	if err != nil {
+		log.Printf("Error getting item field values: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error getting item field values")
+	}
+
+	for _, item := range pending {
+		item.FieldValues = values[item.ID]
+	}
+
+	return nil
+}
+
+// attachPeople fills the assignees and watchers of the items and of their
+// nested sub-items.
+func (s service) attachPeople(items []models.Item) error {
+	pending, ids := flattenItems(items)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	assignees, err := s.repository.GetAssignees(ids)
+	if err != nil {
+		log.Printf("Error getting item assignees: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error getting item assignees")
+	}
+
+	watchers, err := s.repository.GetWatchers(ids)
+	if err != nil {
+		log.Printf("Error getting item watchers: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error getting item watchers")
+	}
+
+	for _, item := range pending {
+		item.AssigneeIDs = assignees[item.ID]
+		item.WatcherIDs = watchers[item.ID]
+	}
+
+	return nil
+}
+
+func (s service) attachPeopleToItem(item *models.Item) error {
+	items := []models.Item{*item}
+	if err := s.attachPeople(items); err != nil {
+		return err
+	}
+
+	item.AssigneeIDs, item.WatcherIDs = items[0].AssigneeIDs, items[0].WatcherIDs
+	return nil
+}
+
+// flattenItems returns the items and their nested sub-items, and their ids.
+func flattenItems(items []models.Item) ([]*models.Item, []uint64) {
+	var pending []*models.Item
+	var collect func(items []models.Item)
+	collect = func(items []models.Item) {
+		for i := range items {
+			pending = append(pending, &items[i])
+			collect(items[i].Children)
+		}
+	}
+	collect(items)
+
+	ids := make([]uint64, len(pending))
+	for i, item := range pending {
+		ids[i] = item.ID
+	}
+	return pending, ids
+}
+
+func (s service) getDescendants(ids []uint64) (*[]models.Item, error) {
+	descendants, err := s.repository.GetDescendants(ids)
+	if err != nil {
+		log.Printf("Error getting sub-items: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting sub-items")
+	}
+
+	return descendants, nil
+}
+
+// fillSubItems rolls up the completion of the sub-items of the item, at every
+// level down to constants.MaxItemDepth, and returns it. The items already
+// visited are skipped, so a cycle left by concurrent moves is not followed.
+func fillSubItems(
+	item *models.Item,
+	children map[uint64][]models.Item,
+	tree bool,
+	visited map[uint64]bool,
+	depth int,
+) models.ItemProgress {
+	progress := models.ItemProgress{}
+	visited[item.ID] = true
+	if depth >= constants.MaxItemDepth {
+		return progress
+	}
+
+	subItems := []models.Item{}
+	for _, child := range children[item.ID] {
+		if !visited[child.ID] {
+			visited[child.ID] = true
+			subItems = append(subItems, child)
+		}
+	}
+
+	if len(subItems) == 0 {
+		return progress
+	}
+
+	for i := range subItems {
+		subProgress := fillSubItems(&subItems[i], children, tree, visited, depth+1)
+		progress.Total += subProgress.Total + 1
+		progress.Done += subProgress.Done
+		if subItems[i].CompletedAt != nil {
+			progress.Done++
+		}
+	}
+
+	item.Progress = &progress
+	if tree {
+		item.Children = subItems
+	}
+
+	return progress
+}
+
+// treeHeight returns how many levels of sub-items the item has.
+func treeHeight(itemID uint64, descendants []models.Item) int {
+	levels := map[uint64]int{itemID: 0}
+	height := 0
+	for _, descendant := range descendants {
+		level := levels[*descendant.ParentID] + 1
+		levels[descendant.ID] = level
+		if level > height {
+			height = level
+		}
+	}
+	return height
+}
+
+func (s service) checkIfUserExists(userID uint64) error {
+	exists, err := s.userRepository.Exists(userID)
+	if err != nil {
+		return apperrors.NewInternalError("Internal error checking if user exists")
+	}
+
+	if !exists {
+		return apperrors.NewNotFoundError("user_id", userID)
 	}
 
 	return nil
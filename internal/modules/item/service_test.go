@@ -0,0 +1,420 @@
+package item_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/item"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/clock"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository keeps the items, assignees and watchers in memory. It
+// implements only what the tested service methods use.
+type fakeRepository struct {
+	item.Repository
+	items     map[uint64]*models.Item
+	assignees map[uint64][]uint64
+	watchers  map[uint64][]uint64
+	assigned  []models.UserItem
+	moved     []models.Item
+}
+
+func (r *fakeRepository) Get(id uint64) (*models.Item, error) {
+	if stored, ok := r.items[id]; ok {
+		found := *stored
+		return &found, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) IsItemInList(listID uint64, itemID uint64) (bool, error) {
+	stored, ok := r.items[itemID]
+	return ok && stored.ListID == listID, nil
+}
+
+func (r *fakeRepository) GetItemsInList(listID uint64, ids []uint64) (*[]models.Item, error) {
+	items := []models.Item{}
+	for _, id := range ids {
+		if stored, ok := r.items[id]; ok && stored.ListID == listID {
+			items = append(items, *stored)
+		}
+	}
+	return &items, nil
+}
+
+// FindItems returns the root items of the list.
+func (r *fakeRepository) FindItems(filter *models.ItemFilter) (*[]models.Item, int64, error) {
+	items := []models.Item{}
+	for _, id := range r.sortedIDs() {
+		if stored := r.items[id]; stored.ListID == filter.ListID && stored.ParentID == nil {
+			items = append(items, *stored)
+		}
+	}
+	return &items, int64(len(items)), nil
+}
+
+// GetDescendants returns the descendants level by level, like the database.
+func (r *fakeRepository) GetDescendants(ids []uint64) (*[]models.Item, error) {
+	descendants := []models.Item{}
+	level := ids
+	for depth := 1; depth < constants.MaxItemDepth && len(level) > 0; depth++ {
+		parents := map[uint64]bool{}
+		for _, id := range level {
+			parents[id] = true
+		}
+
+		level = nil
+		for _, id := range r.sortedIDs() {
+			stored := r.items[id]
+			if stored.ParentID != nil && parents[*stored.ParentID] {
+				descendants = append(descendants, *stored)
+				level = append(level, stored.ID)
+			}
+		}
+	}
+	return &descendants, nil
+}
+
+func (r *fakeRepository) sortedIDs() []uint64 {
+	ids := make([]uint64, 0, len(r.items))
+	for id := range r.items {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (r *fakeRepository) GetTags(ids []uint64, userID uint64) (map[uint64][]models.Tag, error) {
+	return map[uint64][]models.Tag{}, nil
+}
+
+func (r *fakeRepository) GetFieldValues(ids []uint64) (map[uint64][]models.ItemFieldValue, error) {
+	return map[uint64][]models.ItemFieldValue{}, nil
+}
+
+func (r *fakeRepository) FindAssigned(filter *models.AssignedFilter) (*[]models.UserItem, int64, error) {
+	items := []models.UserItem{}
+	for _, userItem := range r.assigned {
+		if filter.Cursor == nil || userItem.ID > filter.Cursor.ID {
+			items = append(items, userItem)
+		}
+	}
+
+	if len(items) > filter.Limit+1 {
+		items = items[:filter.Limit+1]
+	}
+	return &items, int64(len(r.assigned)), nil
+}
+
+func (r *fakeRepository) GetAssignees(ids []uint64) (map[uint64][]uint64, error) {
+	return pick(r.assignees, ids), nil
+}
+
+func (r *fakeRepository) GetWatchers(ids []uint64) (map[uint64][]uint64, error) {
+	return pick(r.watchers, ids), nil
+}
+
+func pick(people map[uint64][]uint64, ids []uint64) map[uint64][]uint64 {
+	picked := map[uint64][]uint64{}
+	for _, id := range ids {
+		if userIDs, ok := people[id]; ok {
+			picked[id] = userIDs
+		}
+	}
+	return picked
+}
+
+func (r *fakeRepository) Assign(itemID uint64, userID uint64) error {
+	r.assignees[itemID] = add(r.assignees[itemID], userID)
+	return nil
+}
+
+func (r *fakeRepository) Unassign(itemID uint64, userID uint64) error {
+	r.assignees[itemID] = remove(r.assignees[itemID], userID)
+	return nil
+}
+
+func (r *fakeRepository) Watch(itemID uint64, userID uint64) error {
+	r.watchers[itemID] = add(r.watchers[itemID], userID)
+	return nil
+}
+
+func (r *fakeRepository) Unwatch(itemID uint64, userID uint64) error {
+	r.watchers[itemID] = remove(r.watchers[itemID], userID)
+	return nil
+}
+
+func add(userIDs []uint64, userID uint64) []uint64 {
+	for _, id := range userIDs {
+		if id == userID {
+			return userIDs
+		}
+	}
+	return append(userIDs, userID)
+}
+
+func remove(userIDs []uint64, userID uint64) []uint64 {
+	kept := []uint64{}
+	for _, id := range userIDs {
+		if id != userID {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+func (r *fakeRepository) Transfer(items *[]models.Item, targetListID uint64) error {
+	r.moved = append([]models.Item{}, *items...)
+	return nil
+}
+
+func (r *fakeRepository) Copy(items *[]models.Item, targetListID uint64) error {
+	r.moved = append([]models.Item{}, *items...)
+	return nil
+}
+
+// newServiceToTest returns a service over two lists. John (1) owns the
+// groceries list (10), where Mary (2) is an editor and Paul (3) a viewer. Mary
+// owns the work list (20), where John is an editor. Ann (4) sees no list.
+func newServiceToTest() (item.Service, *fakeRepository) {
+	parentID := uint64(1)
+	repository := &fakeRepository{
+		items: map[uint64]*models.Item{
+			1: {ID: 1, ListID: 10, Title: "Milk"},
+			2: {ID: 2, ListID: 10, Title: "Skimmed", ParentID: &parentID},
+			3: {ID: 3, ListID: 20, Title: "Report"},
+		},
+		assignees: map[uint64][]uint64{1: {1, 2}, 2: {3}},
+		watchers:  map[uint64][]uint64{1: {3}, 2: {1}},
+	}
+	authorizationService := testutil.AuthorizationService{Roles: map[uint64]map[uint64]models.ListRole{
+		10: {1: models.ListRoleOwner, 2: models.ListRoleEditor, 3: models.ListRoleViewer},
+		20: {2: models.ListRoleOwner, 1: models.ListRoleEditor},
+	}}
+	users := testutil.NewUserRepository("john", "mary", "paul", "ann")
+
+	service := item.NewService(repository, authorizationService, users, nil, nil, clock.New())
+	return service, repository
+}
+
+func assertErrorType[T error](t *testing.T, err error) {
+	_, ok := err.(T)
+	assert.True(t, ok, "expected a %T, got %v", *new(T), err)
+}
+
+func TestAssign(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	stored, err := service.Assign(10, 1, 3, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, stored.AssigneeIDs)
+	assert.Equal(t, []uint64{3}, stored.WatcherIDs)
+
+	// Assignees must be users who can access the list.
+	_, err = service.Assign(10, 1, 4, 2)
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+
+	_, err = service.Assign(10, 1, 99, 2)
+	assertErrorType[*apperrors.NotFoundError](t, err)
+
+	// Viewers cannot assign, and the item must be in the list.
+	_, err = service.Assign(10, 1, 3, 3)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	_, err = service.Assign(10, 3, 2, 2)
+	assertErrorType[*apperrors.NotFoundError](t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, repository.assignees[1])
+}
+
+func TestUnassign(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	stored, err := service.Unassign(10, 1, 2, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1}, stored.AssigneeIDs)
+
+	_, err = service.Unassign(10, 1, 1, 3)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+	assert.Equal(t, []uint64{1}, repository.assignees[1])
+}
+
+func TestWatchAndUnwatch(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	// Viewers can watch the items.
+	stored, err := service.Watch(10, 2, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1, 3}, stored.WatcherIDs)
+
+	stored, err = service.Unwatch(10, 2, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{3}, stored.WatcherIDs)
+
+	_, err = service.Watch(10, 2, 4)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	_, err = service.Watch(20, 2, 1)
+	assertErrorType[*apperrors.NotFoundError](t, err)
+	assert.Equal(t, []uint64{3}, repository.watchers[2])
+}
+
+func TestGetAssigned(t *testing.T) {
+	service, repository := newServiceToTest()
+	repository.assigned = []models.UserItem{
+		{Item: *repository.items[1], ListTitle: "Groceries"},
+		{Item: *repository.items[3], ListTitle: "Work"},
+	}
+	filter := models.AssignedFilter{UserID: 1, Sort: "id", Limit: 1}
+
+	page, err := service.GetAssigned(&filter)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), page.Total)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, []uint64{1, 2}, page.Items[0].AssigneeIDs)
+	assert.Equal(t, &models.ItemProgress{Total: 1}, page.Items[0].Progress)
+	assert.NotNil(t, page.NextCursor)
+
+	cursor, err := models.DecodePageCursor(*page.NextCursor)
+	assert.Nil(t, err)
+	filter.Cursor = cursor
+	page, err = service.GetAssigned(&filter)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), page.Items[0].ID)
+	assert.Nil(t, page.NextCursor)
+
+	// The cursor belongs to the sort it was created with.
+	filter.Sort = "title"
+	_, err = service.GetAssigned(&filter)
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+}
+
+func TestTransferKeepsOnlyPeopleOfTheTargetList(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	_, err := service.Transfer(10, []uint64{1}, 20, 1)
+	assert.Nil(t, err)
+
+	// Mary and John can access the work list, Paul cannot.
+	assert.Len(t, repository.moved, 2)
+	assert.Equal(t, []uint64{1, 2}, repository.moved[0].AssigneeIDs)
+	assert.Equal(t, []uint64{}, repository.moved[0].WatcherIDs)
+	assert.Equal(t, []uint64{}, repository.moved[1].AssigneeIDs)
+	assert.Equal(t, []uint64{1}, repository.moved[1].WatcherIDs)
+}
+
+func TestCopyKeepsOnlyAssigneesOfTheTargetList(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	_, err := service.Copy(10, []uint64{2}, 20, 1)
+	assert.Nil(t, err)
+	assert.Len(t, repository.moved, 1)
+	assert.Equal(t, []uint64{}, repository.moved[0].AssigneeIDs)
+}
+
+func TestCopyCreatesPendingItemsWithTheirSubItems(t *testing.T) {
+	service, repository := newServiceToTest()
+	completedAt, completedBy := clock.New().Now(), uint64(2)
+	repository.items[1].CompletedAt = &completedAt
+	repository.items[1].CompletedBy = &completedBy
+	repository.items[1].CreatedAt = completedAt.Add(-time.Hour)
+
+	_, err := service.Copy(10, []uint64{2, 1}, 20, 1)
+	assert.Nil(t, err)
+
+	// The parent is copied before its sub-item, and the copies are new items.
+	assert.Len(t, repository.moved, 2)
+	assert.Equal(t, uint64(1), repository.moved[0].ID)
+	assert.Equal(t, uint64(2), repository.moved[1].ID)
+	assert.Nil(t, repository.moved[0].CompletedAt)
+	assert.Nil(t, repository.moved[0].CompletedBy)
+	assert.True(t, repository.moved[0].CreatedAt.IsZero())
+	assert.NotNil(t, repository.items[1].CompletedAt)
+}
+
+func TestCopyRequiresEditingBothLists(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	// Paul only views the groceries list and Ann sees neither list.
+	_, err := service.Copy(10, []uint64{1}, 20, 3)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	_, err = service.Copy(10, []uint64{1}, 20, 4)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	// Mary edits the groceries list and owns the work list.
+	_, err = service.Copy(10, []uint64{1}, 20, 2)
+	assert.Nil(t, err)
+	assert.Len(t, repository.moved, 2)
+}
+
+func TestCopyRejectsInvalidRequests(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	_, err := service.Copy(10, []uint64{}, 20, 1)
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+
+	_, err = service.Copy(10, []uint64{1}, 10, 1)
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+
+	_, err = service.Copy(10, []uint64{3}, 20, 1)
+	assertErrorType[*apperrors.NotFoundError](t, err)
+	assert.Empty(t, repository.moved)
+}
+
+func TestGetItemsFromListAsTree(t *testing.T) {
+	service, repository := newServiceToTest()
+	parentID, completedAt := uint64(2), clock.New().Now()
+	repository.items[4] = &models.Item{ID: 4, ListID: 10, Title: "Organic", ParentID: &parentID, CompletedAt: &completedAt}
+
+	page, err := service.GetItemsFromList(&models.ItemFilter{ListID: 10, Sort: "id", Limit: 10, Tree: true}, 1)
+
+	assert.Nil(t, err)
+	assert.Len(t, page.Items, 1)
+	milk := page.Items[0]
+	assert.Equal(t, &models.ItemProgress{Total: 2, Done: 1}, milk.Progress)
+	assert.Len(t, milk.Children, 1)
+	assert.Equal(t, "Skimmed", milk.Children[0].Title)
+	assert.Equal(t, &models.ItemProgress{Total: 1, Done: 1}, milk.Children[0].Progress)
+	assert.Equal(t, "Organic", milk.Children[0].Children[0].Title)
+}
+
+func TestGetItemsFromListAsTreeStopsAtTheMaximumDepth(t *testing.T) {
+	service, repository := newServiceToTest()
+	for id := uint64(4); id < 4+constants.MaxItemDepth; id++ {
+		parentID := id - 1
+		if id == 4 {
+			parentID = 2
+		}
+		repository.items[id] = &models.Item{ID: id, ListID: 10, ParentID: &parentID}
+	}
+
+	page, err := service.GetItemsFromList(&models.ItemFilter{ListID: 10, Sort: "id", Limit: 10, Tree: true}, 1)
+
+	assert.Nil(t, err)
+	depth := 0
+	for level := page.Items; len(level) > 0; level = level[0].Children {
+		depth++
+	}
+	assert.Equal(t, constants.MaxItemDepth, depth)
+}
+
+func TestSubItemCyclesAreNotFollowed(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	// Concurrent moves left two items as the parent of each other.
+	firstID, secondID := uint64(5), uint64(6)
+	repository.items[5] = &models.Item{ID: 5, ListID: 10, Title: "First", ParentID: &secondID}
+	repository.items[6] = &models.Item{ID: 6, ListID: 10, Title: "Second", ParentID: &firstID}
+	repository.assigned = []models.UserItem{{Item: *repository.items[5], ListTitle: "Groceries"}}
+
+	page, err := service.GetAssigned(&models.AssignedFilter{UserID: 1, Sort: "id", Limit: 10})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &models.ItemProgress{Total: 1}, page.Items[0].Progress)
+}
@@ -2,6 +2,7 @@ package list
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
@@ -15,6 +16,9 @@ type (
 	Handler interface {
 		Save(c *gin.Context)
 		Get(c *gin.Context)
+		GetAll(c *gin.Context)
+		Update(c *gin.Context)
+		Patch(c *gin.Context)
 		Delete(c *gin.Context)
 	}
 
@@ -39,7 +43,7 @@ func (h handler) Save(c *gin.Context) {
 	list := models.NewListFromDTO(listDTO)
 	err = h.service.Save(list, userID)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, models.NewHttpError(err))
+		apperrors.HandleServiceError(c, err)
 		return
 	}
 
@@ -53,7 +57,9 @@ func (h handler) Get(c *gin.Context) {
 		return
 	}
 
-	list, err := h.service.Get(id)
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	list, err := h.service.Get(id, userID)
 	if err != nil {
 		apperrors.HandleServiceError(c, err)
 		return
@@ -62,6 +68,58 @@ func (h handler) Get(c *gin.Context) {
 	c.IndentedJSON(http.StatusOK, models.NewListDTO(list))
 }
 
+func (h handler) GetAll(c *gin.Context) {
+	filter, err := getListFilterFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	filter.UserID = c.GetUint64(constants.CtxUserKey)
+
+	page, err := h.service.GetAll(filter)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewListsDTO(page))
+}
+
+func (h handler) Update(c *gin.Context) {
+	h.update(c, h.service.Update)
+}
+
+func (h handler) Patch(c *gin.Context) {
+	h.update(c, h.service.Patch)
+}
+
+func (h handler) update(c *gin.Context, update func(list *models.List, userID uint64) (*models.List, error)) {
+	id, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	listDTO, err := getListFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	list := models.NewListFromDTO(listDTO)
+	list.ID = id
+	updated, err := update(list, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewListDTO(updated))
+}
+
 func (h handler) Delete(c *gin.Context) {
 	id, err := utils.GetIDFromRequest(c, "list_id")
 	if err != nil {
@@ -69,7 +127,15 @@ func (h handler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.service.Delete(id)
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	cascade, err := utils.GetBoolFromRequest(c, "cascade")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	err = h.service.Delete(id, userID, cascade)
 	if err != nil {
 		apperrors.HandleServiceError(c, err)
 		return
@@ -87,3 +153,34 @@ func getListFromRequest(c *gin.Context) (*models.ListDTO, error) {
 
 	return &list, nil
 }
+
+func getListFilterFromRequest(c *gin.Context) (*models.ListFilter, error) {
+	filter := models.ListFilter{
+		Query: c.Query("q"),
+		Sort:  c.DefaultQuery("sort", "id"),
+		Order: models.SortOrder(c.DefaultQuery("order", string(models.SortAsc))),
+	}
+
+	if !models.ListSortFields[filter.Sort] {
+		return nil, fmt.Errorf("invalid sort field '%s'", filter.Sort)
+	}
+
+	if filter.Order != models.SortAsc && filter.Order != models.SortDesc {
+		return nil, errors.New("order must be asc or desc")
+	}
+
+	limit, err := utils.GetPageSizeFromRequest(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		filter.Cursor, err = models.DecodePageCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &filter, nil
+}
@@ -2,8 +2,10 @@ package list
 
 import (
 	"errors"
+	"time"
 
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
 	"gorm.io/gorm"
 )
 
@@ -11,8 +13,11 @@ type (
 	Repository interface {
 		Save(list *models.List) error
 		Get(id uint64) (*models.List, error)
-		Delete(id uint64) error
+		FindAccessibleLists(filter *models.ListFilter) (*[]models.List, int64, error)
+		Update(list *models.List) error
+		Delete(id uint64, deletedBy uint64, cascade bool) error
 		CountItemsOnList(id uint64) (int64, error)
+		GetProgress(ids []uint64) (map[uint64]models.ListProgress, error)
 		Exists(id uint64) (bool, error)
 	}
 
@@ -40,8 +45,50 @@ func (r repository) Get(id uint64) (*models.List, error) {
 	return &list, err
 }
 
-func (r repository) Delete(id uint64) error {
-	return r.db.Delete(&models.List{}, id).Error
+// FindAccessibleLists returns one page of the lists owned by the user or
+// shared with the user as a member, plus the total of matching lists.
+func (r repository) FindAccessibleLists(filter *models.ListFilter) (*[]models.List, int64, error) {
+	var total int64
+	err := r.applyListFilter(r.db.Model(&models.List{}), filter).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := utils.ApplyKeysetPagination(
+		r.applyListFilter(r.db, filter), filter.Sort, filter.Order, filter.Cursor, filter.Limit,
+	)
+
+	var lists []models.List
+	err = query.Find(&lists).Error
+	return &lists, total, err
+}
+
+// Update writes the editable list fields, including null metadata.
+func (r repository) Update(list *models.List) error {
+	return r.db.Model(list).Select("title", "description", "color", "icon").Updates(list).Error
+}
+
+// Delete moves the list to the trash. With cascade, the items still in the
+// list go to the trash with it and are restored together with the list.
+func (r repository) Delete(id uint64, deletedBy uint64, cascade bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
+		if cascade {
+			err := tx.Model(&models.Item{}).
+				Where("list_id = ?", id).
+				Updates(map[string]interface{}{"deleted_at": now, "deleted_by": deletedBy, "deleted_with_list": true}).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.List{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": now, "deleted_by": deletedBy}).
+			Error
+	})
 }
 
 func (r repository) CountItemsOnList(id uint64) (int64, error) {
@@ -50,6 +97,28 @@ func (r repository) CountItemsOnList(id uint64) (int64, error) {
 	return count, err
 }
 
+// GetProgress counts the done and total items of each list in a single query.
+func (r repository) GetProgress(ids []uint64) (map[uint64]models.ListProgress, error) {
+	var rows []struct {
+		ListID uint64
+		Done   int64
+		Total  int64
+	}
+
+	err := r.db.Model(&models.Item{}).
+		Select("list_id, count(completed_at) as done, count(*) as total").
+		Where("list_id IN ?", ids).
+		Group("list_id").
+		Find(&rows).
+		Error
+
+	progress := make(map[uint64]models.ListProgress, len(rows))
+	for _, row := range rows {
+		progress[row.ListID] = models.ListProgress{Done: row.Done, Total: row.Total}
+	}
+	return progress, err
+}
+
 func (r repository) Exists(id uint64) (bool, error) {
 	var exists bool
 	err := r.db.Model(&models.List{}).
@@ -59,3 +128,14 @@ func (r repository) Exists(id uint64) (bool, error) {
 		Error
 	return exists, err
 }
+
+func (r repository) applyListFilter(db *gorm.DB, filter *models.ListFilter) *gorm.DB {
+	members := r.db.Model(&models.ListMember{}).Select("list_id").Where("user_id = ?", filter.UserID)
+	query := db.Where(r.db.Where("user_id = ?", filter.UserID).Or("id IN (?)", members))
+
+	if filter.Query != "" {
+		query = query.Where("title LIKE ?", "%"+utils.EscapeLike(filter.Query)+"%")
+	}
+
+	return query
+}
@@ -0,0 +1,82 @@
+package list_test
+
+import (
+	"regexp"
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/list"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type ListRepositoryTestSuite struct {
+	suite.Suite
+	t          *testing.T
+	repository list.Repository
+	sqlMock    sqlmock.Sqlmock
+}
+
+func (s *ListRepositoryTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	assert.Nil(s.T(), err)
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+	})
+	assert.Nil(s.T(), err)
+
+	s.repository = list.NewRepository(gdb)
+	s.t = s.T()
+	s.sqlMock = mock
+}
+
+type ListRepositoryFindTestSuite struct {
+	ListRepositoryTestSuite
+}
+
+func TestListRepositoryFindTestSuite(t *testing.T) {
+	suite.Run(t, new(ListRepositoryFindTestSuite))
+}
+
+func (s ListRepositoryFindTestSuite) TestFindAccessibleListsSuccess() {
+	cursor := models.PageCursor{Sort: "title", Value: "groceries", ID: 4}
+	filter := models.ListFilter{UserID: 2, Query: "gro", Sort: "title", Order: models.SortAsc, Limit: 10, Cursor: &cursor}
+	accessible := "(user_id = ? OR id IN (SELECT `list_id` FROM `list_member` WHERE user_id = ?)) AND title LIKE ?"
+
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `list` WHERE "+accessible)).
+		WithArgs(2, 2, "%gro%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	rows := sqlmock.NewRows([]string{"id", "title", "user_id"}).AddRow(7, "groceries 2", 2)
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT * FROM `list` WHERE "+accessible+
+			" AND (title > ? OR (title = ? AND id > ?)) AND `list`.`deleted_at` IS NULL ORDER BY title ASC,id ASC LIMIT 11",
+	)).WithArgs(2, 2, "%gro%", "groceries", "groceries", 4).WillReturnRows(rows)
+
+	lists, total, err := s.repository.FindAccessibleLists(&filter)
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, int64(2), total)
+	assert.Len(s.t, *lists, 1)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s ListRepositoryFindTestSuite) TestGetProgressSuccess() {
+	rows := sqlmock.NewRows([]string{"list_id", "done", "total"}).AddRow(1, 1, 3).AddRow(2, 5, 5)
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT list_id, count(completed_at) as done, count(*) as total FROM `item` WHERE list_id IN (?,?) AND `item`.`deleted_at` IS NULL GROUP BY `list_id`",
+	)).WithArgs(1, 2).WillReturnRows(rows)
+
+	progress, err := s.repository.GetProgress([]uint64{1, 2})
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, map[uint64]models.ListProgress{1: {Done: 1, Total: 3}, 2: {Done: 5, Total: 5}}, progress)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
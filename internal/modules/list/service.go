@@ -2,25 +2,33 @@ package list
 
 import (
 	"log"
+	"strings"
+	"unicode/utf8"
 
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/authorization"
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
 )
 
 type (
 	Service interface {
 		Save(list *models.List, userID uint64) error
-		Get(id uint64) (*models.List, error)
-		Delete(id uint64) error
+		Get(id uint64, userID uint64) (*models.List, error)
+		GetAll(filter *models.ListFilter) (*models.ListPage, error)
+		Update(list *models.List, userID uint64) (*models.List, error)
+		Patch(list *models.List, userID uint64) (*models.List, error)
+		Delete(id uint64, userID uint64, cascade bool) error
 	}
 
 	service struct {
-		repository Repository
+		repository           Repository
+		authorizationService authorization.Service
 	}
 )
 
-func NewService(repository Repository) Service {
-	return &service{repository}
+func NewService(repository Repository, authorizationService authorization.Service) Service {
+	return &service{repository, authorizationService}
 }
 
 func (s service) Save(list *models.List, userID uint64) error {
@@ -28,7 +36,12 @@ func (s service) Save(list *models.List, userID uint64) error {
 		list.Owner = &userID
 	}
 
-	err := s.repository.Save(list)
+	err := validateList(list)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.Save(list)
 	if err != nil {
 		log.Printf("Error saving list: %s\n", err.Error())
 		return apperrors.NewInternalError("Internal error saving list")
@@ -37,7 +50,17 @@ func (s service) Save(list *models.List, userID uint64) error {
 	return nil
 }
 
-func (s service) Get(id uint64) (*models.List, error) {
+func (s service) Get(id uint64, userID uint64) (*models.List, error) {
+	err := s.authorizationService.CheckListPermission(id, userID, models.ListRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.get(id)
+}
+
+// get loads the list with its progress, without checking the permissions.
+func (s service) get(id uint64) (*models.List, error) {
 	list, err := s.repository.Get(id)
 	if err != nil {
 		log.Printf("Error getting list: %s\n", err.Error())
@@ -48,21 +71,124 @@ func (s service) Get(id uint64) (*models.List, error) {
 		return nil, apperrors.NewNotFoundError("list", id)
 	}
 
+	progress, err := s.repository.GetProgress([]uint64{id})
+	if err != nil {
+		log.Printf("Error getting list progress: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting list")
+	}
+
+	list.Progress = progress[id]
 	return list, nil
 }
 
-func (s service) Delete(id uint64) error {
-	_, err := s.Get(id)
+func (s service) GetAll(filter *models.ListFilter) (*models.ListPage, error) {
+	if filter.Cursor != nil && filter.Cursor.Sort != filter.Sort {
+		return nil, apperrors.NewObjectInInvalidStateError("cursor does not match the requested sort")
+	}
+
+	lists, total, err := s.repository.FindAccessibleLists(filter)
 	if err != nil {
-		return err
+		log.Printf("Error getting lists: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting lists")
+	}
+
+	page := models.ListPage{Lists: *lists, Total: total}
+	if len(page.Lists) > filter.Limit {
+		page.Lists = page.Lists[:filter.Limit]
+		last := page.Lists[len(page.Lists)-1]
+		cursor := models.PageCursor{Sort: filter.Sort, Value: last.SortValue(filter.Sort), ID: last.ID}.Encode()
+		page.NextCursor = &cursor
+	}
+
+	if len(page.Lists) == 0 {
+		return &page, nil
+	}
+
+	ids := make([]uint64, len(page.Lists))
+	for i, list := range page.Lists {
+		ids[i] = list.ID
 	}
 
-	err = s.checkIfListIsEmpty(id)
+	progress, err := s.repository.GetProgress(ids)
+	if err != nil {
+		log.Printf("Error getting lists progress: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting lists")
+	}
+
+	for i := range page.Lists {
+		page.Lists[i].Progress = progress[page.Lists[i].ID]
+	}
+
+	return &page, nil
+}
+
+// Update replaces the title and all the metadata of the list.
+func (s service) Update(list *models.List, userID uint64) (*models.List, error) {
+	return s.update(list.ID, userID, func(stored *models.List) {
+		stored.Title = list.Title
+		stored.Description = list.Description
+		stored.Color = list.Color
+		stored.Icon = list.Icon
+	})
+}
+
+// Patch changes only the fields informed in the request. Empty strings clear
+// the metadata fields.
+func (s service) Patch(list *models.List, userID uint64) (*models.List, error) {
+	return s.update(list.ID, userID, func(stored *models.List) {
+		if list.Title != "" {
+			stored.Title = list.Title
+		}
+
+		stored.Description = patchField(stored.Description, list.Description)
+		stored.Color = patchField(stored.Color, list.Color)
+		stored.Icon = patchField(stored.Icon, list.Icon)
+	})
+}
+
+func (s service) update(id uint64, userID uint64, apply func(stored *models.List)) (*models.List, error) {
+	err := s.authorizationService.CheckListPermission(id, userID, models.ListRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	apply(stored)
+
+	err = validateList(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.Update(stored)
+	if err != nil {
+		log.Printf("Error updating list: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error updating list")
+	}
+
+	return stored, nil
+}
+
+// Delete moves the list to the trash. A list with items can only be deleted
+// with cascade, which sends its items to the trash as well.
+func (s service) Delete(id uint64, userID uint64, cascade bool) error {
+	err := s.authorizationService.CheckListPermission(id, userID, models.ListRoleOwner)
 	if err != nil {
 		return err
 	}
 
-	err = s.repository.Delete(id)
+	if !cascade {
+		err = s.checkIfListIsEmpty(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.repository.Delete(id, userID, cascade)
 	if err != nil {
 		log.Printf("Error deletting list: %s\n", err.Error())
 		return apperrors.NewInternalError("Internal error deletting list")
@@ -78,8 +204,40 @@ func (s service) checkIfListIsEmpty(listID uint64) error {
 	}
 
 	if itemsCount > 0 {
-		return apperrors.NewObjectInInvalidStateError("list is not empty")
+		return apperrors.NewObjectInInvalidStateError("list is not empty, use cascade=true to delete its items")
 	}
 
 	return nil
 }
+
+func validateList(list *models.List) error {
+	if strings.TrimSpace(list.Title) == "" {
+		return apperrors.NewObjectInInvalidStateError("title cannot be empty")
+	}
+
+	if utf8.RuneCountInString(list.Title) > 255 {
+		return apperrors.NewObjectInInvalidStateError("title cannot exceed 255 characters")
+	}
+
+	if list.Color != nil && !utils.IsHexColor(*list.Color) {
+		return apperrors.NewObjectInInvalidStateError("color must be in the #RRGGBB format")
+	}
+
+	if list.Icon != nil && utf8.RuneCountInString(*list.Icon) > 64 {
+		return apperrors.NewObjectInInvalidStateError("icon cannot exceed 64 characters")
+	}
+
+	return nil
+}
+
+func patchField(stored *string, value *string) *string {
+	if value == nil {
+		return stored
+	}
+
+	if *value == "" {
+		return nil
+	}
+
+	return value
+}
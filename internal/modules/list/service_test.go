@@ -0,0 +1,229 @@
+package list_test
+
+import (
+	"strings"
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/list"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository keeps the lists in memory. It implements only what the
+// tested service methods use.
+type fakeRepository struct {
+	list.Repository
+	lists   map[uint64]*models.List
+	items   map[uint64]int64
+	deleted []uint64
+}
+
+func (r *fakeRepository) Save(stored *models.List) error {
+	stored.ID = uint64(len(r.lists) + 1)
+	saved := *stored
+	r.lists[stored.ID] = &saved
+	return nil
+}
+
+func (r *fakeRepository) Get(id uint64) (*models.List, error) {
+	if stored, ok := r.lists[id]; ok {
+		found := *stored
+		return &found, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) Update(stored *models.List) error {
+	updated := *stored
+	r.lists[stored.ID] = &updated
+	return nil
+}
+
+func (r *fakeRepository) Delete(id uint64, deletedBy uint64, cascade bool) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func (r *fakeRepository) CountItemsOnList(id uint64) (int64, error) {
+	return r.items[id], nil
+}
+
+func (r *fakeRepository) GetProgress(ids []uint64) (map[uint64]models.ListProgress, error) {
+	progress := map[uint64]models.ListProgress{}
+	for _, id := range ids {
+		progress[id] = models.ListProgress{Total: r.items[id]}
+	}
+	return progress, nil
+}
+
+// checkCountingAuthorizationService counts the permission checks.
+type checkCountingAuthorizationService struct {
+	testutil.AuthorizationService
+	checks *int
+}
+
+func (s checkCountingAuthorizationService) CheckListPermission(listID uint64, userID uint64, required models.ListRole) error {
+	*s.checks++
+	return s.AuthorizationService.CheckListPermission(listID, userID, required)
+}
+
+// newServiceToTest returns a service over the groceries list (1), owned by
+// John (1), where Mary (2) is an admin and Paul (3) an editor, and over an
+// empty list (2) owned by John.
+func newServiceToTest() (list.Service, *fakeRepository) {
+	owner, color := uint64(1), "#00FF00"
+	repository := &fakeRepository{
+		lists: map[uint64]*models.List{
+			1: {ID: 1, Title: "Groceries", Owner: &owner, Color: &color},
+			2: {ID: 2, Title: "Empty", Owner: &owner},
+		},
+		items: map[uint64]int64{1: 3},
+	}
+	authorizationService := testutil.AuthorizationService{Roles: map[uint64]map[uint64]models.ListRole{
+		1: {1: models.ListRoleOwner, 2: models.ListRoleAdmin, 3: models.ListRoleEditor},
+		2: {1: models.ListRoleOwner},
+	}}
+
+	return list.NewService(repository, authorizationService), repository
+}
+
+func assertErrorType[T error](t *testing.T, err error) {
+	_, ok := err.(T)
+	assert.True(t, ok, "expected a %T, got %v", *new(T), err)
+}
+
+func TestSave(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	saved := models.List{Title: "Travel"}
+	err := service.Save(&saved, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), *repository.lists[saved.ID].Owner)
+
+	// Lists created without authentication have no owner.
+	shared := models.List{Title: "Shared"}
+	err = service.Save(&shared, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, repository.lists[shared.ID].Owner)
+}
+
+func TestSaveValidatesTheList(t *testing.T) {
+	service, _ := newServiceToTest()
+	color, icon := "green", strings.Repeat("a", 65)
+
+	cases := []struct {
+		name  string
+		saved models.List
+	}{
+		{"empty title", models.List{Title: "  "}},
+		{"long title", models.List{Title: strings.Repeat("a", 256)}},
+		{"invalid color", models.List{Title: "Travel", Color: &color}},
+		{"long icon", models.List{Title: "Travel", Icon: &icon}},
+	}
+
+	for _, c := range cases {
+		err := service.Save(&c.saved, 1)
+		assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+	}
+}
+
+func TestTitleLengthCountsCharacters(t *testing.T) {
+	service, _ := newServiceToTest()
+
+	// 255 characters take 510 bytes in UTF-8.
+	saved := models.List{Title: strings.Repeat("ç", 255)}
+	err := service.Save(&saved, 1)
+	assert.Nil(t, err)
+
+	saved = models.List{Title: strings.Repeat("ç", 256)}
+	err = service.Save(&saved, 1)
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+}
+
+func TestGet(t *testing.T) {
+	service, _ := newServiceToTest()
+
+	found, err := service.Get(1, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "Groceries", found.Title)
+	assert.Equal(t, int64(3), found.Progress.Total)
+
+	_, err = service.Get(1, 4)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+}
+
+func TestUpdateReplacesTheMetadata(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	updated, err := service.Update(&models.List{ID: 1, Title: "Market"}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "Market", updated.Title)
+	assert.Nil(t, updated.Color)
+	assert.Equal(t, "Market", repository.lists[1].Title)
+	assert.Equal(t, uint64(1), *repository.lists[1].Owner)
+
+	// Editors cannot change the list.
+	_, err = service.Update(&models.List{ID: 1, Title: "Mine"}, 3)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+	assert.Equal(t, "Market", repository.lists[1].Title)
+}
+
+func TestUpdateChecksThePermissionOnce(t *testing.T) {
+	_, repository := newServiceToTest()
+	checks := 0
+	service := list.NewService(repository, checkCountingAuthorizationService{
+		AuthorizationService: testutil.AuthorizationService{Roles: map[uint64]map[uint64]models.ListRole{
+			1: {2: models.ListRoleAdmin},
+			9: {2: models.ListRoleAdmin},
+		}},
+		checks: &checks,
+	})
+
+	updated, err := service.Update(&models.List{ID: 1, Title: "Market"}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), updated.Progress.Total)
+	assert.Equal(t, 1, checks)
+
+	// The list may be gone after the check.
+	_, err = service.Update(&models.List{ID: 9, Title: "Market"}, 2)
+	assertErrorType[*apperrors.NotFoundError](t, err)
+}
+
+func TestPatchChangesOnlyTheInformedFields(t *testing.T) {
+	service, repository := newServiceToTest()
+	icon, empty := "cart", ""
+
+	patched, err := service.Patch(&models.List{ID: 1, Icon: &icon}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Groceries", patched.Title)
+	assert.Equal(t, "#00FF00", *patched.Color)
+	assert.Equal(t, "cart", *patched.Icon)
+
+	// Empty strings clear the metadata.
+	patched, err = service.Patch(&models.List{ID: 1, Color: &empty}, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, patched.Color)
+	assert.Nil(t, repository.lists[1].Color)
+	assert.Equal(t, "cart", *repository.lists[1].Icon)
+}
+
+func TestDelete(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	// Only the owner deletes the list, and a list with items needs cascade.
+	err := service.Delete(1, 2, true)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	err = service.Delete(1, 1, false)
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+	assert.Empty(t, repository.deleted)
+
+	err = service.Delete(1, 1, true)
+	assert.Nil(t, err)
+
+	err = service.Delete(2, 1, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1, 2}, repository.deleted)
+}
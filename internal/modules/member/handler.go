@@ -0,0 +1,152 @@
+package member
+
+import (
+	"errors"
+	"net/http"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Handler interface {
+		Add(c *gin.Context)
+		GetAll(c *gin.Context)
+		Update(c *gin.Context)
+		Delete(c *gin.Context)
+	}
+
+	handler struct {
+		service Service
+	}
+)
+
+func NewHandler(service Service) Handler {
+	return &handler{service}
+}
+
+func (h handler) Add(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	memberDTO, err := getMemberFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	if memberDTO.UserID == 0 {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("user id cannot be null")))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	member := models.ListMember{ListID: listID, UserID: memberDTO.UserID, Role: memberDTO.Role}
+	err = h.service.Add(&member, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, models.NewMemberDTO(&member))
+}
+
+func (h handler) GetAll(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	members, err := h.service.GetAll(listID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewMembersDTO(members))
+}
+
+func (h handler) Update(c *gin.Context) {
+	listID, memberID, httpErr := getListIDAndUserIDFromRequest(c)
+	if httpErr != nil {
+		c.IndentedJSON(http.StatusBadRequest, httpErr)
+		return
+	}
+
+	memberDTO, err := getMemberFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	member := models.ListMember{ListID: listID, UserID: memberID, Role: memberDTO.Role}
+	err = h.service.UpdateRole(&member, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewMemberDTO(&member))
+}
+
+func (h handler) Delete(c *gin.Context) {
+	listID, memberID, httpErr := getListIDAndUserIDFromRequest(c)
+	if httpErr != nil {
+		c.IndentedJSON(http.StatusBadRequest, httpErr)
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	err := h.service.Remove(listID, memberID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func getMemberFromRequest(c *gin.Context) (*models.MemberDTO, error) {
+	var member models.MemberDTO
+
+	if err := c.BindJSON(&member); err != nil {
+		return nil, errors.New("invalid body request format")
+	}
+
+	if member.Role == "" {
+		return nil, errors.New("role cannot be empty")
+	}
+
+	return &member, nil
+}
+
+func getListIDAndUserIDFromRequest(c *gin.Context) (uint64, uint64, *models.HttpError) {
+	var listID, userID uint64
+
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		httpErr := models.NewHttpError(err)
+		return listID, userID, &httpErr
+	}
+
+	userID, err = utils.GetIDFromRequest(c, "user_id")
+	if err != nil {
+		httpErr := models.NewHttpError(err)
+		return listID, userID, &httpErr
+	}
+
+	return listID, userID, nil
+}
@@ -0,0 +1,70 @@
+package member
+
+import (
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"gorm.io/gorm"
+)
+
+type (
+	Repository interface {
+		Save(member *models.ListMember) error
+		Get(listID uint64, userID uint64) (*models.ListMember, error)
+		GetAll(listID uint64) (*[]models.ListMember, error)
+		Update(member *models.ListMember) error
+		Delete(listID uint64, userID uint64) error
+	}
+
+	repository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r repository) Save(member *models.ListMember) error {
+	return r.db.Create(member).Error
+}
+
+func (r repository) Get(listID uint64, userID uint64) (*models.ListMember, error) {
+	var members []models.ListMember
+	err := r.db.Where(&models.ListMember{ListID: listID, UserID: userID}).Limit(1).Find(&members).Error
+
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	return &members[0], nil
+}
+
+func (r repository) GetAll(listID uint64) (*[]models.ListMember, error) {
+	var members []models.ListMember
+	err := r.db.Where(&models.ListMember{ListID: listID}).Order("created_at").Find(&members).Error
+	return &members, err
+}
+
+func (r repository) Update(member *models.ListMember) error {
+	return r.db.Model(member).Update("role", member.Role).Error
+}
+
+// Delete revokes the membership and, with it, the assignments and watches of
+// the user on the items of the list, trashed ones included, so the user is no
+// longer notified of them.
+func (r repository) Delete(listID uint64, userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		listItems := tx.Table("item").Select("id").Where("list_id = ?", listID)
+
+		err := tx.Delete(&models.ItemAssignee{}, "user_id = ? and item_id in (?)", userID, listItems).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&models.ItemWatcher{}, "user_id = ? and item_id in (?)", userID, listItems).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.ListMember{}, "list_id = ? and user_id = ?", listID, userID).Error
+	})
+}
@@ -0,0 +1,60 @@
+package member_test
+
+import (
+	"regexp"
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/member"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type MemberRepositoryTestSuite struct {
+	suite.Suite
+	t          *testing.T
+	repository member.Repository
+	sqlMock    sqlmock.Sqlmock
+}
+
+func TestMemberRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(MemberRepositoryTestSuite))
+}
+
+func (s *MemberRepositoryTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	assert.Nil(s.T(), err)
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+	})
+	assert.Nil(s.T(), err)
+
+	s.repository = member.NewRepository(gdb)
+	s.t = s.T()
+	s.sqlMock = mock
+}
+
+func (s MemberRepositoryTestSuite) TestDeleteClearsAssignmentsAndWatches() {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(
+		"DELETE FROM `item_assignee` WHERE user_id = ? and item_id in (SELECT id FROM `item` WHERE list_id = ?)",
+	)).WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 2))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(
+		"DELETE FROM `item_watcher` WHERE user_id = ? and item_id in (SELECT id FROM `item` WHERE list_id = ?)",
+	)).WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `list_member` WHERE list_id = ? and user_id = ?")).
+		WithArgs(1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectCommit()
+
+	err := s.repository.Delete(1, 3)
+
+	assert.Nil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,196 @@
+package member
+
+import (
+	"fmt"
+	"log"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/authorization"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/user"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+type (
+	Service interface {
+		Add(member *models.ListMember, userID uint64) error
+		GetAll(listID uint64, userID uint64) (*[]models.ListMember, error)
+		UpdateRole(member *models.ListMember, userID uint64) error
+		Remove(listID uint64, memberID uint64, userID uint64) error
+	}
+
+	service struct {
+		repository           Repository
+		authorizationService authorization.Service
+		userRepository       user.Repository
+	}
+)
+
+func NewService(
+	repository Repository,
+	authorizationService authorization.Service,
+	userRepository user.Repository,
+) Service {
+	return &service{repository, authorizationService, userRepository}
+}
+
+func (s service) Add(member *models.ListMember, userID uint64) error {
+	err := s.checkCanGrant(member.ListID, member.Role, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkIfUserExists(member.UserID)
+	if err != nil {
+		return err
+	}
+
+	role, err := s.authorizationService.GetListRole(member.ListID, member.UserID)
+	if err != nil {
+		return err
+	}
+
+	if role == models.ListRoleOwner {
+		return apperrors.NewObjectInInvalidStateError("user is the owner of the list")
+	}
+
+	current, err := s.get(member.ListID, member.UserID)
+	if err != nil {
+		return err
+	}
+
+	if current != nil {
+		return apperrors.NewObjectInInvalidStateError(
+			fmt.Sprintf("user %d is already a member of list %d", member.UserID, member.ListID),
+		)
+	}
+
+	err = s.repository.Save(member)
+	if err != nil {
+		log.Printf("Error saving list member: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error saving list member")
+	}
+
+	return nil
+}
+
+func (s service) GetAll(listID uint64, userID uint64) (*[]models.ListMember, error) {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.repository.GetAll(listID)
+	if err != nil {
+		log.Printf("Error getting list members: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting list members")
+	}
+
+	return members, nil
+}
+
+func (s service) UpdateRole(member *models.ListMember, userID uint64) error {
+	err := s.checkCanGrant(member.ListID, member.Role, userID)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.getExisting(member.ListID, member.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkCanGrant(member.ListID, current.Role, userID)
+	if err != nil {
+		return err
+	}
+
+	current.Role = member.Role
+	err = s.repository.Update(current)
+	if err != nil {
+		log.Printf("Error updating list member: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error updating list member")
+	}
+
+	*member = *current
+	return nil
+}
+
+// Remove revokes a membership. Members can always leave a list by removing
+// themselves, otherwise the admin role is required.
+func (s service) Remove(listID uint64, memberID uint64, userID uint64) error {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleViewer)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.getExisting(listID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if memberID != userID {
+		err = s.checkCanGrant(listID, current.Role, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.repository.Delete(listID, memberID)
+	if err != nil {
+		log.Printf("Error deleting list member: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error deleting list member")
+	}
+
+	return nil
+}
+
+// checkCanGrant ensures the user may manage members with the given role:
+// admins manage viewers and editors, only the owner manages admins.
+func (s service) checkCanGrant(listID uint64, role models.ListRole, userID uint64) error {
+	if !role.IsAssignable() {
+		return apperrors.NewObjectInInvalidStateError(fmt.Sprintf("invalid member role '%s'", role))
+	}
+
+	required := models.ListRoleAdmin
+	if role == models.ListRoleAdmin {
+		required = models.ListRoleOwner
+	}
+
+	return s.authorizationService.CheckListPermission(listID, userID, required)
+}
+
+func (s service) get(listID uint64, memberID uint64) (*models.ListMember, error) {
+	member, err := s.repository.Get(listID, memberID)
+	if err != nil {
+		log.Printf("Error getting list member: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting list member")
+	}
+
+	return member, nil
+}
+
+func (s service) getExisting(listID uint64, memberID uint64) (*models.ListMember, error) {
+	member, err := s.get(listID, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	if member == nil {
+		return nil, apperrors.NewNotFoundError("member", memberID)
+	}
+
+	return member, nil
+}
+
+func (s service) checkIfUserExists(userID uint64) error {
+	exists, err := s.userRepository.Exists(userID)
+	if err != nil {
+		return apperrors.NewInternalError("Internal error checking if user exists")
+	}
+
+	if !exists {
+		return apperrors.NewNotFoundError("user_id", userID)
+	}
+
+	return nil
+}
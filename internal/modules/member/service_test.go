@@ -0,0 +1,189 @@
+package member_test
+
+import (
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/member"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository keeps the members of each list in memory, by user id.
+type fakeRepository struct {
+	members map[uint64]map[uint64]models.ListMember
+}
+
+func (r *fakeRepository) Save(listMember *models.ListMember) error {
+	r.members[listMember.ListID][listMember.UserID] = *listMember
+	return nil
+}
+
+func (r *fakeRepository) Get(listID uint64, userID uint64) (*models.ListMember, error) {
+	if stored, ok := r.members[listID][userID]; ok {
+		return &stored, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) GetAll(listID uint64) (*[]models.ListMember, error) {
+	members := []models.ListMember{}
+	for _, stored := range r.members[listID] {
+		members = append(members, stored)
+	}
+	return &members, nil
+}
+
+func (r *fakeRepository) Update(listMember *models.ListMember) error {
+	r.members[listMember.ListID][listMember.UserID] = *listMember
+	return nil
+}
+
+func (r *fakeRepository) Delete(listID uint64, userID uint64) error {
+	delete(r.members[listID], userID)
+	return nil
+}
+
+// fakeAuthorizationService resolves the roles from the owners of the lists
+// and the members kept by the fake repository.
+type fakeAuthorizationService struct {
+	owners     map[uint64]uint64
+	repository *fakeRepository
+}
+
+func (s fakeAuthorizationService) GetListRole(listID uint64, userID uint64) (models.ListRole, error) {
+	if s.owners[listID] == userID {
+		return models.ListRoleOwner, nil
+	}
+
+	if stored, ok := s.repository.members[listID][userID]; ok {
+		return stored.Role, nil
+	}
+	return models.ListRoleNone, nil
+}
+
+func (s fakeAuthorizationService) CheckListPermission(listID uint64, userID uint64, required models.ListRole) error {
+	role, _ := s.GetListRole(listID, userID)
+	if !role.Includes(required) {
+		return apperrors.NewForbiddenError("forbidden")
+	}
+	return nil
+}
+
+// newServiceToTest returns a service over a list (10) owned by John (1), where
+// Mary (2) is an admin and Paul (3) an editor. Ann (4) and Bob (5) are not
+// members.
+func newServiceToTest() (member.Service, *fakeRepository) {
+	repository := &fakeRepository{members: map[uint64]map[uint64]models.ListMember{
+		10: {
+			2: {ListID: 10, UserID: 2, Role: models.ListRoleAdmin},
+			3: {ListID: 10, UserID: 3, Role: models.ListRoleEditor},
+		},
+	}}
+	authorizationService := fakeAuthorizationService{owners: map[uint64]uint64{10: 1}, repository: repository}
+	users := testutil.NewUserRepository("john", "mary", "paul", "ann", "bob")
+
+	return member.NewService(repository, authorizationService, users), repository
+}
+
+func assertErrorType[T error](t *testing.T, err error) {
+	_, ok := err.(T)
+	assert.True(t, ok, "expected a %T, got %v", *new(T), err)
+}
+
+func TestAdd(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	err := service.Add(&models.ListMember{ListID: 10, UserID: 4, Role: models.ListRoleViewer}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, models.ListRoleViewer, repository.members[10][4].Role)
+
+	// Only the owner grants the admin role.
+	err = service.Add(&models.ListMember{ListID: 10, UserID: 5, Role: models.ListRoleAdmin}, 2)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	err = service.Add(&models.ListMember{ListID: 10, UserID: 5, Role: models.ListRoleAdmin}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, models.ListRoleAdmin, repository.members[10][5].Role)
+}
+
+func TestAddRejectsInvalidMembers(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	// Editors cannot manage members.
+	err := service.Add(&models.ListMember{ListID: 10, UserID: 4, Role: models.ListRoleViewer}, 3)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	err = service.Add(&models.ListMember{ListID: 10, UserID: 4, Role: models.ListRoleOwner}, 1)
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+
+	err = service.Add(&models.ListMember{ListID: 10, UserID: 99, Role: models.ListRoleViewer}, 1)
+	assertErrorType[*apperrors.NotFoundError](t, err)
+
+	err = service.Add(&models.ListMember{ListID: 10, UserID: 1, Role: models.ListRoleViewer}, 1)
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+
+	err = service.Add(&models.ListMember{ListID: 10, UserID: 3, Role: models.ListRoleViewer}, 1)
+	assertErrorType[*apperrors.ObjectInInvalidStateError](t, err)
+	assert.Equal(t, models.ListRoleEditor, repository.members[10][3].Role)
+	assert.Len(t, repository.members[10], 2)
+}
+
+func TestGetAll(t *testing.T) {
+	service, _ := newServiceToTest()
+
+	members, err := service.GetAll(10, 3)
+	assert.Nil(t, err)
+	assert.Len(t, *members, 2)
+
+	_, err = service.GetAll(10, 4)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+}
+
+func TestUpdateRole(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	updated := models.ListMember{ListID: 10, UserID: 3, Role: models.ListRoleViewer}
+	err := service.UpdateRole(&updated, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, models.ListRoleViewer, repository.members[10][3].Role)
+
+	// Admins cannot promote to admin nor demote other admins.
+	err = service.UpdateRole(&models.ListMember{ListID: 10, UserID: 3, Role: models.ListRoleAdmin}, 2)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	err = service.UpdateRole(&models.ListMember{ListID: 10, UserID: 2, Role: models.ListRoleViewer}, 2)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	err = service.UpdateRole(&models.ListMember{ListID: 10, UserID: 2, Role: models.ListRoleViewer}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, models.ListRoleViewer, repository.members[10][2].Role)
+
+	err = service.UpdateRole(&models.ListMember{ListID: 10, UserID: 4, Role: models.ListRoleViewer}, 1)
+	assertErrorType[*apperrors.NotFoundError](t, err)
+}
+
+func TestRemove(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	// Editors can leave the list but cannot remove anyone else.
+	err := service.Remove(10, 2, 3)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	err = service.Remove(10, 3, 3)
+	assert.Nil(t, err)
+	assert.NotContains(t, repository.members[10], uint64(3))
+
+	// Only the owner removes admins.
+	repository.members[10][3] = models.ListMember{ListID: 10, UserID: 3, Role: models.ListRoleAdmin}
+	err = service.Remove(10, 3, 2)
+	assertErrorType[*apperrors.ForbiddenError](t, err)
+
+	err = service.Remove(10, 3, 1)
+	assert.Nil(t, err)
+	assert.NotContains(t, repository.members[10], uint64(3))
+
+	err = service.Remove(10, 4, 1)
+	assertErrorType[*apperrors.NotFoundError](t, err)
+}
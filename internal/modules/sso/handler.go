@@ -0,0 +1,144 @@
+package sso
+
+import (
+	"errors"
+	"net/http"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Handler interface {
+		Save(c *gin.Context)
+		Get(c *gin.Context)
+		GetAll(c *gin.Context)
+		Update(c *gin.Context)
+		Delete(c *gin.Context)
+	}
+
+	handler struct {
+		service Service
+	}
+)
+
+func NewHandler(service Service) Handler {
+	return &handler{service}
+}
+
+// Save registers an SSO application. The response is the only one that
+// carries the secret of an HMAC application.
+func (h handler) Save(c *gin.Context) {
+	appDTO, err := getApplicationFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	app := newApplicationFromDTO(appDTO)
+	app.Enabled = appDTO.Enabled == nil || *appDTO.Enabled
+
+	err = h.service.Save(app)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, models.NewSSOApplicationDTO(app, true))
+}
+
+func (h handler) Get(c *gin.Context) {
+	id, err := utils.GetIDFromRequest(c, "app_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	app, err := h.service.Get(id)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewSSOApplicationDTO(app, false))
+}
+
+func (h handler) GetAll(c *gin.Context) {
+	apps, err := h.service.GetAll()
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewSSOApplicationsDTO(apps))
+}
+
+func (h handler) Update(c *gin.Context) {
+	id, err := utils.GetIDFromRequest(c, "app_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	appDTO, err := getApplicationFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	if appDTO.Enabled == nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("enabled must be informed")))
+		return
+	}
+
+	app := newApplicationFromDTO(appDTO)
+	app.ID = id
+	app.Enabled = *appDTO.Enabled
+
+	updated, err := h.service.Update(app)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewSSOApplicationDTO(updated, false))
+}
+
+func (h handler) Delete(c *gin.Context) {
+	id, err := utils.GetIDFromRequest(c, "app_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func getApplicationFromRequest(c *gin.Context) (*models.SSOApplicationDTO, error) {
+	var app models.SSOApplicationDTO
+
+	if err := c.BindJSON(&app); err != nil {
+		return nil, errors.New("invalid body request format")
+	}
+
+	return &app, nil
+}
+
+func newApplicationFromDTO(appDTO *models.SSOApplicationDTO) *models.SSOApplication {
+	return &models.SSOApplication{
+		Name:      appDTO.Name,
+		Issuer:    appDTO.Issuer,
+		Audience:  appDTO.Audience,
+		Algorithm: appDTO.Algorithm,
+		Secret:    appDTO.Secret,
+		PublicKey: appDTO.PublicKey,
+	}
+}
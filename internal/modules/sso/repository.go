@@ -0,0 +1,71 @@
+package sso
+
+import (
+	"errors"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"gorm.io/gorm"
+)
+
+type (
+	Repository interface {
+		Save(app *models.SSOApplication) error
+		Get(id uint64) (*models.SSOApplication, error)
+		GetAll() (*[]models.SSOApplication, error)
+		GetByIssuer(issuer string) (*models.SSOApplication, error)
+		Update(app *models.SSOApplication) error
+		Delete(id uint64) error
+	}
+
+	repository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r repository) Save(app *models.SSOApplication) error {
+	return r.db.Create(app).Error
+}
+
+func (r repository) Get(id uint64) (*models.SSOApplication, error) {
+	var app models.SSOApplication
+	err := r.db.First(&app, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &app, err
+}
+
+func (r repository) GetAll() (*[]models.SSOApplication, error) {
+	var apps []models.SSOApplication
+	err := r.db.Order("id").Find(&apps).Error
+	return &apps, err
+}
+
+func (r repository) GetByIssuer(issuer string) (*models.SSOApplication, error) {
+	var app models.SSOApplication
+	err := r.db.Where("issuer = ?", issuer).First(&app).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &app, err
+}
+
+// Update saves all the columns of the application, so the key of the
+// algorithm not in use is cleared and the application can be disabled.
+func (r repository) Update(app *models.SSOApplication) error {
+	return r.db.Save(app).Error
+}
+
+// Delete removes the application. The jtis of its used tokens go away with
+// it.
+func (r repository) Delete(id uint64) error {
+	return r.db.Delete(&models.SSOApplication{}, id).Error
+}
@@ -0,0 +1,189 @@
+package sso
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+type (
+	Service interface {
+		Save(app *models.SSOApplication) error
+		Get(id uint64) (*models.SSOApplication, error)
+		GetAll() (*[]models.SSOApplication, error)
+		Update(app *models.SSOApplication) (*models.SSOApplication, error)
+		Delete(id uint64) error
+	}
+
+	service struct {
+		repository Repository
+	}
+)
+
+func NewService(repository Repository) Service {
+	return &service{repository}
+}
+
+// Save registers the application. An HMAC application without a secret gets
+// a random one, which the caller must hand over to the application.
+func (s service) Save(app *models.SSOApplication) error {
+	if app.IsHMAC() && (app.Secret == nil || *app.Secret == "") {
+		secret, err := generateSecret()
+		if err != nil {
+			log.Printf("Error generating SSO application secret: %s\n", err.Error())
+			return apperrors.NewInternalError("Internal error saving SSO application")
+		}
+		app.Secret = &secret
+	}
+
+	err := s.validateApplication(app)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.Save(app)
+	if err != nil {
+		log.Printf("Error saving SSO application: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error saving SSO application")
+	}
+
+	return nil
+}
+
+func (s service) Get(id uint64) (*models.SSOApplication, error) {
+	app, err := s.repository.Get(id)
+	if err != nil {
+		log.Printf("Error getting SSO application: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting SSO application")
+	}
+
+	if app == nil {
+		return nil, apperrors.NewNotFoundError("SSO application", id)
+	}
+
+	return app, nil
+}
+
+func (s service) GetAll() (*[]models.SSOApplication, error) {
+	apps, err := s.repository.GetAll()
+	if err != nil {
+		log.Printf("Error getting SSO applications: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting SSO applications")
+	}
+
+	return apps, nil
+}
+
+// Update changes the application. The secret and the public key are kept
+// when not informed, which allows changing only the other attributes.
+func (s service) Update(app *models.SSOApplication) (*models.SSOApplication, error) {
+	stored, err := s.Get(app.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored.Name = app.Name
+	stored.Issuer = app.Issuer
+	stored.Audience = app.Audience
+	stored.Algorithm = app.Algorithm
+	stored.Enabled = app.Enabled
+
+	if app.Secret != nil {
+		stored.Secret = app.Secret
+	}
+
+	if app.PublicKey != nil {
+		stored.PublicKey = app.PublicKey
+	}
+
+	err = s.validateApplication(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.Update(stored)
+	if err != nil {
+		log.Printf("Error updating SSO application: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error updating SSO application")
+	}
+
+	return stored, nil
+}
+
+func (s service) Delete(id uint64) error {
+	_, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.Delete(id)
+	if err != nil {
+		log.Printf("Error deleting SSO application: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error deleting SSO application")
+	}
+
+	return nil
+}
+
+// validateApplication checks the attributes of the application and keeps
+// only the key of its algorithm.
+func (s service) validateApplication(app *models.SSOApplication) error {
+	app.Name = strings.TrimSpace(app.Name)
+	if app.Name == "" || len(app.Name) > 100 {
+		return apperrors.NewObjectInInvalidStateError("name must have between 1 and 100 characters")
+	}
+
+	if app.Issuer == "" || len(app.Issuer) > 255 {
+		return apperrors.NewObjectInInvalidStateError("issuer must have between 1 and 255 characters")
+	}
+
+	if app.Audience == "" || len(app.Audience) > 255 {
+		return apperrors.NewObjectInInvalidStateError("audience must have between 1 and 255 characters")
+	}
+
+	if !models.SSOAlgorithms[app.Algorithm] {
+		return apperrors.NewObjectInInvalidStateError(fmt.Sprintf("invalid algorithm '%s'", app.Algorithm))
+	}
+
+	if app.IsHMAC() {
+		app.PublicKey = nil
+		if app.Secret == nil || len(*app.Secret) < constants.MinSSOSecretLength {
+			return apperrors.NewObjectInInvalidStateError(
+				fmt.Sprintf("secret must have at least %d characters", constants.MinSSOSecretLength),
+			)
+		}
+	} else {
+		app.Secret = nil
+		if _, err := app.VerificationKey(); err != nil {
+			return apperrors.NewObjectInInvalidStateError(
+				fmt.Sprintf("public_key must be a PEM public key for %s", app.Algorithm),
+			)
+		}
+	}
+
+	registered, err := s.repository.GetByIssuer(app.Issuer)
+	if err != nil {
+		log.Printf("Error getting SSO application by issuer: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error saving SSO application")
+	}
+
+	if registered != nil && registered.ID != app.ID {
+		return apperrors.NewObjectInInvalidStateError(fmt.Sprintf("issuer '%s' already registered", app.Issuer))
+	}
+
+	return nil
+}
+
+func generateSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
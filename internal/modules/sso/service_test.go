@@ -0,0 +1,83 @@
+package sso_test
+
+import (
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/sso"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApplication() *models.SSOApplication {
+	return &models.SSOApplication{
+		Name: "Partner", Issuer: "https://partner.test", Audience: "list-manager", Algorithm: "HS256", Enabled: true,
+	}
+}
+
+func TestSaveGeneratesHMACSecret(t *testing.T) {
+	repository := &testutil.SSORepository{}
+	service := sso.NewService(repository)
+
+	app := newApplication()
+	err := service.Save(app)
+
+	assert.Nil(t, err)
+	assert.Len(t, *app.Secret, 64)
+	assert.Len(t, repository.Saved, 1)
+}
+
+func TestSaveRejectsInvalidApplications(t *testing.T) {
+	secret := "short"
+	publicKey := "not a key"
+	registered := newApplication()
+	registered.ID = 1
+	repository := &testutil.SSORepository{Apps: []models.SSOApplication{*registered}}
+	service := sso.NewService(repository)
+
+	tests := map[string]func(app *models.SSOApplication){
+		"short secret":      func(app *models.SSOApplication) { app.Secret = &secret },
+		"unknown algorithm": func(app *models.SSOApplication) { app.Algorithm = "none" },
+		"invalid key":       func(app *models.SSOApplication) { app.Algorithm, app.PublicKey = "RS256", &publicKey },
+		"taken issuer":      func(app *models.SSOApplication) {},
+		"missing audience":  func(app *models.SSOApplication) { app.Audience = "" },
+	}
+
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := newApplication()
+			if name != "taken issuer" {
+				app.Issuer = "https://other.test"
+			}
+			change(app)
+
+			err := service.Save(app)
+
+			_, ok := err.(*apperrors.ObjectInInvalidStateError)
+			assert.True(t, ok, "expected an invalid state error, got %v", err)
+		})
+	}
+	assert.Empty(t, repository.Saved)
+}
+
+func TestUpdateKeepsSecretWhenNotInformed(t *testing.T) {
+	secret := "0123456789abcdef0123456789abcdef"
+	registered := newApplication()
+	registered.ID = 1
+	registered.Secret = &secret
+	repository := &testutil.SSORepository{Apps: []models.SSOApplication{*registered}}
+	service := sso.NewService(repository)
+
+	app := newApplication()
+	app.ID = 1
+	app.Name = "Renamed"
+	app.Enabled = false
+
+	updated, err := service.Update(app)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Renamed", updated.Name)
+	assert.False(t, updated.Enabled)
+	assert.Equal(t, secret, *updated.Secret)
+}
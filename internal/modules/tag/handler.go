@@ -0,0 +1,211 @@
+package tag
+
+import (
+	"errors"
+	"net/http"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Handler interface {
+		Save(c *gin.Context)
+		GetAll(c *gin.Context)
+		SaveOnList(c *gin.Context)
+		GetByList(c *gin.Context)
+		Update(c *gin.Context)
+		Delete(c *gin.Context)
+		Merge(c *gin.Context)
+		SetItemTags(c *gin.Context)
+	}
+
+	handler struct {
+		service Service
+	}
+)
+
+func NewHandler(service Service) Handler {
+	return &handler{service}
+}
+
+// Save creates a personal tag of the authenticated user.
+func (h handler) Save(c *gin.Context) {
+	h.save(c, nil)
+}
+
+func (h handler) GetAll(c *gin.Context) {
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	tags, err := h.service.GetUserTags(userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewTagsDTO(tags))
+}
+
+// SaveOnList creates a tag shared by the members of the list.
+func (h handler) SaveOnList(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	h.save(c, &listID)
+}
+
+func (h handler) GetByList(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	tags, err := h.service.GetListTags(listID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewTagsDTO(tags))
+}
+
+func (h handler) Update(c *gin.Context) {
+	id, err := utils.GetIDFromRequest(c, "tag_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	tagDTO, err := getTagFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	tag := models.Tag{ID: id, Name: tagDTO.Name, Color: tagDTO.Color}
+	updated, err := h.service.Update(&tag, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewTagDTO(updated))
+}
+
+func (h handler) Delete(c *gin.Context) {
+	id, err := utils.GetIDFromRequest(c, "tag_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	err = h.service.Delete(id, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Merge merges the tag of the path into the target_id tag of the body.
+func (h handler) Merge(c *gin.Context) {
+	id, err := utils.GetIDFromRequest(c, "tag_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	var mergeDTO models.TagMergeDTO
+	if err = c.BindJSON(&mergeDTO); err != nil || mergeDTO.TargetID == 0 {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("target_id must be informed")))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	target, err := h.service.Merge(id, mergeDTO.TargetID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewTagDTO(target))
+}
+
+// SetItemTags replaces the tags of an item.
+func (h handler) SetItemTags(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	itemID, err := utils.GetIDFromRequest(c, "item_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	var itemTagsDTO models.ItemTagsDTO
+	if err = c.BindJSON(&itemTagsDTO); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("invalid body request format")))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	tags, err := h.service.SetItemTags(listID, itemID, itemTagsDTO.TagIDs, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewTagsDTO(tags))
+}
+
+func (h handler) save(c *gin.Context, listID *uint64) {
+	tagDTO, err := getTagFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	tag := models.Tag{Name: tagDTO.Name, Color: tagDTO.Color, ListID: listID}
+	err = h.service.Save(&tag, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, models.NewTagDTO(&tag))
+}
+
+func getTagFromRequest(c *gin.Context) (*models.TagDTO, error) {
+	var tag models.TagDTO
+
+	if err := c.BindJSON(&tag); err != nil {
+		return nil, errors.New("invalid body request format")
+	}
+
+	if tag.Name == "" {
+		return nil, errors.New("name cannot be empty")
+	}
+
+	return &tag, nil
+}
@@ -0,0 +1,154 @@
+package tag
+
+import (
+	"errors"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"gorm.io/gorm"
+)
+
+// visibleTags selects the tags a user can see on the items: the list tags and
+// the personal tags of the user.
+const visibleTags = "tag.user_id IS NULL OR tag.user_id = ?"
+
+type (
+	Repository interface {
+		Save(tag *models.Tag) error
+		Get(id uint64) (*models.Tag, error)
+		GetByName(scope *models.Tag, name string) (*models.Tag, error)
+		GetUserTags(userID uint64) (*[]models.Tag, error)
+		GetListTags(listID uint64) (*[]models.Tag, error)
+		GetTags(ids []uint64) (*[]models.Tag, error)
+		GetItemTags(itemID uint64, userID uint64) (*[]models.Tag, error)
+		Update(tag *models.Tag) error
+		Delete(id uint64) error
+		Merge(sourceID uint64, targetID uint64) error
+		SetItemTags(itemID uint64, userID uint64, tagIDs []uint64) error
+	}
+
+	repository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r repository) Save(tag *models.Tag) error {
+	return r.db.Create(tag).Error
+}
+
+func (r repository) Get(id uint64) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.First(&tag, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &tag, err
+}
+
+// GetByName returns the tag with the name in the same scope, user or list,
+// of the given tag.
+func (r repository) GetByName(scope *models.Tag, name string) (*models.Tag, error) {
+	query := r.db.Where("name = ?", name)
+	if scope.ListID != nil {
+		query = query.Where("list_id = ?", *scope.ListID)
+	} else {
+		query = query.Where("user_id = ?", *scope.UserID)
+	}
+
+	var tag models.Tag
+	err := query.Take(&tag).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &tag, err
+}
+
+func (r repository) GetUserTags(userID uint64) (*[]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Where("user_id = ?", userID).Order("name").Find(&tags).Error
+	return &tags, err
+}
+
+func (r repository) GetListTags(listID uint64) (*[]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Where("list_id = ?", listID).Order("name").Find(&tags).Error
+	return &tags, err
+}
+
+func (r repository) GetTags(ids []uint64) (*[]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Where("id IN ?", ids).Find(&tags).Error
+	return &tags, err
+}
+
+// GetItemTags returns the tags of the item that the user can see, list tags
+// and the personal tags of the user.
+func (r repository) GetItemTags(itemID uint64, userID uint64) (*[]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.
+		Joins("JOIN item_tag ON item_tag.tag_id = tag.id").
+		Where("item_tag.item_id = ?", itemID).
+		Where(visibleTags, userID).
+		Order("tag.name").
+		Find(&tags).
+		Error
+	return &tags, err
+}
+
+// Update writes the name and the color of the tag. The items reference the
+// tag by id, so a rename shows up on every tagged item at once.
+func (r repository) Update(tag *models.Tag) error {
+	return r.db.Model(tag).Select("name", "color").Updates(tag).Error
+}
+
+// Delete removes the tag, and with it the tag from every item.
+func (r repository) Delete(id uint64) error {
+	return r.db.Delete(&models.Tag{}, id).Error
+}
+
+// Merge moves the items tagged with the source tag to the target tag and
+// removes the source tag, in a single transaction. Items that already had
+// both tags keep a single link to the target.
+func (r repository) Merge(sourceID uint64, targetID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			"INSERT IGNORE INTO item_tag (item_id, tag_id) SELECT item_id, ? FROM item_tag WHERE tag_id = ?",
+			targetID, sourceID,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.Tag{}, sourceID).Error
+	})
+}
+
+// SetItemTags replaces the tags of the item that the user can see. The
+// personal tags of other users are kept.
+func (r repository) SetItemTags(itemID uint64, userID uint64, tagIDs []uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		replaced := tx.Model(&models.Tag{}).Select("id").Where(visibleTags, userID)
+		err := tx.Delete(&models.ItemTag{}, "item_id = ? AND tag_id IN (?)", itemID, replaced).Error
+		if err != nil {
+			return err
+		}
+
+		if len(tagIDs) == 0 {
+			return nil
+		}
+
+		links := make([]models.ItemTag, len(tagIDs))
+		for i, tagID := range tagIDs {
+			links[i] = models.ItemTag{ItemID: itemID, TagID: tagID}
+		}
+
+		return tx.Create(&links).Error
+	})
+}
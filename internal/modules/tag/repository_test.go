@@ -0,0 +1,87 @@
+package tag_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/tag"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type TagRepositoryTestSuite struct {
+	suite.Suite
+	t          *testing.T
+	repository tag.Repository
+	sqlMock    sqlmock.Sqlmock
+}
+
+func TestTagRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(TagRepositoryTestSuite))
+}
+
+func (s *TagRepositoryTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	assert.Nil(s.T(), err)
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+	})
+	assert.Nil(s.T(), err)
+
+	s.repository = tag.NewRepository(gdb)
+	s.t = s.T()
+	s.sqlMock = mock
+}
+
+func (s TagRepositoryTestSuite) TestMergeMovesItemsAndDeletesSource() {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(
+		"INSERT IGNORE INTO item_tag (item_id, tag_id) SELECT item_id, ? FROM item_tag WHERE tag_id = ?",
+	)).WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 3))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `tag` WHERE `tag`.`id` = ?")).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectCommit()
+
+	err := s.repository.Merge(1, 2)
+
+	assert.Nil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s TagRepositoryTestSuite) TestMergeRollsBackOnError() {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec("INSERT IGNORE INTO item_tag").WillReturnError(errors.New("deadlock"))
+	s.sqlMock.ExpectRollback()
+
+	err := s.repository.Merge(1, 2)
+
+	assert.NotNil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s TagRepositoryTestSuite) TestSetItemTagsReplacesLinks() {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec(regexp.QuoteMeta(
+		"DELETE FROM `item_tag` WHERE item_id = ? AND tag_id IN (SELECT `id` FROM `tag` WHERE tag.user_id IS NULL OR tag.user_id = ?)",
+	)).
+		WithArgs(7, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `item_tag` (`item_id`,`tag_id`) VALUES (?,?),(?,?)")).
+		WithArgs(7, 1, 7, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.sqlMock.ExpectCommit()
+
+	err := s.repository.SetItemTags(7, 3, []uint64{1, 2})
+
+	assert.Nil(s.t, err)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
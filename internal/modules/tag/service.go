@@ -0,0 +1,318 @@
+package tag
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/authorization"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/item"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
+)
+
+type (
+	Service interface {
+		Save(tag *models.Tag, userID uint64) error
+		GetUserTags(userID uint64) (*[]models.Tag, error)
+		GetListTags(listID uint64, userID uint64) (*[]models.Tag, error)
+		Update(tag *models.Tag, userID uint64) (*models.Tag, error)
+		Delete(id uint64, userID uint64) error
+		Merge(sourceID uint64, targetID uint64, userID uint64) (*models.Tag, error)
+		SetItemTags(listID uint64, itemID uint64, tagIDs []uint64, userID uint64) (*[]models.Tag, error)
+	}
+
+	service struct {
+		repository           Repository
+		itemRepository       item.Repository
+		authorizationService authorization.Service
+	}
+)
+
+func NewService(
+	repository Repository,
+	itemRepository item.Repository,
+	authorizationService authorization.Service,
+) Service {
+	return &service{repository, itemRepository, authorizationService}
+}
+
+// Save creates a tag of the list informed in the tag, which requires the
+// admin role on the list, or a personal tag of the user.
+func (s service) Save(tag *models.Tag, userID uint64) error {
+	if tag.ListID != nil {
+		err := s.authorizationService.CheckListPermission(*tag.ListID, userID, models.ListRoleAdmin)
+		if err != nil {
+			return err
+		}
+		tag.UserID = nil
+	} else {
+		tag.UserID = &userID
+	}
+
+	err := s.validateTag(tag)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.Save(tag)
+	if err != nil {
+		log.Printf("Error saving tag: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error saving tag")
+	}
+
+	return nil
+}
+
+func (s service) GetUserTags(userID uint64) (*[]models.Tag, error) {
+	tags, err := s.repository.GetUserTags(userID)
+	if err != nil {
+		log.Printf("Error getting tags: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting tags")
+	}
+
+	return tags, nil
+}
+
+func (s service) GetListTags(listID uint64, userID uint64) (*[]models.Tag, error) {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.repository.GetListTags(listID)
+	if err != nil {
+		log.Printf("Error getting list tags: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting list tags")
+	}
+
+	return tags, nil
+}
+
+// Update renames and recolors the tag.
+func (s service) Update(tag *models.Tag, userID uint64) (*models.Tag, error) {
+	stored, err := s.getManagedTag(tag.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored.Name = tag.Name
+	stored.Color = tag.Color
+
+	err = s.validateTag(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.Update(stored)
+	if err != nil {
+		log.Printf("Error updating tag: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error updating tag")
+	}
+
+	return stored, nil
+}
+
+func (s service) Delete(id uint64, userID uint64) error {
+	_, err := s.getManagedTag(id, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.Delete(id)
+	if err != nil {
+		log.Printf("Error deleting tag: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error deleting tag")
+	}
+
+	return nil
+}
+
+// Merge replaces the source tag by the target tag on every item and removes
+// the source tag. Both tags must belong to the same user or list.
+func (s service) Merge(sourceID uint64, targetID uint64, userID uint64) (*models.Tag, error) {
+	if sourceID == targetID {
+		return nil, apperrors.NewObjectInInvalidStateError("a tag cannot be merged into itself")
+	}
+
+	source, err := s.getManagedTag(sourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.getManagedTag(targetID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sameScope(source, target) {
+		return nil, apperrors.NewObjectInInvalidStateError("only tags of the same user or list can be merged")
+	}
+
+	err = s.repository.Merge(sourceID, targetID)
+	if err != nil {
+		log.Printf("Error merging tags: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error merging tags")
+	}
+
+	return target, nil
+}
+
+// SetItemTags replaces the tags of the item that the user can see. Only tags
+// of the item list, personal tags of the user and the tags the item already
+// has can be used, and the personal tags of other users are kept.
+func (s service) SetItemTags(listID uint64, itemID uint64, tagIDs []uint64, userID uint64) (*[]models.Tag, error) {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	isItemOnList, err := s.itemRepository.IsItemInList(listID, itemID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("Internal error checking item in list")
+	}
+
+	if !isItemOnList {
+		return nil, apperrors.NewItemNotFoundInListError(itemID, listID)
+	}
+
+	if len(tagIDs) > constants.MaxBulkItems {
+		return nil, apperrors.NewObjectInInvalidStateError(
+			fmt.Sprintf("an item cannot have more than %d tags", constants.MaxBulkItems),
+		)
+	}
+
+	ids, err := s.getUsableTagIDs(listID, itemID, tagIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.SetItemTags(itemID, userID, ids)
+	if err != nil {
+		log.Printf("Error tagging item: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error tagging item")
+	}
+
+	return s.getItemTags(itemID, userID)
+}
+
+func (s service) getItemTags(itemID uint64, userID uint64) (*[]models.Tag, error) {
+	tags, err := s.repository.GetItemTags(itemID, userID)
+	if err != nil {
+		log.Printf("Error getting item tags: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting item tags")
+	}
+
+	return tags, nil
+}
+
+// getManagedTag returns the tag if the user can change it: personal tags by
+// their owner and list tags by the list admins. Personal tags of other users
+// are reported as not found.
+func (s service) getManagedTag(id uint64, userID uint64) (*models.Tag, error) {
+	tag, err := s.repository.Get(id)
+	if err != nil {
+		log.Printf("Error getting tag: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting tag")
+	}
+
+	if tag == nil || (tag.UserID != nil && *tag.UserID != userID) {
+		return nil, apperrors.NewNotFoundError("tag", id)
+	}
+
+	if tag.ListID != nil {
+		err = s.authorizationService.CheckListPermission(*tag.ListID, userID, models.ListRoleAdmin)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tag, nil
+}
+
+// getUsableTagIDs removes the repeated tags and checks that each one can be
+// used on the item. The tags the item already has are accepted, so the tags
+// returned for an item can always be sent back.
+func (s service) getUsableTagIDs(listID uint64, itemID uint64, tagIDs []uint64, userID uint64) ([]uint64, error) {
+	if len(tagIDs) == 0 {
+		return []uint64{}, nil
+	}
+
+	tags, err := s.repository.GetTags(tagIDs)
+	if err != nil {
+		log.Printf("Error getting tags: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting tags")
+	}
+
+	current, err := s.getItemTags(itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usable := make(map[uint64]bool, len(*tags)+len(*current))
+	for _, tag := range *tags {
+		if tag.UserID != nil {
+			usable[tag.ID] = *tag.UserID == userID
+		} else {
+			usable[tag.ID] = tag.ListID != nil && *tag.ListID == listID
+		}
+	}
+
+	for _, tag := range *current {
+		usable[tag.ID] = true
+	}
+
+	ids := make([]uint64, 0, len(tagIDs))
+	added := make(map[uint64]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		if !usable[id] {
+			return nil, apperrors.NewNotFoundError("tag", id)
+		}
+
+		if !added[id] {
+			added[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s service) validateTag(tag *models.Tag) error {
+	tag.Name = strings.TrimSpace(tag.Name)
+	if tag.Name == "" {
+		return apperrors.NewObjectInInvalidStateError("name cannot be empty")
+	}
+
+	if len(tag.Name) > 64 {
+		return apperrors.NewObjectInInvalidStateError("name cannot exceed 64 characters")
+	}
+
+	if tag.Color != nil && !utils.IsHexColor(*tag.Color) {
+		return apperrors.NewObjectInInvalidStateError("color must be in the #RRGGBB format")
+	}
+
+	existing, err := s.repository.GetByName(tag, tag.Name)
+	if err != nil {
+		log.Printf("Error getting tag by name: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error getting tag by name")
+	}
+
+	if existing != nil && existing.ID != tag.ID {
+		return apperrors.NewObjectInInvalidStateError(
+			fmt.Sprintf("tag '%s' already exists, merge the tags instead", tag.Name),
+		)
+	}
+
+	return nil
+}
+
+func sameScope(a *models.Tag, b *models.Tag) bool {
+	if a.ListID != nil {
+		return b.ListID != nil && *a.ListID == *b.ListID
+	}
+
+	return b.UserID != nil && *a.UserID == *b.UserID
+}
@@ -0,0 +1,203 @@
+package tag_test
+
+import (
+	"sort"
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/item"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/tag"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository keeps the tags of the items like the database does: the
+// tags a user replaces are only the ones the user can see.
+type fakeRepository struct {
+	tags     map[uint64]*models.Tag
+	itemTags map[uint64][]uint64
+}
+
+func (r *fakeRepository) Save(tag *models.Tag) error {
+	tag.ID = uint64(len(r.tags) + 1)
+	r.tags[tag.ID] = tag
+	return nil
+}
+
+func (r *fakeRepository) Get(id uint64) (*models.Tag, error) {
+	if tag, ok := r.tags[id]; ok {
+		stored := *tag
+		return &stored, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) GetByName(scope *models.Tag, name string) (*models.Tag, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) GetUserTags(userID uint64) (*[]models.Tag, error) {
+	return &[]models.Tag{}, nil
+}
+
+func (r *fakeRepository) GetListTags(listID uint64) (*[]models.Tag, error) {
+	return &[]models.Tag{}, nil
+}
+
+func (r *fakeRepository) GetTags(ids []uint64) (*[]models.Tag, error) {
+	tags := []models.Tag{}
+	for _, id := range ids {
+		if tag, ok := r.tags[id]; ok {
+			tags = append(tags, *tag)
+		}
+	}
+	return &tags, nil
+}
+
+func (r *fakeRepository) GetItemTags(itemID uint64, userID uint64) (*[]models.Tag, error) {
+	tags := []models.Tag{}
+	for _, id := range r.itemTags[itemID] {
+		if tag := r.tags[id]; isVisible(tag, userID) {
+			tags = append(tags, *tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return &tags, nil
+}
+
+func (r *fakeRepository) Update(tag *models.Tag) error { return nil }
+
+func (r *fakeRepository) Delete(id uint64) error {
+	delete(r.tags, id)
+	return nil
+}
+
+func (r *fakeRepository) Merge(sourceID uint64, targetID uint64) error { return nil }
+
+func (r *fakeRepository) SetItemTags(itemID uint64, userID uint64, tagIDs []uint64) error {
+	kept := []uint64{}
+	for _, id := range r.itemTags[itemID] {
+		if !isVisible(r.tags[id], userID) {
+			kept = append(kept, id)
+		}
+	}
+	r.itemTags[itemID] = append(kept, tagIDs...)
+	return nil
+}
+
+func isVisible(tag *models.Tag, userID uint64) bool {
+	return tag.UserID == nil || *tag.UserID == userID
+}
+
+// fakeItemRepository implements only what the tag service uses.
+type fakeItemRepository struct {
+	item.Repository
+	items map[uint64]uint64
+}
+
+func (r fakeItemRepository) IsItemInList(listID uint64, itemID uint64) (bool, error) {
+	return r.items[itemID] == listID, nil
+}
+
+func newServiceToTest() (tag.Service, *fakeRepository) {
+	john, mary := uint64(1), uint64(2)
+	camping, work := uint64(10), uint64(20)
+	repository := &fakeRepository{
+		tags: map[uint64]*models.Tag{
+			1: {ID: 1, Name: "outdoor", ListID: &camping},
+			2: {ID: 2, Name: "urgent", ListID: &work},
+			3: {ID: 3, Name: "john's", UserID: &john},
+			4: {ID: 4, Name: "mary's", UserID: &mary},
+			5: {ID: 5, Name: "food", ListID: &camping},
+		},
+		itemTags: map[uint64][]uint64{7: {1, 4}},
+	}
+	authorizationService := testutil.AuthorizationService{
+		ListRoles: map[uint64]models.ListRole{10: models.ListRoleAdmin, 20: models.ListRoleViewer},
+	}
+	items := fakeItemRepository{items: map[uint64]uint64{7: 10, 8: 20}}
+	return tag.NewService(repository, items, authorizationService), repository
+}
+
+func tagIDs(tags *[]models.Tag) []uint64 {
+	ids := []uint64{}
+	for _, tag := range *tags {
+		ids = append(ids, tag.ID)
+	}
+	return ids
+}
+
+func TestSetItemTagsKeepsPersonalTagsOfOtherUsers(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	tags, err := service.SetItemTags(10, 7, []uint64{3, 5, 3}, 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{5, 3}, tagIDs(tags))
+	assert.ElementsMatch(t, []uint64{4, 5, 3}, repository.itemTags[7])
+}
+
+func TestSetItemTagsAcceptsTheTagsOfTheItem(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	// A tag of another list is left on the item, for instance by an older
+	// transfer, and is returned with the item.
+	repository.itemTags[7] = append(repository.itemTags[7], 2)
+	current, err := repository.GetItemTags(7, 1)
+	assert.Nil(t, err)
+
+	tags, err := service.SetItemTags(10, 7, tagIDs(current), 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, tagIDs(current), tagIDs(tags))
+}
+
+func TestSetItemTagsRejectsTagsOfOthers(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	for _, id := range []uint64{2, 4, 99} {
+		_, err := service.SetItemTags(10, 7, []uint64{id}, 1)
+
+		_, ok := err.(*apperrors.NotFoundError)
+		assert.True(t, ok, "expected a not found error for tag %d, got %v", id, err)
+	}
+	assert.Equal(t, []uint64{1, 4}, repository.itemTags[7])
+}
+
+func TestSetItemTagsChecksListAndItem(t *testing.T) {
+	service, _ := newServiceToTest()
+
+	_, err := service.SetItemTags(20, 8, []uint64{2}, 1)
+	_, ok := err.(*apperrors.ForbiddenError)
+	assert.True(t, ok, "expected a forbidden error, got %v", err)
+
+	_, err = service.SetItemTags(10, 8, []uint64{1}, 1)
+	assert.Equal(t, apperrors.NewItemNotFoundInListError(8, 10), err)
+}
+
+func TestPersonalTagsOfOtherUsersAreNotFound(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	_, err := service.Update(&models.Tag{ID: 4, Name: "mine"}, 1)
+	_, ok := err.(*apperrors.NotFoundError)
+	assert.True(t, ok, "expected a not found error, got %v", err)
+
+	err = service.Delete(4, 1)
+	_, ok = err.(*apperrors.NotFoundError)
+	assert.True(t, ok, "expected a not found error, got %v", err)
+	assert.Contains(t, repository.tags, uint64(4))
+}
+
+func TestMergeRequiresTheSameScope(t *testing.T) {
+	service, _ := newServiceToTest()
+
+	_, err := service.Merge(1, 3, 1)
+	_, ok := err.(*apperrors.ObjectInInvalidStateError)
+	assert.True(t, ok, "expected an invalid state error, got %v", err)
+
+	// List tags are managed by the list admins only.
+	_, err = service.Merge(2, 1, 1)
+	_, ok = err.(*apperrors.ForbiddenError)
+	assert.True(t, ok, "expected a forbidden error, got %v", err)
+}
@@ -0,0 +1,75 @@
+package trash
+
+import (
+	"net/http"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Handler interface {
+		Get(c *gin.Context)
+		RestoreList(c *gin.Context)
+		RestoreItem(c *gin.Context)
+	}
+
+	handler struct {
+		service Service
+	}
+)
+
+func NewHandler(service Service) Handler {
+	return &handler{service}
+}
+
+func (h handler) Get(c *gin.Context) {
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	trash, err := h.service.Get(userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewTrashDTO(trash))
+}
+
+func (h handler) RestoreList(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	err = h.service.RestoreList(listID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h handler) RestoreItem(c *gin.Context) {
+	itemID, err := utils.GetIDFromRequest(c, "item_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	err = h.service.RestoreItem(itemID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
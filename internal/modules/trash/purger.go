@@ -0,0 +1,78 @@
+package trash
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/clock"
+)
+
+type (
+	// Purger periodically removes for good what has been in the trash for
+	// longer than the retention period.
+	Purger interface {
+		Start()
+		Stop()
+		RunOnce() (int, error)
+	}
+
+	purger struct {
+		repository Repository
+		clock      clock.Clock
+		retention  time.Duration
+		interval   time.Duration
+		stop       chan struct{}
+		wait       sync.WaitGroup
+	}
+)
+
+func NewPurger(repository Repository, clock clock.Clock, retention time.Duration, interval time.Duration) Purger {
+	return &purger{
+		repository: repository,
+		clock:      clock,
+		retention:  retention,
+		interval:   interval,
+		stop:       make(chan struct{}),
+	}
+}
+
+func (p *purger) Start() {
+	p.wait.Add(1)
+	go func() {
+		defer p.wait.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := p.RunOnce(); err != nil {
+					log.Printf("Error purging trash: %s\n", err.Error())
+				}
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (p *purger) Stop() {
+	close(p.stop)
+	p.wait.Wait()
+}
+
+// RunOnce purges the lists and items deleted before the retention period and
+// returns how many were removed. Nothing is purged while another instance is
+// purging the trash.
+func (p *purger) RunOnce() (int, error) {
+	purged := 0
+	_, err := p.repository.WithPurgeLock(func() error {
+		var err error
+		deletedBefore := p.clock.Now().UTC().Add(-p.retention)
+		purged, err = p.repository.Purge(deletedBefore)
+		return err
+	})
+	return purged, err
+}
@@ -0,0 +1,34 @@
+package trash_test
+
+import (
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/trash"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgerRemovesWhatIsOlderThanRetention(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	repository := newRepositoryToTest()
+	purger := trash.NewPurger(repository, &testutil.Clock{Current: now}, 30*24*time.Hour, time.Hour)
+
+	purged, err := purger.RunOnce()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, purged)
+	assert.Equal(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), repository.deletedBefore)
+}
+
+func TestPurgerSkipsWhileAnotherInstancePurges(t *testing.T) {
+	repository := newRepositoryToTest()
+	repository.purgeLock.HeldElsewhere = true
+	purger := trash.NewPurger(repository, &testutil.Clock{Current: time.Now()}, 30*24*time.Hour, time.Hour)
+
+	purged, err := purger.RunOnce()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, purged)
+	assert.True(t, repository.deletedBefore.IsZero())
+}
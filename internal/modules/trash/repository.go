@@ -0,0 +1,213 @@
+package trash
+
+import (
+	"errors"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// purgeLockName names the database lock held by the instance purging the
+// trash.
+const purgeLockName = "list_manager_trash_purger"
+
+type (
+	Repository interface {
+		GetDeletedLists(userID uint64) (*[]models.List, error)
+		GetDeletedItems(userID uint64) (*[]models.Item, error)
+		GetDeletedList(id uint64) (*models.List, error)
+		GetDeletedItem(id uint64) (*models.Item, error)
+		RestoreList(id uint64) error
+		RestoreItem(id uint64) error
+		Purge(deletedBefore time.Time) (int, error)
+		WithPurgeLock(fn func() error) (bool, error)
+	}
+
+	repository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+// GetDeletedLists returns the lists owned by the user that are in the trash.
+func (r repository) GetDeletedLists(userID uint64) (*[]models.List, error) {
+	var lists []models.List
+	err := r.db.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&lists).
+		Error
+	return &lists, err
+}
+
+// GetDeletedItems returns the items deleted on their own, by the user or from
+// a list owned by the user. Items deleted along with a list or a parent item
+// are restored with it, so they are not listed here.
+func (r repository) GetDeletedItems(userID uint64) (*[]models.Item, error) {
+	var items []models.Item
+	err := r.db.Unscoped().
+		Select("item.*").
+		Joins("JOIN list ON list.id = item.list_id").
+		Where("item.deleted_at IS NOT NULL AND list.deleted_at IS NULL").
+		Where("item.deleted_with_list = ? AND item.deleted_with_parent = ?", false, false).
+		Where(r.db.Where("item.deleted_by = ?", userID).Or("list.user_id = ?", userID)).
+		Order("item.deleted_at DESC").
+		Find(&items).
+		Error
+	return &items, err
+}
+
+func (r repository) GetDeletedList(id uint64) (*models.List, error) {
+	var list models.List
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&list, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &list, err
+}
+
+func (r repository) GetDeletedItem(id uint64) (*models.Item, error) {
+	var item models.Item
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&item, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &item, err
+}
+
+// RestoreList takes the list out of the trash together with the items that
+// were deleted with it.
+func (r repository) RestoreList(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&models.Item{}).
+			Where("list_id = ? AND deleted_with_list = ?", id, true).
+			Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": nil, "deleted_with_list": false}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&models.List{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": nil}).
+			Error
+	})
+}
+
+// RestoreItem takes the item out of the trash together with the sub-items
+// that were deleted with it.
+func (r repository) RestoreItem(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&models.Item{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": nil}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		parentIDs := []uint64{id}
+		for depth := 1; depth < constants.MaxItemDepth && len(parentIDs) > 0; depth++ {
+			var childIDs []uint64
+			err = tx.Unscoped().Model(&models.Item{}).
+				Where("parent_id IN ? AND deleted_with_parent = ?", parentIDs, true).
+				Pluck("id", &childIDs).
+				Error
+			if err != nil {
+				return err
+			}
+
+			if len(childIDs) > 0 {
+				err = tx.Unscoped().Model(&models.Item{}).
+					Where("id IN ?", childIDs).
+					Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": nil, "deleted_with_parent": false}).
+					Error
+				if err != nil {
+					return err
+				}
+			}
+			parentIDs = childIDs
+		}
+
+		return nil
+	})
+}
+
+// Purge permanently removes the lists and items that went to the trash before
+// the given time, and returns how many lists and items were removed. The
+// items of a purged list are removed with it.
+func (r repository) Purge(deletedBefore time.Time) (int, error) {
+	purged := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var listIDs []uint64
+		err := tx.Unscoped().Model(&models.List{}).
+			Where("deleted_at < ?", deletedBefore).
+			Pluck("id", &listIDs).
+			Error
+		if err != nil {
+			return err
+		}
+
+		items := tx.Unscoped().Model(&models.Item{}).Where("deleted_at < ?", deletedBefore)
+		if len(listIDs) > 0 {
+			items = items.Or("list_id IN ?", listIDs)
+		}
+
+		var itemIDs []uint64
+		if err = items.Pluck("id", &itemIDs).Error; err != nil {
+			return err
+		}
+
+		if len(itemIDs) > 0 {
+			if err = tx.Delete(&models.ItemReminder{}, "item_id IN ?", itemIDs).Error; err != nil {
+				return err
+			}
+
+			if err = tx.Unscoped().Delete(&models.Item{}, itemIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(listIDs) > 0 {
+			if err = tx.Delete(&models.ListMember{}, "list_id IN ?", listIDs).Error; err != nil {
+				return err
+			}
+
+			if err = tx.Delete(&models.Tag{}, "list_id IN ?", listIDs).Error; err != nil {
+				return err
+			}
+
+			if err = tx.Delete(&models.CustomField{}, "list_id IN ?", listIDs).Error; err != nil {
+				return err
+			}
+
+			if err = tx.Delete(&models.ListStatus{}, "list_id IN ?", listIDs).Error; err != nil {
+				return err
+			}
+
+			if err = tx.Unscoped().Delete(&models.List{}, listIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		purged = len(listIDs) + len(itemIDs)
+		return nil
+	})
+	return purged, err
+}
+
+// WithPurgeLock runs fn unless another instance is purging the trash, and
+// tells whether it ran.
+func (r repository) WithPurgeLock(fn func() error) (bool, error) {
+	return utils.WithLock(r.db, purgeLockName, 0, fn)
+}
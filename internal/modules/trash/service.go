@@ -0,0 +1,145 @@
+package trash
+
+import (
+	"log"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/authorization"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+type (
+	Service interface {
+		Get(userID uint64) (*models.Trash, error)
+		RestoreList(listID uint64, userID uint64) error
+		RestoreItem(itemID uint64, userID uint64) error
+	}
+
+	service struct {
+		repository           Repository
+		authorizationService authorization.Service
+	}
+)
+
+func NewService(repository Repository, authorizationService authorization.Service) Service {
+	return &service{repository, authorizationService}
+}
+
+func (s service) Get(userID uint64) (*models.Trash, error) {
+	lists, err := s.repository.GetDeletedLists(userID)
+	if err != nil {
+		log.Printf("Error getting deleted lists: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting deleted lists")
+	}
+
+	items, err := s.repository.GetDeletedItems(userID)
+	if err != nil {
+		log.Printf("Error getting deleted items: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting deleted items")
+	}
+
+	restorable, err := s.keepRestorableItems(*items, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Trash{Lists: *lists, Items: restorable}, nil
+}
+
+// keepRestorableItems leaves out the items of the lists the user can no
+// longer edit, such as the lists the user was removed from.
+func (s service) keepRestorableItems(items []models.Item, userID uint64) ([]models.Item, error) {
+	canEdit := map[uint64]bool{}
+	restorable := []models.Item{}
+	for _, item := range items {
+		allowed, ok := canEdit[item.ListID]
+		if !ok {
+			role, err := s.authorizationService.GetListRole(item.ListID, userID)
+			if err != nil {
+				return nil, err
+			}
+			allowed = role.Includes(models.ListRoleEditor)
+			canEdit[item.ListID] = allowed
+		}
+
+		if allowed {
+			restorable = append(restorable, item)
+		}
+	}
+
+	return restorable, nil
+}
+
+// RestoreList takes a list out of the trash. Only the owner of the list can
+// restore it.
+func (s service) RestoreList(listID uint64, userID uint64) error {
+	list, err := s.repository.GetDeletedList(listID)
+	if err != nil {
+		log.Printf("Error getting deleted list: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error getting deleted list")
+	}
+
+	if list == nil {
+		return apperrors.NewNotFoundError("list", listID)
+	}
+
+	if list.Owner == nil || *list.Owner != userID {
+		return apperrors.NewForbiddenError("only the owner can restore the list")
+	}
+
+	err = s.repository.RestoreList(listID)
+	if err != nil {
+		log.Printf("Error restoring list: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error restoring list")
+	}
+
+	return nil
+}
+
+// RestoreItem takes an item and its sub-items out of the trash. The list and
+// the parent of the item must not be in the trash and the user must be able
+// to edit the list.
+func (s service) RestoreItem(itemID uint64, userID uint64) error {
+	item, err := s.repository.GetDeletedItem(itemID)
+	if err != nil {
+		log.Printf("Error getting deleted item: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error getting deleted item")
+	}
+
+	if item == nil {
+		return apperrors.NewNotFoundError("item", itemID)
+	}
+
+	if item.DeletedWithList {
+		return apperrors.NewObjectInInvalidStateError("item was deleted with its list, restore the list instead")
+	}
+
+	if item.DeletedWithParent {
+		return apperrors.NewObjectInInvalidStateError("item was deleted with its parent, restore the parent instead")
+	}
+
+	if item.ParentID != nil {
+		parent, err := s.repository.GetDeletedItem(*item.ParentID)
+		if err != nil {
+			log.Printf("Error getting deleted item: %s\n", err.Error())
+			return apperrors.NewInternalError("Internal error getting deleted item")
+		}
+
+		if parent != nil {
+			return apperrors.NewObjectInInvalidStateError("parent item is in the trash, restore it first")
+		}
+	}
+
+	err = s.authorizationService.CheckListPermission(item.ListID, userID, models.ListRoleEditor)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.RestoreItem(itemID)
+	if err != nil {
+		log.Printf("Error restoring item: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error restoring item")
+	}
+
+	return nil
+}
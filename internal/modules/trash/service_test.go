@@ -0,0 +1,135 @@
+package trash_test
+
+import (
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/trash"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRepository struct {
+	lists         map[uint64]*models.List
+	items         map[uint64]*models.Item
+	restored      []uint64
+	deletedBefore time.Time
+	purgeLock     testutil.Lock
+}
+
+func (r *fakeRepository) GetDeletedLists(userID uint64) (*[]models.List, error) {
+	return &[]models.List{}, nil
+}
+
+// GetDeletedItems returns the items deleted on their own, ordered by id.
+func (r *fakeRepository) GetDeletedItems(userID uint64) (*[]models.Item, error) {
+	items := []models.Item{}
+	for id := uint64(100); id <= 104; id++ {
+		if item, ok := r.items[id]; ok && !item.DeletedWithList && !item.DeletedWithParent {
+			items = append(items, *item)
+		}
+	}
+	return &items, nil
+}
+
+func (r *fakeRepository) GetDeletedList(id uint64) (*models.List, error) {
+	return r.lists[id], nil
+}
+
+func (r *fakeRepository) GetDeletedItem(id uint64) (*models.Item, error) {
+	return r.items[id], nil
+}
+
+func (r *fakeRepository) RestoreList(id uint64) error {
+	r.restored = append(r.restored, id)
+	return nil
+}
+
+func (r *fakeRepository) RestoreItem(id uint64) error {
+	r.restored = append(r.restored, id)
+	return nil
+}
+
+func (r *fakeRepository) Purge(deletedBefore time.Time) (int, error) {
+	r.deletedBefore = deletedBefore
+	return 3, nil
+}
+
+func (r *fakeRepository) WithPurgeLock(fn func() error) (bool, error) {
+	return r.purgeLock.With(fn)
+}
+
+func newRepositoryToTest() *fakeRepository {
+	owner := uint64(1)
+	parentID := uint64(100)
+	return &fakeRepository{
+		lists: map[uint64]*models.List{
+			10: {ID: 10, Owner: &owner},
+		},
+		items: map[uint64]*models.Item{
+			100: {ID: 100, ListID: 20},
+			101: {ID: 101, ListID: 10, DeletedWithList: true},
+			102: {ID: 102, ListID: 30},
+			103: {ID: 103, ListID: 20, ParentID: &parentID},
+			104: {ID: 104, ListID: 20, ParentID: &parentID, DeletedWithParent: true},
+		},
+	}
+}
+
+func newServiceToTest(repository trash.Repository) trash.Service {
+	return trash.NewService(repository, testutil.AuthorizationService{
+		ListRoles: map[uint64]models.ListRole{20: models.ListRoleEditor, 30: models.ListRoleViewer},
+	})
+}
+
+func TestGetLeavesOutTheItemsOfListsTheUserCannotEdit(t *testing.T) {
+	repository := newRepositoryToTest()
+
+	found, err := newServiceToTest(repository).Get(2)
+
+	// The user only views the list of item 102.
+	assert.Nil(t, err)
+	assert.Len(t, found.Items, 2)
+	assert.Equal(t, uint64(100), found.Items[0].ID)
+	assert.Equal(t, uint64(103), found.Items[1].ID)
+}
+
+func TestRestoreListByOwner(t *testing.T) {
+	repository := newRepositoryToTest()
+
+	err := newServiceToTest(repository).RestoreList(10, 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{10}, repository.restored)
+}
+
+func TestRestoreListErrors(t *testing.T) {
+	repository := newRepositoryToTest()
+	service := newServiceToTest(repository)
+
+	assert.IsType(t, &apperrors.ForbiddenError{}, service.RestoreList(10, 2))
+	assert.IsType(t, &apperrors.NotFoundError{}, service.RestoreList(11, 1))
+	assert.Empty(t, repository.restored)
+}
+
+func TestRestoreItem(t *testing.T) {
+	repository := newRepositoryToTest()
+	service := newServiceToTest(repository)
+
+	assert.Nil(t, service.RestoreItem(100, 2))
+	assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, service.RestoreItem(101, 1))
+	assert.IsType(t, &apperrors.ForbiddenError{}, service.RestoreItem(102, 2))
+	assert.IsType(t, &apperrors.NotFoundError{}, service.RestoreItem(105, 2))
+	assert.Equal(t, []uint64{100}, repository.restored)
+}
+
+func TestRestoreSubItem(t *testing.T) {
+	repository := newRepositoryToTest()
+	service := newServiceToTest(repository)
+
+	assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, service.RestoreItem(103, 2))
+	assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, service.RestoreItem(104, 2))
+	assert.Empty(t, repository.restored)
+}
@@ -16,6 +16,7 @@ type (
 	Handler interface {
 		Save(c *gin.Context)
 		Get(c *gin.Context)
+		GetAll(c *gin.Context)
 		Update(c *gin.Context)
 	}
 
@@ -37,7 +38,7 @@ func (h handler) Save(c *gin.Context) {
 
 	err = h.service.Save(user)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, models.NewHttpError(err))
+		apperrors.HandleServiceError(c, err)
 		return
 	}
 
@@ -60,6 +61,16 @@ func (h handler) Get(c *gin.Context) {
 	c.IndentedJSON(http.StatusOK, user)
 }
 
+func (h handler) GetAll(c *gin.Context) {
+	users, err := h.service.GetAll()
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, users)
+}
+
 func (h handler) Update(c *gin.Context) {
 	id, err := utils.GetIDFromRequest(c, "id")
 	if err != nil {
@@ -11,7 +11,9 @@ type (
 	Repository interface {
 		Save(user *models.User) error
 		Get(id uint64) (*models.User, error)
+		GetAll() (*[]models.User, error)
 		GetByLogin(login string) (*models.User, error)
+		GetByEmail(email string) (*models.User, error)
 		Update(user *models.User) error
 		Exists(id uint64) (bool, error)
 		ExistsByLogin(login string) (bool, error)
@@ -41,6 +43,12 @@ func (r repository) Get(id uint64) (*models.User, error) {
 	return &user, err
 }
 
+func (r repository) GetAll() (*[]models.User, error) {
+	var users []models.User
+	err := r.db.Order("id").Find(&users).Error
+	return &users, err
+}
+
 func (r repository) GetByLogin(login string) (*models.User, error) {
 	var user models.User
 	err := r.db.Where(&models.User{Login: login}).First(&user).Error
@@ -52,6 +60,19 @@ func (r repository) GetByLogin(login string) (*models.User, error) {
 	return &user, err
 }
 
+// GetByEmail returns the user with the email. The email is not unique, so
+// nil is returned when several users have it.
+func (r repository) GetByEmail(email string) (*models.User, error) {
+	var users []models.User
+	err := r.db.Where("email = ?", email).Order("id").Limit(2).Find(&users).Error
+
+	if err != nil || len(users) != 1 {
+		return nil, err
+	}
+
+	return &users[0], nil
+}
+
 func (r repository) Update(user *models.User) error {
 	return r.db.Model(user).Updates(user).Error
 }
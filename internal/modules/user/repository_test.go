@@ -62,7 +62,7 @@ func (s UserRepositorySaveTestSuite) TestUserSaveSuccess() {
 
 	s.sqlMock.ExpectBegin()
 	s.sqlMock.ExpectExec(defaultExpectedInsertQuery).
-		WithArgs(user.Name, user.Email, user.Login, user.Password).
+		WithArgs(user.Name, user.Email, user.Login, user.Password, user.Role).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	s.sqlMock.ExpectCommit()
 
@@ -79,7 +79,7 @@ func (s UserRepositorySaveTestSuite) TestUserSaveError() {
 
 	s.sqlMock.ExpectBegin()
 	s.sqlMock.ExpectExec(defaultExpectedInsertQuery).
-		WithArgs(user.Name, user.Email, user.Login, user.Password).
+		WithArgs(user.Name, user.Email, user.Login, user.Password, user.Role).
 		WillReturnError(expectedError)
 	s.sqlMock.ExpectRollback()
 
@@ -102,9 +102,9 @@ func (s UserRepositoryGetTestSuite) TestUserGetSuccess() {
 	user := getUserToTest()
 
 	rows := sqlmock.NewRows([]string{
-		"id", "name", "email", "login", "password",
+		"id", "name", "email", "login", "password", "role",
 	}).AddRow(
-		user.ID, user.Name, user.Email, user.Login, user.Password,
+		user.ID, user.Name, user.Email, user.Login, user.Password, user.Role,
 	)
 	s.sqlMock.ExpectQuery(defaultExpectedGetQuery).WithArgs(user.ID).WillReturnRows(rows)
 
@@ -141,13 +141,30 @@ func (s UserRepositoryGetTestSuite) TestUserGetError() {
 	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
 }
 
+func (s UserRepositoryGetTestSuite) TestUserGetAllSuccess() {
+	user := getUserToTest()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "name", "email", "login", "password", "role",
+	}).AddRow(
+		user.ID, user.Name, user.Email, user.Login, user.Password, user.Role,
+	)
+	s.sqlMock.ExpectQuery(defaultExpectedGetQuery).WillReturnRows(rows)
+
+	dbUsers, err := s.repository.GetAll()
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, &[]models.User{user}, dbUsers)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
 func (s UserRepositoryGetTestSuite) TestUserGetByLoginSuccess() {
 	user := getUserToTest()
 
 	rows := sqlmock.NewRows([]string{
-		"id", "name", "email", "login", "password",
+		"id", "name", "email", "login", "password", "role",
 	}).AddRow(
-		user.ID, user.Name, user.Email, user.Login, user.Password,
+		user.ID, user.Name, user.Email, user.Login, user.Password, user.Role,
 	)
 	s.sqlMock.ExpectQuery(defaultExpectedGetQuery).WithArgs(user.Login).WillReturnRows(rows)
 
@@ -158,6 +175,38 @@ func (s UserRepositoryGetTestSuite) TestUserGetByLoginSuccess() {
 	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
 }
 
+func (s UserRepositoryGetTestSuite) TestUserGetByEmailSuccess() {
+	user := getUserToTest()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "name", "email", "login", "password", "role",
+	}).AddRow(
+		user.ID, user.Name, user.Email, user.Login, user.Password, user.Role,
+	)
+	s.sqlMock.ExpectQuery(defaultExpectedGetQuery).WithArgs(user.Email).WillReturnRows(rows)
+
+	dbUser, err := s.repository.GetByEmail(user.Email)
+
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, &user, dbUser)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
+func (s UserRepositoryGetTestSuite) TestUserGetByEmailSharedByUsers() {
+	user := getUserToTest()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "login", "password", "role"}).
+		AddRow(user.ID, user.Name, user.Email, user.Login, user.Password, user.Role).
+		AddRow(user.ID+1, user.Name, user.Email, "other", user.Password, user.Role)
+	s.sqlMock.ExpectQuery(defaultExpectedGetQuery).WithArgs(user.Email).WillReturnRows(rows)
+
+	dbUser, err := s.repository.GetByEmail(user.Email)
+
+	assert.Nil(s.t, err)
+	assert.Nil(s.t, dbUser)
+	assert.Nil(s.t, s.sqlMock.ExpectationsWereMet())
+}
+
 func (s UserRepositoryGetTestSuite) TestUserGetByLoginNotFoundError() {
 	login := "test"
 
@@ -197,7 +246,7 @@ func (s UserRepositoryUpdateTestSuite) TestUserUpdateSuccess() {
 
 	s.sqlMock.ExpectBegin()
 	s.sqlMock.ExpectExec(defaultExpectedUpdateQuery).
-		WithArgs(user.Name, user.Email, user.Login, user.Password, user.ID).
+		WithArgs(user.Name, user.Email, user.Login, user.Password, user.Role, user.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	s.sqlMock.ExpectCommit()
 
@@ -214,7 +263,7 @@ func (s UserRepositoryUpdateTestSuite) TestUserUpdateError() {
 
 	s.sqlMock.ExpectBegin()
 	s.sqlMock.ExpectExec(defaultExpectedUpdateQuery).
-		WithArgs(user.Name, user.Email, user.Login, user.Password, user.ID).
+		WithArgs(user.Name, user.Email, user.Login, user.Password, user.Role, user.ID).
 		WillReturnError(expectedError)
 	s.sqlMock.ExpectRollback()
 
@@ -318,6 +367,7 @@ func getUserToTest() models.User {
 		Email:    "test",
 		Login:    "test",
 		Password: "test",
+		Role:     models.UserRoleMember,
 	}
 	return user
 }
@@ -328,5 +378,6 @@ func getUserToSaveTest() models.User {
 		Email:    "test",
 		Login:    "test",
 		Password: "test",
+		Role:     models.UserRoleMember,
 	}
 }
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"fmt"
 	"log"
 
 	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
@@ -11,6 +12,7 @@ type (
 	Service interface {
 		Save(user *models.User) error
 		Get(id uint64) (*models.User, error)
+		GetAll() (*[]models.User, error)
 		Update(id uint64, user *models.User) (*models.User, error)
 	}
 
@@ -24,6 +26,14 @@ func NewService(repository Repository) Service {
 }
 
 func (s service) Save(user *models.User) error {
+	if user.Role == "" {
+		user.Role = models.UserRoleMember
+	}
+
+	if !user.Role.IsValid() {
+		return apperrors.NewObjectInInvalidStateError(fmt.Sprintf("invalid user role '%s'", user.Role))
+	}
+
 	err := s.checkIfLoginExists(user.Login)
 	if err != nil {
 		return err
@@ -60,6 +70,20 @@ func (s service) Get(id uint64) (*models.User, error) {
 	return user, nil
 }
 
+func (s service) GetAll() (*[]models.User, error) {
+	users, err := s.repository.GetAll()
+	if err != nil {
+		log.Printf("Error getting users: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting users")
+	}
+
+	for i := range *users {
+		(*users)[i].Password = ""
+	}
+
+	return users, nil
+}
+
 func (s service) Update(id uint64, user *models.User) (*models.User, error) {
 	dbUser, err := s.Get(id)
 	if err != nil {
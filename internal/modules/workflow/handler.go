@@ -0,0 +1,182 @@
+package workflow
+
+import (
+	"errors"
+	"net/http"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	Handler interface {
+		Save(c *gin.Context)
+		GetByList(c *gin.Context)
+		Update(c *gin.Context)
+		Reorder(c *gin.Context)
+		Delete(c *gin.Context)
+	}
+
+	handler struct {
+		service Service
+	}
+)
+
+func NewHandler(service Service) Handler {
+	return &handler{service}
+}
+
+func (h handler) Save(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	statusDTO, err := getStatusFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	status := models.ListStatus{
+		ListID:   listID,
+		Name:     statusDTO.Name,
+		Done:     statusDTO.Done,
+		WIPLimit: statusDTO.WIPLimit,
+		Next:     statusDTO.Next,
+	}
+	err = h.service.Save(&status, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, models.NewListStatusDTO(&status))
+}
+
+func (h handler) GetByList(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	statuses, err := h.service.GetListStatuses(listID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewListStatusesDTO(statuses))
+}
+
+// Update changes the status. The transitions are only replaced when next is
+// informed.
+func (h handler) Update(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	statusID, err := utils.GetIDFromRequest(c, "status_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	statusDTO, err := getStatusFromRequest(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	status := models.ListStatus{
+		ID:       statusID,
+		ListID:   listID,
+		Name:     statusDTO.Name,
+		Done:     statusDTO.Done,
+		WIPLimit: statusDTO.WIPLimit,
+		Next:     statusDTO.Next,
+	}
+	updated, err := h.service.Update(&status, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewListStatusDTO(updated))
+}
+
+// Reorder sets the order of the workflow to the order of status_ids.
+func (h handler) Reorder(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	var orderDTO models.StatusOrderDTO
+	if err = c.BindJSON(&orderDTO); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(errors.New("invalid body request format")))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	statuses, err := h.service.Reorder(listID, orderDTO.StatusIDs, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, models.NewListStatusesDTO(statuses))
+}
+
+func (h handler) Delete(c *gin.Context) {
+	listID, err := utils.GetIDFromRequest(c, "list_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	statusID, err := utils.GetIDFromRequest(c, "status_id")
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, models.NewHttpError(err))
+		return
+	}
+
+	userID := c.GetUint64(constants.CtxUserKey)
+
+	err = h.service.Delete(listID, statusID, userID)
+	if err != nil {
+		apperrors.HandleServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func getStatusFromRequest(c *gin.Context) (*models.ListStatusDTO, error) {
+	var status models.ListStatusDTO
+
+	if err := c.BindJSON(&status); err != nil {
+		return nil, errors.New("invalid body request format")
+	}
+
+	if status.Name == "" {
+		return nil, errors.New("name cannot be empty")
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,176 @@
+package workflow
+
+import (
+	"errors"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"gorm.io/gorm"
+)
+
+type (
+	Repository interface {
+		Save(status *models.ListStatus) error
+		Get(id uint64) (*models.ListStatus, error)
+		GetListStatuses(listID uint64) (*[]models.ListStatus, error)
+		Update(status *models.ListStatus) error
+		Reorder(ids []uint64) error
+		Delete(id uint64) error
+		CountItems(id uint64) (int64, error)
+	}
+
+	repository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+// Save appends the status to the end of the workflow of its list. The items
+// of the list without a status go to the new status when it matches their
+// completion, so a list that adopts a workflow keeps all its items on it.
+func (r repository) Save(status *models.ListStatus) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&models.ListStatus{}).Where("list_id = ?", status.ListID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		status.Position = int(count)
+		if err = tx.Create(status).Error; err != nil {
+			return err
+		}
+
+		completion := "completed_at IS NULL"
+		if status.Done {
+			completion = "completed_at IS NOT NULL"
+		}
+
+		err = tx.Model(&models.Item{}).
+			Where("list_id = ? AND status_id IS NULL AND "+completion, status.ListID).
+			Update("status_id", status.ID).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return replaceTransitions(tx, status)
+	})
+}
+
+func (r repository) Get(id uint64) (*models.ListStatus, error) {
+	var status models.ListStatus
+	err := r.db.First(&status, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []models.ListStatus{status}
+	err = r.fillTransitions(statuses)
+	return &statuses[0], err
+}
+
+// GetListStatuses returns the statuses of the list in the workflow order,
+// with their transitions.
+func (r repository) GetListStatuses(listID uint64) (*[]models.ListStatus, error) {
+	var statuses []models.ListStatus
+	err := r.db.Where("list_id = ?", listID).Order("position").Find(&statuses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.fillTransitions(statuses)
+	return &statuses, err
+}
+
+// Update writes the name, the WIP limit and the done flag of the status and,
+// when Next is not nil, replaces its transitions.
+func (r repository) Update(status *models.ListStatus) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(status).Select("name", "wip_limit", "done").Updates(status).Error
+		if err != nil {
+			return err
+		}
+
+		return replaceTransitions(tx, status)
+	})
+}
+
+// Reorder gives the statuses the positions of their order in ids.
+func (r repository) Reorder(ids []uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for position, id := range ids {
+			err := tx.Model(&models.ListStatus{}).Where("id = ?", id).Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Delete removes the status and its transitions. Items in the trash that
+// were in the status are left without a status.
+func (r repository) Delete(id uint64) error {
+	return r.db.Delete(&models.ListStatus{}, id).Error
+}
+
+func (r repository) CountItems(id uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Item{}).Where("status_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r repository) fillTransitions(statuses []models.ListStatus) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, len(statuses))
+	for i, status := range statuses {
+		ids[i] = status.ID
+	}
+
+	var transitions []models.StatusTransition
+	err := r.db.Where("from_status_id IN ?", ids).Order("to_status_id").Find(&transitions).Error
+	if err != nil {
+		return err
+	}
+
+	next := make(map[uint64][]uint64)
+	for _, transition := range transitions {
+		next[transition.FromStatusID] = append(next[transition.FromStatusID], transition.ToStatusID)
+	}
+
+	for i := range statuses {
+		statuses[i].Next = next[statuses[i].ID]
+	}
+	return nil
+}
+
+// replaceTransitions swaps the transitions from the status by the ones in
+// Next. Nil Next means nothing changed.
+func replaceTransitions(tx *gorm.DB, status *models.ListStatus) error {
+	if status.Next == nil {
+		return nil
+	}
+
+	err := tx.Delete(&models.StatusTransition{}, "from_status_id = ?", status.ID).Error
+	if err != nil || len(status.Next) == 0 {
+		return err
+	}
+
+	transitions := make([]models.StatusTransition, len(status.Next))
+	for i, id := range status.Next {
+		transitions[i] = models.StatusTransition{FromStatusID: status.ID, ToStatusID: id}
+	}
+	return tx.Create(&transitions).Error
+}
@@ -0,0 +1,258 @@
+package workflow
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/authorization"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+type (
+	Service interface {
+		Save(status *models.ListStatus, userID uint64) error
+		GetListStatuses(listID uint64, userID uint64) (*[]models.ListStatus, error)
+		Update(status *models.ListStatus, userID uint64) (*models.ListStatus, error)
+		Reorder(listID uint64, ids []uint64, userID uint64) (*[]models.ListStatus, error)
+		Delete(listID uint64, statusID uint64, userID uint64) error
+	}
+
+	service struct {
+		repository           Repository
+		authorizationService authorization.Service
+	}
+)
+
+func NewService(repository Repository, authorizationService authorization.Service) Service {
+	return &service{repository, authorizationService}
+}
+
+// Save appends a status to the workflow of the list, which requires the
+// admin role on the list.
+func (s service) Save(status *models.ListStatus, userID uint64) error {
+	err := s.authorizationService.CheckListPermission(status.ListID, userID, models.ListRoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := s.getListStatuses(status.ListID)
+	if err != nil {
+		return err
+	}
+
+	if len(*statuses) >= constants.MaxListStatuses {
+		return apperrors.NewObjectInInvalidStateError(
+			fmt.Sprintf("a list cannot have more than %d statuses", constants.MaxListStatuses),
+		)
+	}
+
+	err = s.validateStatus(status, statuses)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.Save(status)
+	if err != nil {
+		log.Printf("Error saving status: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error saving status")
+	}
+
+	return nil
+}
+
+func (s service) GetListStatuses(listID uint64, userID uint64) (*[]models.ListStatus, error) {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getListStatuses(listID)
+}
+
+// Update renames the status and changes its WIP limit, its done flag and,
+// when informed, the statuses items can move to from it. The done flag of a
+// status with items cannot change, as it would leave their completion out of
+// sync.
+func (s service) Update(status *models.ListStatus, userID uint64) (*models.ListStatus, error) {
+	stored, err := s.getManagedStatus(status.ListID, status.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.Done != stored.Done {
+		count, err := s.repository.CountItems(stored.ID)
+		if err != nil {
+			log.Printf("Error counting status items: %s\n", err.Error())
+			return nil, apperrors.NewInternalError("Internal error counting status items")
+		}
+
+		if count > 0 {
+			return nil, apperrors.NewObjectInInvalidStateError("the done flag of a status with items cannot be changed")
+		}
+	}
+
+	statuses, err := s.getListStatuses(status.ListID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.validateStatus(status, statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.Update(status)
+	if err != nil {
+		log.Printf("Error updating status: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error updating status")
+	}
+
+	if status.Next == nil {
+		status.Next = stored.Next
+	}
+	status.Position = stored.Position
+
+	return status, nil
+}
+
+// Reorder changes the order of the workflow. All statuses of the list must
+// be informed.
+func (s service) Reorder(listID uint64, ids []uint64, userID uint64) (*[]models.ListStatus, error) {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := s.getListStatuses(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint64]bool, len(*statuses))
+	for _, status := range *statuses {
+		byID[status.ID] = true
+	}
+
+	informed := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		if !byID[id] {
+			return nil, apperrors.NewNotFoundError("status", id)
+		}
+		informed[id] = true
+	}
+
+	if len(informed) != len(ids) || len(ids) != len(*statuses) {
+		return nil, apperrors.NewObjectInInvalidStateError("status_ids must have every status of the list once")
+	}
+
+	err = s.repository.Reorder(ids)
+	if err != nil {
+		log.Printf("Error reordering statuses: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error reordering statuses")
+	}
+
+	return s.getListStatuses(listID)
+}
+
+// Delete removes a status without items from the workflow.
+func (s service) Delete(listID uint64, statusID uint64, userID uint64) error {
+	_, err := s.getManagedStatus(listID, statusID, userID)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.repository.CountItems(statusID)
+	if err != nil {
+		log.Printf("Error counting status items: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error counting status items")
+	}
+
+	if count > 0 {
+		return apperrors.NewObjectInInvalidStateError("status has items, move them to another status first")
+	}
+
+	err = s.repository.Delete(statusID)
+	if err != nil {
+		log.Printf("Error deleting status: %s\n", err.Error())
+		return apperrors.NewInternalError("Internal error deleting status")
+	}
+
+	return nil
+}
+
+func (s service) getListStatuses(listID uint64) (*[]models.ListStatus, error) {
+	statuses, err := s.repository.GetListStatuses(listID)
+	if err != nil {
+		log.Printf("Error getting list statuses: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting list statuses")
+	}
+
+	return statuses, nil
+}
+
+func (s service) getManagedStatus(listID uint64, statusID uint64, userID uint64) (*models.ListStatus, error) {
+	err := s.authorizationService.CheckListPermission(listID, userID, models.ListRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := s.repository.Get(statusID)
+	if err != nil {
+		log.Printf("Error getting status: %s\n", err.Error())
+		return nil, apperrors.NewInternalError("Internal error getting status")
+	}
+
+	if status == nil || status.ListID != listID {
+		return nil, apperrors.NewNotFoundError("status", statusID)
+	}
+
+	return status, nil
+}
+
+// validateStatus normalizes the name of the status, checks that it is unique
+// in the list and that the transitions lead to other statuses of the list.
+func (s service) validateStatus(status *models.ListStatus, statuses *[]models.ListStatus) error {
+	status.Name = strings.TrimSpace(status.Name)
+	if status.Name == "" {
+		return apperrors.NewObjectInInvalidStateError("name cannot be empty")
+	}
+
+	if len(status.Name) > 64 {
+		return apperrors.NewObjectInInvalidStateError("name cannot exceed 64 characters")
+	}
+
+	if status.WIPLimit != nil && *status.WIPLimit < 1 {
+		return apperrors.NewObjectInInvalidStateError("wip_limit must be at least 1")
+	}
+
+	inList := make(map[uint64]bool, len(*statuses))
+	for _, other := range *statuses {
+		inList[other.ID] = true
+		if other.ID != status.ID && strings.EqualFold(other.Name, status.Name) {
+			return apperrors.NewObjectInInvalidStateError(fmt.Sprintf("status '%s' already exists", status.Name))
+		}
+	}
+
+	if status.Next == nil {
+		return nil
+	}
+
+	next := make([]uint64, 0, len(status.Next))
+	added := make(map[uint64]bool, len(status.Next))
+	for _, id := range status.Next {
+		if id == status.ID || !inList[id] {
+			return apperrors.NewNotFoundError("status", id)
+		}
+
+		if !added[id] {
+			added[id] = true
+			next = append(next, id)
+		}
+	}
+	status.Next = next
+
+	return nil
+}
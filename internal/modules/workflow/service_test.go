@@ -0,0 +1,163 @@
+package workflow_test
+
+import (
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/modules/workflow"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRepository struct {
+	statuses  []models.ListStatus
+	itemCount map[uint64]int64
+	saved     []models.ListStatus
+	order     []uint64
+	deleted   []uint64
+}
+
+func (r *fakeRepository) Save(status *models.ListStatus) error {
+	status.ID = uint64(len(r.statuses) + 1)
+	r.saved = append(r.saved, *status)
+	return nil
+}
+
+func (r *fakeRepository) Get(id uint64) (*models.ListStatus, error) {
+	for _, status := range r.statuses {
+		if status.ID == id {
+			return &status, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) GetListStatuses(listID uint64) (*[]models.ListStatus, error) {
+	statuses := []models.ListStatus{}
+	for _, status := range r.statuses {
+		if status.ListID == listID {
+			statuses = append(statuses, status)
+		}
+	}
+	return &statuses, nil
+}
+
+func (r *fakeRepository) Update(status *models.ListStatus) error {
+	r.saved = append(r.saved, *status)
+	return nil
+}
+
+func (r *fakeRepository) Reorder(ids []uint64) error {
+	r.order = ids
+	return nil
+}
+
+func (r *fakeRepository) Delete(id uint64) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func (r *fakeRepository) CountItems(id uint64) (int64, error) {
+	return r.itemCount[id], nil
+}
+
+func newServiceToTest() (workflow.Service, *fakeRepository) {
+	repository := &fakeRepository{
+		statuses: []models.ListStatus{
+			{ID: 1, ListID: 10, Name: "To do", Next: []uint64{2}},
+			{ID: 2, ListID: 10, Name: "Doing", Next: []uint64{1, 3}},
+			{ID: 3, ListID: 10, Name: "Done", Done: true},
+			{ID: 4, ListID: 20, Name: "Backlog"},
+		},
+		itemCount: map[uint64]int64{2: 1},
+	}
+	authorizationService := testutil.AuthorizationService{
+		ListRoles: map[uint64]models.ListRole{10: models.ListRoleOwner, 20: models.ListRoleEditor},
+	}
+	return workflow.NewService(repository, authorizationService), repository
+}
+
+func TestSaveValidatesStatus(t *testing.T) {
+	service, repository := newServiceToTest()
+	limit := 0
+	invalid := []models.ListStatus{
+		{ListID: 10, Name: "doing"},
+		{ListID: 10, Name: "Review", WIPLimit: &limit},
+	}
+
+	for _, status := range invalid {
+		err := service.Save(&status, 1)
+
+		assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, err, status.Name)
+	}
+
+	err := service.Save(&models.ListStatus{ListID: 10, Name: "Review", Next: []uint64{4}}, 1)
+	assert.IsType(t, &apperrors.NotFoundError{}, err)
+
+	err = service.Save(&models.ListStatus{ListID: 20, Name: "Review"}, 1)
+	assert.IsType(t, &apperrors.ForbiddenError{}, err)
+
+	assert.Empty(t, repository.saved)
+}
+
+func TestSaveDeduplicatesTransitions(t *testing.T) {
+	service, repository := newServiceToTest()
+	status := models.ListStatus{ListID: 10, Name: " Review ", Next: []uint64{3, 2, 3}}
+
+	err := service.Save(&status, 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Review", repository.saved[0].Name)
+	assert.Equal(t, []uint64{3, 2}, repository.saved[0].Next)
+}
+
+func TestUpdateDoneFlagOfStatusWithItems(t *testing.T) {
+	service, _ := newServiceToTest()
+
+	_, err := service.Update(&models.ListStatus{ID: 2, ListID: 10, Name: "Doing", Done: true}, 1)
+	assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, err)
+
+	updated, err := service.Update(&models.ListStatus{ID: 1, ListID: 10, Name: "Ready", Done: true}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{2}, updated.Next)
+}
+
+func TestUpdateCannotTransitionToItself(t *testing.T) {
+	service, _ := newServiceToTest()
+
+	_, err := service.Update(&models.ListStatus{ID: 1, ListID: 10, Name: "To do", Next: []uint64{1}}, 1)
+
+	assert.IsType(t, &apperrors.NotFoundError{}, err)
+}
+
+func TestReorderRequiresEveryStatus(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	_, err := service.Reorder(10, []uint64{3, 1}, 1)
+	assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, err)
+
+	_, err = service.Reorder(10, []uint64{3, 1, 1}, 1)
+	assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, err)
+
+	_, err = service.Reorder(10, []uint64{3, 1, 4}, 1)
+	assert.IsType(t, &apperrors.NotFoundError{}, err)
+
+	_, err = service.Reorder(10, []uint64{3, 1, 2}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{3, 1, 2}, repository.order)
+}
+
+func TestDeleteStatusWithItems(t *testing.T) {
+	service, repository := newServiceToTest()
+
+	err := service.Delete(10, 2, 1)
+	assert.IsType(t, &apperrors.ObjectInInvalidStateError{}, err)
+
+	err = service.Delete(10, 4, 1)
+	assert.IsType(t, &apperrors.NotFoundError{}, err)
+
+	err = service.Delete(10, 3, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{3}, repository.deleted)
+}
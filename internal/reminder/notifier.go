@@ -0,0 +1,154 @@
+package reminder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+type (
+	// Notifier delivers a reminder to the assignees and watchers of the item.
+	Notifier interface {
+		Notify(notification *models.ReminderNotification) error
+	}
+
+	logNotifier struct{}
+
+	smtpNotifier struct {
+		addr string
+		from string
+		auth smtp.Auth
+	}
+
+	webhookNotifier struct {
+		url    string
+		client *http.Client
+	}
+)
+
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func NewSMTPNotifier(addr string, from string, auth smtp.Auth) Notifier {
+	return &smtpNotifier{addr, from, auth}
+}
+
+func NewWebhookNotifier(url string, client *http.Client) Notifier {
+	return &webhookNotifier{url, client}
+}
+
+// NewNotifierFromEnv builds the notifier selected by REMINDER_NOTIFIER (log,
+// smtp or webhook), defaulting to log.
+func NewNotifierFromEnv() (Notifier, error) {
+	switch os.Getenv("REMINDER_NOTIFIER") {
+	case "", "log":
+		return NewLogNotifier(), nil
+	case "smtp":
+		addr := os.Getenv("SMTP_ADDR")
+		if addr == "" {
+			return nil, fmt.Errorf("SMTP_ADDR is required by the smtp notifier")
+		}
+
+		var auth smtp.Auth
+		if username := os.Getenv("SMTP_USERNAME"); username != "" {
+			host := strings.Split(addr, ":")[0]
+			auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+		}
+		return NewSMTPNotifier(addr, os.Getenv("SMTP_FROM"), auth), nil
+	case "webhook":
+		url := os.Getenv("REMINDER_WEBHOOK_URL")
+		if url == "" {
+			return nil, fmt.Errorf("REMINDER_WEBHOOK_URL is required by the webhook notifier")
+		}
+		return NewWebhookNotifier(url, &http.Client{Timeout: 10 * time.Second}), nil
+	default:
+		return nil, fmt.Errorf("unknown reminder notifier %s", os.Getenv("REMINDER_NOTIFIER"))
+	}
+}
+
+func (n logNotifier) Notify(notification *models.ReminderNotification) error {
+	log.Printf("Reminder: item %d '%s' of list %d is due at %s (users %s)\n",
+		notification.ItemID, notification.Title, notification.ListID,
+		formatDueAt(notification), strings.Join(recipientEmails(notification), ", "))
+	return nil
+}
+
+// Notify sends a single e-mail to all the recipients, so a failed delivery
+// is retried for all of them. Reminders without recipients are skipped.
+func (n smtpNotifier) Notify(notification *models.ReminderNotification) error {
+	if len(notification.Recipients) == 0 {
+		return nil
+	}
+
+	// Titles and addresses are user input, so line breaks are removed to keep
+	// them from adding headers to the message.
+	title := stripLineBreaks(notification.Title)
+	emails := make([]string, len(notification.Recipients))
+	addresses := make([]string, len(notification.Recipients))
+	for i, recipient := range notification.Recipients {
+		emails[i] = stripLineBreaks(recipient.Email)
+		addresses[i] = (&mail.Address{Name: stripLineBreaks(recipient.Name), Address: emails[i]}).String()
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("From: %s\r\n", n.from))
+	message.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(addresses, ", ")))
+	message.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Reminder: "+title)))
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	message.WriteString("Hello,\r\n\r\n")
+	message.WriteString(fmt.Sprintf("The item \"%s\" is due at %s.\r\n", title, formatDueAt(notification)))
+
+	return smtp.SendMail(n.addr, n.auth, n.from, emails, []byte(message.String()))
+}
+
+func (n webhookNotifier) Notify(notification *models.ReminderNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	response, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+func recipientEmails(notification *models.ReminderNotification) []string {
+	emails := make([]string, len(notification.Recipients))
+	for i, recipient := range notification.Recipients {
+		emails[i] = recipient.Email
+	}
+	return emails
+}
+
+func stripLineBreaks(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+func formatDueAt(notification *models.ReminderNotification) string {
+	location := time.UTC
+	if notification.DueTimezone != nil {
+		if loaded, err := time.LoadLocation(*notification.DueTimezone); err == nil {
+			location = loaded
+		}
+	}
+	return notification.DueAt.In(location).Format(time.RFC1123)
+}
@@ -0,0 +1,124 @@
+package reminder_test
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/reminder"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// startSMTPStandIn accepts a single SMTP session on a local port and sends
+// the received message data to the returned channel.
+func startSMTPStandIn(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	messages := make(chan string, 1)
+	go func() {
+		defer listener.Close()
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		write("220 localhost ESMTP stand-in")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					messages <- data.String()
+					write("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				write("250 localhost")
+			case command == "DATA":
+				inData = true
+				write("354 End data with <CR><LF>.<CR><LF>")
+			case command == "QUIT":
+				write("221 Bye")
+				return
+			default:
+				write("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), messages
+}
+
+func TestSMTPNotifierSendsReminderEmail(t *testing.T) {
+	addr, messages := startSMTPStandIn(t)
+	timezone := "America/Sao_Paulo"
+	notifier := reminder.NewSMTPNotifier(addr, "reminders@listmanager.local", nil)
+
+	err := notifier.Notify(&models.ReminderNotification{
+		ReminderID:  1,
+		Title:       "Pay the bills",
+		DueAt:       time.Date(2026, 1, 10, 15, 0, 0, 0, time.UTC),
+		DueTimezone: &timezone,
+		Recipients: []models.ReminderRecipient{
+			{UserID: 1, Name: "Test", Email: "test@test.com"},
+			{UserID: 2, Name: "Other", Email: "other@test.com"},
+		},
+	})
+	assert.Nil(t, err)
+
+	select {
+	case message := <-messages:
+		assert.Contains(t, message, `To: "Test" <test@test.com>, "Other" <other@test.com>`)
+		assert.Contains(t, message, "Subject: Reminder: Pay the bills")
+		assert.Contains(t, message, "12:00:00 -03")
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP stand-in did not receive the message")
+	}
+}
+
+func TestSMTPNotifierEscapesHeaders(t *testing.T) {
+	addr, messages := startSMTPStandIn(t)
+	notifier := reminder.NewSMTPNotifier(addr, "reminders@listmanager.local", nil)
+
+	err := notifier.Notify(&models.ReminderNotification{
+		ReminderID: 1,
+		Title:      "Café\r\nBcc: attacker@evil.com",
+		DueAt:      time.Date(2026, 1, 10, 15, 0, 0, 0, time.UTC),
+		Recipients: []models.ReminderRecipient{
+			{UserID: 1, Name: "Test\r\nCc: attacker@evil.com", Email: "test@test.com"},
+		},
+	})
+	assert.Nil(t, err)
+
+	select {
+	case message := <-messages:
+		headers := message[:strings.Index(message, "\r\n\r\n")]
+		for _, header := range strings.Split(headers, "\r\n") {
+			assert.False(t, strings.HasPrefix(header, "Bcc:") || strings.HasPrefix(header, "Cc:"), "injected header %q", header)
+		}
+		assert.Contains(t, headers, "Subject: =?utf-8?q?Reminder:_Caf=C3=A9__Bcc:_attacker@evil.com?=")
+		assert.Contains(t, headers, "To: \"Test  Cc: attacker@evil.com\" <test@test.com>")
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP stand-in did not receive the message")
+	}
+}
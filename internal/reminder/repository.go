@@ -0,0 +1,96 @@
+package reminder
+
+import (
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// lockName names the database lock held by the instance sending reminders.
+const lockName = "list_manager_reminder_scheduler"
+
+type (
+	Repository interface {
+		GetDueReminders(now time.Time, limit int) (*[]models.ReminderNotification, error)
+		MarkSent(reminderID uint64, sentAt time.Time) error
+		WithLock(fn func() error) (bool, error)
+	}
+
+	repository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+// GetDueReminders returns the pending reminders of open items, not in the
+// trash, whose time has come, with the assignees and watchers of the item as
+// recipients. Only the users who can still see the list are notified: its
+// owner and members, or anyone for a list without owner.
+func (r repository) GetDueReminders(now time.Time, limit int) (*[]models.ReminderNotification, error) {
+	var notifications []models.ReminderNotification
+	err := r.db.Table("item_reminder").
+		Select(`item_reminder.id as reminder_id, item.id as item_id, item.list_id, item.title,
+			item.due_at, item.due_timezone, item_reminder.remind_at`).
+		Joins("JOIN item ON item.id = item_reminder.item_id").
+		Where("item_reminder.sent_at IS NULL AND item_reminder.remind_at <= ?", now).
+		Where("item.completed_at IS NULL AND item.deleted_at IS NULL").
+		Order("item_reminder.remind_at").
+		Limit(limit).
+		Scan(&notifications).
+		Error
+	if err != nil || len(notifications) == 0 {
+		return &notifications, err
+	}
+
+	itemIDs := make([]uint64, len(notifications))
+	for i, notification := range notifications {
+		itemIDs[i] = notification.ItemID
+	}
+
+	// A user both assigned to and watching an item is notified once.
+	var recipients []models.ReminderRecipient
+	err = r.db.Raw(
+		`SELECT recipient.item_id, user.id as user_id, user.name, user.email
+		FROM (
+			SELECT item_id, user_id FROM item_assignee WHERE item_id IN ?
+			UNION
+			SELECT item_id, user_id FROM item_watcher WHERE item_id IN ?
+		) recipient
+		JOIN user ON user.id = recipient.user_id
+		JOIN item ON item.id = recipient.item_id
+		JOIN list ON list.id = item.list_id
+		LEFT JOIN list_member ON list_member.list_id = item.list_id AND list_member.user_id = recipient.user_id
+		WHERE list.user_id IS NULL OR list.user_id = recipient.user_id OR list_member.user_id IS NOT NULL
+		ORDER BY recipient.item_id, user.id`,
+		itemIDs, itemIDs,
+	).Scan(&recipients).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byItem := make(map[uint64][]models.ReminderRecipient, len(itemIDs))
+	for _, recipient := range recipients {
+		byItem[recipient.ItemID] = append(byItem[recipient.ItemID], recipient)
+	}
+
+	for i := range notifications {
+		notifications[i].Recipients = byItem[notifications[i].ItemID]
+	}
+
+	return &notifications, nil
+}
+
+func (r repository) MarkSent(reminderID uint64, sentAt time.Time) error {
+	return r.db.Model(&models.ItemReminder{ID: reminderID}).Update("sent_at", sentAt).Error
+}
+
+// WithLock runs fn unless another instance is sending reminders, and tells
+// whether it ran, so each reminder is sent by a single instance.
+func (r repository) WithLock(fn func() error) (bool, error) {
+	return utils.WithLock(r.db, lockName, 0, fn)
+}
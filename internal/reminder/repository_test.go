@@ -0,0 +1,79 @@
+package reminder_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/reminder"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func newRepositoryToTest(t *testing.T) (reminder.Repository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+	})
+	assert.Nil(t, err)
+
+	return reminder.NewRepository(gdb), mock
+}
+
+func TestGetDueRemindersNotifiesOnlyWhoCanSeeTheList(t *testing.T) {
+	repository, mock := newRepositoryToTest(t)
+	now := time.Date(2022, 9, 20, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `item_reminder` JOIN item ON item.id = item_reminder.item_id")).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"reminder_id", "item_id", "list_id", "title", "remind_at"}).
+			AddRow(1, 7, 10, "Buy milk", now))
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"LEFT JOIN list_member ON list_member.list_id = item.list_id AND list_member.user_id = recipient.user_id "+
+			"WHERE list.user_id IS NULL OR list.user_id = recipient.user_id OR list_member.user_id IS NOT NULL",
+	)).
+		WithArgs(7, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"item_id", "user_id", "name", "email"}).
+			AddRow(7, 2, "Mary", "mary@test.com"))
+
+	notifications, err := repository.GetDueReminders(now, 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []models.ReminderRecipient{{ItemID: 7, UserID: 2, Name: "Mary", Email: "mary@test.com"}},
+		(*notifications)[0].Recipients)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestWithLockRunsOnlyWithTheLock(t *testing.T) {
+	repository, mock := newRepositoryToTest(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, ?)")).
+		WithArgs("list_manager_reminder_scheduler", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("SELECT RELEASE_LOCK(?)")).
+		WithArgs("list_manager_reminder_scheduler").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, ?)")).
+		WithArgs("list_manager_reminder_scheduler", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(0))
+
+	runs := 0
+	for _, expected := range []bool{true, false} {
+		ran, err := repository.WithLock(func() error {
+			runs++
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, expected, ran)
+	}
+	assert.Equal(t, 1, runs)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,108 @@
+package reminder
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/clock"
+)
+
+const batchSize = 100
+
+type (
+	// Scheduler periodically delivers the reminders whose time has come.
+	Scheduler interface {
+		Start()
+		Stop()
+		RunOnce() (int, error)
+	}
+
+	scheduler struct {
+		repository Repository
+		notifier   Notifier
+		clock      clock.Clock
+		interval   time.Duration
+		stop       chan struct{}
+		wait       sync.WaitGroup
+	}
+)
+
+func NewScheduler(repository Repository, notifier Notifier, clock clock.Clock, interval time.Duration) Scheduler {
+	return &scheduler{
+		repository: repository,
+		notifier:   notifier,
+		clock:      clock,
+		interval:   interval,
+		stop:       make(chan struct{}),
+	}
+}
+
+func (s *scheduler) Start() {
+	s.wait.Add(1)
+	go func() {
+		defer s.wait.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.RunOnce(); err != nil {
+					log.Printf("Error sending reminders: %s\n", err.Error())
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *scheduler) Stop() {
+	close(s.stop)
+	s.wait.Wait()
+}
+
+// RunOnce sends every reminder due at the current time and returns how many
+// were delivered. A failed delivery is logged and retried on the next run.
+// Nothing is sent while another instance is sending the reminders.
+func (s *scheduler) RunOnce() (int, error) {
+	sent := 0
+	_, err := s.repository.WithLock(func() error {
+		var err error
+		sent, err = s.send()
+		return err
+	})
+	return sent, err
+}
+
+func (s *scheduler) send() (int, error) {
+	sent := 0
+	for {
+		now := s.clock.Now().UTC()
+		notifications, err := s.repository.GetDueReminders(now, batchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		delivered := 0
+		for i := range *notifications {
+			notification := &(*notifications)[i]
+			if err = s.notifier.Notify(notification); err != nil {
+				log.Printf("Error notifying reminder %d: %s\n", notification.ReminderID, err.Error())
+				continue
+			}
+
+			if err = s.repository.MarkSent(notification.ReminderID, now); err != nil {
+				return sent, err
+			}
+			delivered++
+		}
+
+		sent += delivered
+		if len(*notifications) < batchSize || delivered == 0 {
+			return sent, nil
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package reminder_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/reminder"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRepository struct {
+	reminders []models.ReminderNotification
+	sent      map[uint64]time.Time
+	lock      testutil.Lock
+}
+
+func (r *fakeRepository) GetDueReminders(now time.Time, limit int) (*[]models.ReminderNotification, error) {
+	due := []models.ReminderNotification{}
+	for _, reminder := range r.reminders {
+		if _, ok := r.sent[reminder.ReminderID]; ok || reminder.RemindAt.After(now) {
+			continue
+		}
+		due = append(due, reminder)
+	}
+	return &due, nil
+}
+
+func (r *fakeRepository) MarkSent(reminderID uint64, sentAt time.Time) error {
+	r.sent[reminderID] = sentAt
+	return nil
+}
+
+func (r *fakeRepository) WithLock(fn func() error) (bool, error) {
+	return r.lock.With(fn)
+}
+
+type fakeNotifier struct {
+	notified []uint64
+	failFor  uint64
+}
+
+func (n *fakeNotifier) Notify(notification *models.ReminderNotification) error {
+	if notification.ReminderID == n.failFor {
+		return errors.New("delivery failed")
+	}
+	n.notified = append(n.notified, notification.ReminderID)
+	return nil
+}
+
+func TestSchedulerSendsOnlyDueReminders(t *testing.T) {
+	start := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	clock := &testutil.Clock{Current: start}
+	repository := &fakeRepository{
+		reminders: []models.ReminderNotification{
+			{ReminderID: 1, RemindAt: start.Add(-time.Minute)},
+			{ReminderID: 2, RemindAt: start.Add(time.Hour)},
+		},
+		sent: map[uint64]time.Time{},
+	}
+	notifier := &fakeNotifier{}
+	scheduler := reminder.NewScheduler(repository, notifier, clock, time.Minute)
+
+	sent, err := scheduler.RunOnce()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []uint64{1}, notifier.notified)
+	assert.Equal(t, start, repository.sent[1])
+
+	clock.Current = start.Add(2 * time.Hour)
+	sent, err = scheduler.RunOnce()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []uint64{1, 2}, notifier.notified)
+}
+
+func TestSchedulerKeepsFailedReminderPending(t *testing.T) {
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	repository := &fakeRepository{
+		reminders: []models.ReminderNotification{{ReminderID: 1, RemindAt: now}},
+		sent:      map[uint64]time.Time{},
+	}
+	notifier := &fakeNotifier{failFor: 1}
+	scheduler := reminder.NewScheduler(repository, notifier, &testutil.Clock{Current: now}, time.Minute)
+
+	sent, err := scheduler.RunOnce()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, repository.sent)
+}
+
+func TestSchedulerSkipsWhileAnotherInstanceSends(t *testing.T) {
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	repository := &fakeRepository{
+		reminders: []models.ReminderNotification{{ReminderID: 1, RemindAt: now}},
+		sent:      map[uint64]time.Time{},
+		lock:      testutil.Lock{HeldElsewhere: true},
+	}
+	notifier := &fakeNotifier{}
+	scheduler := reminder.NewScheduler(repository, notifier, &testutil.Clock{Current: now}, time.Minute)
+
+	sent, err := scheduler.RunOnce()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, notifier.notified)
+}
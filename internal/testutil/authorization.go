@@ -0,0 +1,29 @@
+package testutil
+
+import (
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/apperrors"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+// AuthorizationService resolves the list roles from memory. Roles holds the
+// role of each user by list and ListRoles the role, by list, of the users
+// not found in Roles.
+type AuthorizationService struct {
+	Roles     map[uint64]map[uint64]models.ListRole
+	ListRoles map[uint64]models.ListRole
+}
+
+func (s AuthorizationService) GetListRole(listID uint64, userID uint64) (models.ListRole, error) {
+	if role, ok := s.Roles[listID][userID]; ok {
+		return role, nil
+	}
+	return s.ListRoles[listID], nil
+}
+
+func (s AuthorizationService) CheckListPermission(listID uint64, userID uint64, required models.ListRole) error {
+	role, _ := s.GetListRole(listID, userID)
+	if !role.Includes(required) {
+		return apperrors.NewForbiddenError("forbidden")
+	}
+	return nil
+}
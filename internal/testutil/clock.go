@@ -0,0 +1,18 @@
+// Package testutil holds the fakes shared by the tests of several packages.
+package testutil
+
+import "time"
+
+// Clock is a clock that only moves when told to.
+type Clock struct {
+	Current time.Time
+}
+
+func (c *Clock) Now() time.Time {
+	return c.Current
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.Current = c.Current.Add(d)
+}
@@ -0,0 +1,17 @@
+package testutil
+
+// Lock stands for a named database lock shared by the instances of the
+// application.
+type Lock struct {
+	// HeldElsewhere tells that another instance holds the lock.
+	HeldElsewhere bool
+}
+
+// With runs fn unless another instance holds the lock, and tells whether fn
+// ran.
+func (l *Lock) With(fn func() error) (bool, error) {
+	if l.HeldElsewhere {
+		return false, nil
+	}
+	return true, fn()
+}
@@ -0,0 +1,61 @@
+package testutil
+
+import "git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+
+// SSORepository keeps the SSO applications in memory. Saved records every
+// application saved or updated.
+type SSORepository struct {
+	Apps  []models.SSOApplication
+	Saved []models.SSOApplication
+}
+
+func (r *SSORepository) Save(app *models.SSOApplication) error {
+	app.ID = uint64(len(r.Apps) + 1)
+	r.Apps = append(r.Apps, *app)
+	r.Saved = append(r.Saved, *app)
+	return nil
+}
+
+func (r *SSORepository) Get(id uint64) (*models.SSOApplication, error) {
+	return r.find(func(app models.SSOApplication) bool { return app.ID == id }), nil
+}
+
+func (r *SSORepository) GetAll() (*[]models.SSOApplication, error) {
+	apps := append([]models.SSOApplication{}, r.Apps...)
+	return &apps, nil
+}
+
+func (r *SSORepository) GetByIssuer(issuer string) (*models.SSOApplication, error) {
+	return r.find(func(app models.SSOApplication) bool { return app.Issuer == issuer }), nil
+}
+
+func (r *SSORepository) Update(app *models.SSOApplication) error {
+	for i := range r.Apps {
+		if r.Apps[i].ID == app.ID {
+			r.Apps[i] = *app
+		}
+	}
+	r.Saved = append(r.Saved, *app)
+	return nil
+}
+
+func (r *SSORepository) Delete(id uint64) error {
+	apps := []models.SSOApplication{}
+	for _, app := range r.Apps {
+		if app.ID != id {
+			apps = append(apps, app)
+		}
+	}
+	r.Apps = apps
+	return nil
+}
+
+func (r *SSORepository) find(matches func(app models.SSOApplication) bool) *models.SSOApplication {
+	for _, app := range r.Apps {
+		if matches(app) {
+			stored := app
+			return &stored
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package testutil
+
+import (
+	"sync"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+)
+
+// UserRepository keeps the users in memory, by login.
+type UserRepository struct {
+	Users map[string]*models.User
+	mu    sync.Mutex
+}
+
+// NewUserRepository returns a repository with a member for each login, with
+// ids starting at 1.
+func NewUserRepository(logins ...string) *UserRepository {
+	users := make(map[string]*models.User, len(logins))
+	for i, login := range logins {
+		users[login] = &models.User{ID: uint64(i + 1), Login: login, Role: models.UserRoleMember}
+	}
+	return &UserRepository{Users: users}
+}
+
+func (r *UserRepository) Save(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.ID = uint64(len(r.Users) + 1)
+	r.Users[user.Login] = user
+	return nil
+}
+
+func (r *UserRepository) Get(id uint64) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user := r.find(id); user != nil {
+		stored := *user
+		return &stored, nil
+	}
+	return nil, nil
+}
+
+func (r *UserRepository) GetAll() (*[]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := make([]models.User, 0, len(r.Users))
+	for _, user := range r.Users {
+		users = append(users, *user)
+	}
+	return &users, nil
+}
+
+func (r *UserRepository) GetByLogin(login string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.Users[login]
+	if !ok {
+		return nil, nil
+	}
+	stored := *user
+	return &stored, nil
+}
+
+// GetByEmail finds nothing when the email is not unique, as the database.
+func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found *models.User
+	for _, user := range r.Users {
+		if user.Email == email {
+			if found != nil {
+				return nil, nil
+			}
+			stored := *user
+			found = &stored
+		}
+	}
+	return found, nil
+}
+
+func (r *UserRepository) Update(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored := r.find(user.ID); stored != nil {
+		*stored = *user
+	}
+	return nil
+}
+
+func (r *UserRepository) Exists(id uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.find(id) != nil, nil
+}
+
+func (r *UserRepository) ExistsByLogin(login string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.Users[login] != nil, nil
+}
+
+func (r *UserRepository) find(id uint64) *models.User {
+	for _, user := range r.Users {
+		if user.ID == id {
+			return user
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package clock
+
+import "time"
+
+// Clock abstracts the current time so time based rules can be tested with a
+// fixed or manually advanced clock.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func New() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
@@ -3,6 +3,87 @@ package constants
 import "time"
 
 const (
-	TokenExpirationTime = 1 * time.Hour
-	CtxUserKey          = "user.id"
+	TokenExpirationTime        = 1 * time.Hour
+	RefreshTokenExpirationTime = 30 * 24 * time.Hour
+	CtxUserKey                 = "user.id"
+	CtxClaimsKey               = "user.claims"
+	JWKSCacheTime              = 15 * time.Minute
+	// RevokedTokensCacheTime is how long an instance may accept an access
+	// token revoked by another instance.
+	RevokedTokensCacheTime = 10 * time.Second
+)
+
+const (
+	// SSOTokenMaxLifetime is the longest an SSO token may be valid, which
+	// bounds how long its jti is kept to detect replays.
+	SSOTokenMaxLifetime = 5 * time.Minute
+	// SSOTokenLeeway is the clock skew tolerated on the SSO token times.
+	SSOTokenLeeway = 30 * time.Second
+	// MinSSOSecretLength is the minimum length of the secret of an SSO
+	// application that signs its tokens with HMAC.
+	MinSSOSecretLength = 32
+)
+
+const (
+	// OIDCLoginLifetime is how long the user has to log in at the OpenID
+	// Connect provider.
+	OIDCLoginLifetime = 10 * time.Minute
+	// OIDCLinkLifetime is how long the user has to confirm the link of a
+	// provider identity to the account.
+	OIDCLinkLifetime = 10 * time.Minute
+	// OIDCStateCookie keeps the state of the login in the browser that
+	// started it, so the callback is only accepted from that browser.
+	OIDCStateCookie = "oidc_state"
+	// MaxOIDCLoginSuffix is how many numbered logins are tried before a
+	// random one when the login of a new OIDC user is taken.
+	MaxOIDCLoginSuffix = 20
+)
+
+const (
+	// MFAChallengeLifetime is how long the user has to inform the second
+	// factor after the password.
+	MFAChallengeLifetime = 5 * time.Minute
+	// MaxMFAAttempts is how many wrong codes end an MFA challenge.
+	MaxMFAAttempts = 5
+	// MFARecoveryCodeCount is how many recovery codes are generated.
+	MFARecoveryCodeCount = 10
+	// MFARecoveryCodeSize is how many random bytes make a recovery code.
+	MFARecoveryCodeSize = 10
+	// MinMFARecoverySecretSize is the minimum length of the secret that keys
+	// the hashes of the recovery codes.
+	MinMFARecoverySecretSize = 32
+)
+
+const (
+	// MinJWTKeySecretSize is the minimum length of the secret that encrypts
+	// the private signing keys stored in the database.
+	MinJWTKeySecretSize = 32
+	// SigningKeyLockName names the database lock held while an instance
+	// rotates the signing keys.
+	SigningKeyLockName = "list_manager_signing_key_rotation"
+	// SigningKeyLockTimeout is how long an instance waits for another one to
+	// rotate the signing keys.
+	SigningKeyLockTimeout = 30 * time.Second
+)
+
+const (
+	// LoginAttemptWindow is how long failed logins are remembered after the
+	// last one.
+	LoginAttemptWindow = time.Hour
+	// FreeLoginAttempts is how many failed logins of a login are allowed
+	// before each attempt must wait for an exponential backoff, starting at
+	// LoginBackoffBase and limited to MaxLoginBackoff.
+	FreeLoginAttempts = 3
+	// FreeIPLoginAttempts is the same for the failed logins of an IP, which
+	// may be shared by many users.
+	FreeIPLoginAttempts = 20
+	LoginBackoffBase    = time.Second
+	MaxLoginBackoff     = 5 * time.Minute
+	// LoginLockoutThreshold is how many failed logins lock the login for
+	// LoginLockoutDuration.
+	LoginLockoutThreshold = 10
+	LoginLockoutDuration  = 15 * time.Minute
+	// TrustedLoginIPLifetime is how long an IP the user logged in from is
+	// not locked by the failed logins of the user made elsewhere.
+	TrustedLoginIPLifetime = 30 * 24 * time.Hour
 )
@@ -0,0 +1,10 @@
+package constants
+
+// MaxListFields is the maximum number of custom fields of a list.
+const MaxListFields = 50
+
+// MaxFieldOptions is the maximum number of options of a select field.
+const MaxFieldOptions = 100
+
+// MaxFieldTextLength is the maximum length of the value of a text field.
+const MaxFieldTextLength = 1024
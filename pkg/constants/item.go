@@ -0,0 +1,8 @@
+package constants
+
+// MaxBulkItems is the maximum number of items handled by a bulk operation.
+const MaxBulkItems = 100
+
+// MaxItemDepth is the maximum number of levels of an item tree, counting the
+// root item.
+const MaxItemDepth = 5
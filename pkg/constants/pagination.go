@@ -0,0 +1,6 @@
+package constants
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
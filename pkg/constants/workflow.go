@@ -0,0 +1,4 @@
+package constants
+
+// MaxListStatuses is the maximum number of statuses of a list workflow.
+const MaxListStatuses = 20
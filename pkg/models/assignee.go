@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// ItemAssignee is a user responsible for an item. An item can have any
+// number of assignees.
+type ItemAssignee struct {
+	ItemID    uint64 `gorm:"primaryKey;autoIncrement:false"`
+	UserID    uint64 `gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt time.Time
+}
+
+// ItemWatcher is a user following an item, who is also notified of its
+// reminders.
+type ItemWatcher struct {
+	ItemID    uint64 `gorm:"primaryKey;autoIncrement:false"`
+	UserID    uint64 `gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt time.Time
+}
+
+// AssignedFilter restricts the listing of the items assigned to a user
+// across the lists the user can access.
+type AssignedFilter struct {
+	UserID uint64
+	Status ItemStatus
+	Sort   string
+	Order  SortOrder
+	Limit  int
+	Cursor *PageCursor
+}
+
+type UserItemPage struct {
+	Items      []UserItem
+	Total      int64
+	NextCursor *string
+}
+
+// AssignedSortFields are the fields accepted by the sort query parameter of
+// the assigned items listing.
+var AssignedSortFields = map[string]bool{
+	"id":       true,
+	"title":    true,
+	"priority": true,
+}
@@ -1,28 +1,203 @@
 package models
 
+import "time"
+
 type ListDTO struct {
 	ListParam tinyList `json:"list"`
 }
 
 type tinyList struct {
-	ID    uint64 `json:"id"`
-	Title string `json:"title"`
+	ID          uint64        `json:"id"`
+	Title       string        `json:"title"`
+	Description *string       `json:"description"`
+	Color       *string       `json:"color"`
+	Icon        *string       `json:"icon"`
+	Progress    *ListProgress `json:"progress,omitempty"`
+}
+
+type ListSummaryDTO struct {
+	ID         uint64       `json:"id"`
+	Title      string       `json:"title"`
+	Color      *string      `json:"color"`
+	Icon       *string      `json:"icon"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ItemsCount int64        `json:"items_count"`
+	Progress   ListProgress `json:"progress"`
+}
+
+type ListsDTO struct {
+	Lists      []ListSummaryDTO `json:"lists"`
+	Total      int64            `json:"total"`
+	NextCursor *string          `json:"next_cursor"`
+}
+
+type MemberDTO struct {
+	UserID uint64   `json:"user_id"`
+	Role   ListRole `json:"role"`
+}
+
+type MembersDTO struct {
+	Members []MemberDTO `json:"members"`
 }
 
 type ItemDTO struct {
-	ID          uint64  `json:"id"`
-	Title       string  `json:"title"`
-	Description *string `json:"description"`
-	UserID      *uint64 `json:"user_id"`
+	ID          uint64       `json:"id"`
+	Title       string       `json:"title"`
+	Description *string      `json:"description"`
+	ParentID    *uint64      `json:"parent_id"`
+	StatusID    *uint64      `json:"status_id"`
+	Completed   bool         `json:"completed"`
+	CompletedBy *uint64      `json:"completed_by"`
+	CompletedAt *time.Time   `json:"completed_at"`
+	DueAt       *time.Time   `json:"due_at"`
+	DueTimezone *string      `json:"due_timezone"`
+	Recurrence  *string      `json:"recurrence"`
+	Priority    ItemPriority `json:"priority"`
+	Reminders   []int        `json:"reminders"`
+	Position    string       `json:"position"`
+	CreatedAt   time.Time    `json:"created_at"`
+
+	Progress *ItemProgress `json:"progress,omitempty"`
+	Children []ItemDTO     `json:"children,omitempty"`
+	Tags     []TagDTO      `json:"tags,omitempty"`
+	// AssigneeIDs replaces the assignees of the item when informed in a
+	// request. Watchers are only changed by the watch endpoints.
+	AssigneeIDs []uint64   `json:"assignee_ids"`
+	WatcherIDs  []uint64   `json:"watcher_ids"`
+	Fields      FieldInput `json:"fields,omitempty"`
+}
+
+type UserItemDTO struct {
+	ItemDTO
+	ListID    uint64 `json:"list_id"`
+	ListTitle string `json:"list_title"`
+	Overdue   bool   `json:"overdue"`
+}
+
+type UserItemsDTO struct {
+	Items []UserItemDTO `json:"items"`
+}
+
+type UserItemPageDTO struct {
+	Items      []UserItemDTO `json:"items"`
+	Total      int64         `json:"total"`
+	NextCursor *string       `json:"next_cursor"`
+}
+
+type ItemAssigneeDTO struct {
+	UserID uint64 `json:"user_id"`
+}
+
+type ItemOccurrenceDTO struct {
+	ID          uint64    `json:"id"`
+	DueAt       time.Time `json:"due_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	CompletedBy *uint64   `json:"completed_by"`
+}
+
+type ItemOccurrencesDTO struct {
+	Occurrences []ItemOccurrenceDTO `json:"occurrences"`
+}
+
+type CustomFieldDTO struct {
+	ID      uint64    `json:"id"`
+	Name    string    `json:"name"`
+	Type    FieldType `json:"type"`
+	Options []string  `json:"options,omitempty"`
+}
+
+type CustomFieldsDTO struct {
+	Fields []CustomFieldDTO `json:"fields"`
+}
+
+type ListStatusDTO struct {
+	ID       uint64   `json:"id"`
+	Name     string   `json:"name"`
+	Done     bool     `json:"done"`
+	WIPLimit *int     `json:"wip_limit"`
+	Next     []uint64 `json:"next"`
+}
+
+type ListStatusesDTO struct {
+	Statuses []ListStatusDTO `json:"statuses"`
+}
+
+type StatusOrderDTO struct {
+	StatusIDs []uint64 `json:"status_ids"`
+}
+
+type BoardColumnDTO struct {
+	Status    ListStatusDTO `json:"status"`
+	Count     int64         `json:"count"`
+	OverLimit bool          `json:"over_limit"`
+	Items     []ItemDTO     `json:"items"`
+}
+
+type BoardDTO struct {
+	Columns []BoardColumnDTO `json:"columns"`
+}
+
+type TagDTO struct {
+	ID     uint64  `json:"id"`
+	Name   string  `json:"name"`
+	Color  *string `json:"color"`
+	ListID *uint64 `json:"list_id"`
+}
+
+type TagsDTO struct {
+	Tags []TagDTO `json:"tags"`
+}
+
+type TagMergeDTO struct {
+	TargetID uint64 `json:"target_id"`
+}
+
+type ItemTagsDTO struct {
+	TagIDs []uint64 `json:"tag_ids"`
+}
+
+type ItemTransferDTO struct {
+	ListID  uint64   `json:"list_id"`
+	ItemIDs []uint64 `json:"item_ids"`
+}
+
+type ItemMoveDTO struct {
+	AfterID  *uint64 `json:"after_id"`
+	BeforeID *uint64 `json:"before_id"`
 }
 
 type ItemsDTO struct {
 	Items []ItemDTO `json:"items"`
 }
 
+type ItemPageDTO struct {
+	Items      []ItemDTO `json:"items"`
+	Total      int64     `json:"total"`
+	NextCursor *string   `json:"next_cursor"`
+}
+
+type TrashListDTO struct {
+	ID        uint64    `json:"id"`
+	Title     string    `json:"title"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type TrashItemDTO struct {
+	ID        uint64    `json:"id"`
+	ListID    uint64    `json:"list_id"`
+	Title     string    `json:"title"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type TrashDTO struct {
+	Lists []TrashListDTO `json:"lists"`
+	Items []TrashItemDTO `json:"items"`
+}
+
 type AuthRequest struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
+	ClientIP string `json:"-"`
 }
 
 type AuthRequestSSO struct {
@@ -30,22 +205,200 @@ type AuthRequestSSO struct {
 	APPToken string `json:"app_token"`
 }
 
+type AuthRequestOIDC struct {
+	Code  string `form:"code"`
+	State string `form:"state"`
+	// BrowserState is the state kept by the browser that started the login.
+	BrowserState string `form:"-"`
+}
+
+type OIDCAuthorizationDTO struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"-"`
+}
+
+type OIDCLinkRequest struct {
+	LinkToken string `json:"link_token"`
+}
+
+type AuthRequestRefresh struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// AuthResponse carries the tokens of a login. When the user must inform the
+// second factor it carries only the MFA token, exchanged for the tokens in
+// /authenticate/mfa.
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  *User  `json:"user"`
+	Token                 string   `json:"token,omitempty"`
+	RefreshToken          string   `json:"refresh_token,omitempty"`
+	User                  *User    `json:"user,omitempty"`
+	MFARequired           bool     `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool     `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string   `json:"mfa_token,omitempty"`
+	RecoveryCodes         []string `json:"recovery_codes,omitempty"`
+	OIDCLinkRequired      bool     `json:"oidc_link_required,omitempty"`
+	OIDCLinkToken         string   `json:"oidc_link_token,omitempty"`
+}
+
+type AuthRequestMFA struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+	ClientIP     string `json:"-"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+// MFAEnrollmentDTO is the secret to register in the authenticator app, also
+// as an otpauth URI to show in a QR code.
+type MFAEnrollmentDTO struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type MFARecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SSOApplicationDTO exposes the secret of an HMAC application only when it is
+// generated, in the response of its creation.
+type SSOApplicationDTO struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	Issuer    string    `json:"issuer"`
+	Audience  string    `json:"audience"`
+	Algorithm string    `json:"algorithm"`
+	Secret    *string   `json:"secret,omitempty"`
+	PublicKey *string   `json:"public_key,omitempty"`
+	Enabled   *bool     `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SSOApplicationsDTO struct {
+	Applications []SSOApplicationDTO `json:"applications"`
+}
+
+// JWKDTO is the public key of a signing key in the JSON Web Key format. RSA
+// keys fill N and E, EC keys Crv, X and Y, and Ed25519 keys Crv and X.
+type JWKDTO struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSDTO struct {
+	Keys []JWKDTO `json:"keys"`
 }
 
 func NewListDTO(list *List) *ListDTO {
-	tinyList := tinyList{ID: list.ID, Title: list.Title}
+	progress := list.Progress
+	tinyList := tinyList{
+		ID:          list.ID,
+		Title:       list.Title,
+		Description: list.Description,
+		Color:       list.Color,
+		Icon:        list.Icon,
+		Progress:    &progress,
+	}
 	return &ListDTO{tinyList}
 }
 
+func NewListsDTO(page *ListPage) *ListsDTO {
+	listsDTO := make([]ListSummaryDTO, len(page.Lists))
+	for i, list := range page.Lists {
+		listsDTO[i] = ListSummaryDTO{
+			ID:         list.ID,
+			Title:      list.Title,
+			Color:      list.Color,
+			Icon:       list.Icon,
+			CreatedAt:  list.CreatedAt,
+			ItemsCount: list.Progress.Total,
+			Progress:   list.Progress,
+		}
+	}
+	return &ListsDTO{Lists: listsDTO, Total: page.Total, NextCursor: page.NextCursor}
+}
+
+func NewMemberDTO(member *ListMember) *MemberDTO {
+	return &MemberDTO{UserID: member.UserID, Role: member.Role}
+}
+
+func NewMembersDTO(members *[]ListMember) *MembersDTO {
+	membersDTO := make([]MemberDTO, len(*members))
+	for i, member := range *members {
+		membersDTO[i] = *NewMemberDTO(&member)
+	}
+	return &MembersDTO{Members: membersDTO}
+}
+
 func NewItemDTO(item *Item) *ItemDTO {
+	var dueAt *time.Time
+	if item.DueAt != nil {
+		localDueAt := item.DueAt.In(item.DueLocation())
+		dueAt = &localDueAt
+	}
+
+	var children []ItemDTO
+	if len(item.Children) > 0 {
+		children = NewItemsDTO(&item.Children).Items
+	}
+
+	var tags []TagDTO
+	if len(item.Tags) > 0 {
+		tags = NewTagsDTO(&item.Tags).Tags
+	}
+
+	var fields FieldInput
+	if len(item.FieldValues) > 0 {
+		fields = make(FieldInput, len(item.FieldValues))
+		for _, value := range item.FieldValues {
+			fields[value.FieldID] = value.Get()
+		}
+	}
+
+	assigneeIDs, watcherIDs := item.AssigneeIDs, item.WatcherIDs
+	if assigneeIDs == nil {
+		assigneeIDs = []uint64{}
+	}
+	if watcherIDs == nil {
+		watcherIDs = []uint64{}
+	}
+
 	return &ItemDTO{
 		ID:          item.ID,
 		Title:       item.Title,
 		Description: item.Description,
-		UserID:      item.UserID,
+		ParentID:    item.ParentID,
+		StatusID:    item.StatusID,
+		Completed:   item.CompletedAt != nil,
+		CompletedBy: item.CompletedBy,
+		CompletedAt: item.CompletedAt,
+		DueAt:       dueAt,
+		DueTimezone: item.DueTimezone,
+		Recurrence:  item.Recurrence,
+		Priority:    item.Priority,
+		Reminders:   item.ReminderOffsets,
+		Position:    item.Position,
+		CreatedAt:   item.CreatedAt,
+		Progress:    item.Progress,
+		Children:    children,
+		Tags:        tags,
+		Fields:      fields,
+		AssigneeIDs: assigneeIDs,
+		WatcherIDs:  watcherIDs,
 	}
 }
 
@@ -56,3 +409,141 @@ func NewItemsDTO(items *[]Item) *ItemsDTO {
 	}
 	return &ItemsDTO{Items: itemsDTO}
 }
+
+func NewItemPageDTO(page *ItemPage) *ItemPageDTO {
+	return &ItemPageDTO{
+		Items:      NewItemsDTO(&page.Items).Items,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}
+}
+
+func NewUserItemsDTO(items *[]UserItem) *UserItemsDTO {
+	itemsDTO := make([]UserItemDTO, len(*items))
+	for i, item := range *items {
+		itemsDTO[i] = UserItemDTO{
+			ItemDTO:   *NewItemDTO(&item.Item),
+			ListID:    item.ListID,
+			ListTitle: item.ListTitle,
+			Overdue:   item.Overdue,
+		}
+	}
+	return &UserItemsDTO{Items: itemsDTO}
+}
+
+func NewUserItemPageDTO(page *UserItemPage) *UserItemPageDTO {
+	return &UserItemPageDTO{
+		Items:      NewUserItemsDTO(&page.Items).Items,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}
+}
+
+func NewItemOccurrencesDTO(occurrences *[]ItemOccurrence) *ItemOccurrencesDTO {
+	occurrencesDTO := make([]ItemOccurrenceDTO, len(*occurrences))
+	for i, occurrence := range *occurrences {
+		occurrencesDTO[i] = ItemOccurrenceDTO{
+			ID:          occurrence.ID,
+			DueAt:       occurrence.DueAt,
+			CompletedAt: occurrence.CompletedAt,
+			CompletedBy: occurrence.CompletedBy,
+		}
+	}
+	return &ItemOccurrencesDTO{Occurrences: occurrencesDTO}
+}
+
+func NewListStatusDTO(status *ListStatus) *ListStatusDTO {
+	next := status.Next
+	if next == nil {
+		next = []uint64{}
+	}
+	return &ListStatusDTO{ID: status.ID, Name: status.Name, Done: status.Done, WIPLimit: status.WIPLimit, Next: next}
+}
+
+func NewListStatusesDTO(statuses *[]ListStatus) *ListStatusesDTO {
+	statusesDTO := make([]ListStatusDTO, len(*statuses))
+	for i, status := range *statuses {
+		statusesDTO[i] = *NewListStatusDTO(&status)
+	}
+	return &ListStatusesDTO{Statuses: statusesDTO}
+}
+
+func NewBoardDTO(board *Board) *BoardDTO {
+	columnsDTO := make([]BoardColumnDTO, len(board.Columns))
+	for i, column := range board.Columns {
+		columnsDTO[i] = BoardColumnDTO{
+			Status:    *NewListStatusDTO(&column.Status),
+			Count:     column.Count,
+			OverLimit: column.Status.WIPLimit != nil && column.Count > int64(*column.Status.WIPLimit),
+			Items:     NewItemsDTO(&column.Items).Items,
+		}
+	}
+	return &BoardDTO{Columns: columnsDTO}
+}
+
+func NewTagDTO(tag *Tag) *TagDTO {
+	return &TagDTO{ID: tag.ID, Name: tag.Name, Color: tag.Color, ListID: tag.ListID}
+}
+
+func NewTagsDTO(tags *[]Tag) *TagsDTO {
+	tagsDTO := make([]TagDTO, len(*tags))
+	for i, tag := range *tags {
+		tagsDTO[i] = *NewTagDTO(&tag)
+	}
+	return &TagsDTO{Tags: tagsDTO}
+}
+
+func NewCustomFieldDTO(field *CustomField) *CustomFieldDTO {
+	return &CustomFieldDTO{ID: field.ID, Name: field.Name, Type: field.Type, Options: field.Options}
+}
+
+func NewCustomFieldsDTO(fields *[]CustomField) *CustomFieldsDTO {
+	fieldsDTO := make([]CustomFieldDTO, len(*fields))
+	for i, field := range *fields {
+		fieldsDTO[i] = *NewCustomFieldDTO(&field)
+	}
+	return &CustomFieldsDTO{Fields: fieldsDTO}
+}
+
+func NewTrashDTO(trash *Trash) *TrashDTO {
+	listsDTO := make([]TrashListDTO, len(trash.Lists))
+	for i, list := range trash.Lists {
+		listsDTO[i] = TrashListDTO{ID: list.ID, Title: list.Title, DeletedAt: list.DeletedAt.Time}
+	}
+
+	itemsDTO := make([]TrashItemDTO, len(trash.Items))
+	for i, item := range trash.Items {
+		itemsDTO[i] = TrashItemDTO{ID: item.ID, ListID: item.ListID, Title: item.Title, DeletedAt: item.DeletedAt.Time}
+	}
+
+	return &TrashDTO{Lists: listsDTO, Items: itemsDTO}
+}
+
+func NewSSOApplicationDTO(app *SSOApplication, withSecret bool) *SSOApplicationDTO {
+	enabled := app.Enabled
+	appDTO := SSOApplicationDTO{
+		ID:        app.ID,
+		Name:      app.Name,
+		Issuer:    app.Issuer,
+		Audience:  app.Audience,
+		Algorithm: app.Algorithm,
+		PublicKey: app.PublicKey,
+		Enabled:   &enabled,
+		CreatedAt: app.CreatedAt,
+		UpdatedAt: app.UpdatedAt,
+	}
+
+	if withSecret {
+		appDTO.Secret = app.Secret
+	}
+
+	return &appDTO
+}
+
+func NewSSOApplicationsDTO(apps *[]SSOApplication) *SSOApplicationsDTO {
+	appsDTO := make([]SSOApplicationDTO, len(*apps))
+	for i, app := range *apps {
+		appsDTO[i] = *NewSSOApplicationDTO(&app, false)
+	}
+	return &SSOApplicationsDTO{Applications: appsDTO}
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType is the type of the values of a custom field.
+type FieldType string
+
+const (
+	FieldTypeText     FieldType = "text"
+	FieldTypeNumber   FieldType = "number"
+	FieldTypeDate     FieldType = "date"
+	FieldTypeSelect   FieldType = "select"
+	FieldTypeCheckbox FieldType = "checkbox"
+	FieldTypeUser     FieldType = "user"
+)
+
+// FieldDateLayout is the format of the values of date fields.
+const FieldDateLayout = "2006-01-02"
+
+// FieldTypes are the types accepted for custom fields.
+var FieldTypes = map[FieldType]bool{
+	FieldTypeText:     true,
+	FieldTypeNumber:   true,
+	FieldTypeDate:     true,
+	FieldTypeSelect:   true,
+	FieldTypeCheckbox: true,
+	FieldTypeUser:     true,
+}
+
+// CustomField is a field defined on a list, filled in by each item of the
+// list. Only select fields have options.
+type CustomField struct {
+	ID        uint64
+	ListID    uint64
+	Name      string
+	Type      FieldType
+	Options   FieldOptions
+	CreatedAt time.Time
+}
+
+// FieldInput are the custom field values informed in a request, by field
+// id. A nil value clears the field.
+type FieldInput map[uint64]interface{}
+
+// FieldOptions are the values accepted by a select field, stored as a JSON
+// array.
+type FieldOptions []string
+
+// ItemFieldValue is the value of a custom field on an item. Only the column
+// of the field type is set.
+type ItemFieldValue struct {
+	ItemID      uint64 `gorm:"primaryKey;autoIncrement:false"`
+	FieldID     uint64 `gorm:"primaryKey;autoIncrement:false"`
+	TextValue   *string
+	NumberValue *float64
+	DateValue   *time.Time
+	BoolValue   *bool
+	UserValue   *uint64
+}
+
+// FieldOperator tells how a custom field filter compares the item values.
+type FieldOperator string
+
+const (
+	FieldOperatorEqual FieldOperator = "eq"
+	FieldOperatorMin   FieldOperator = "min"
+	FieldOperatorMax   FieldOperator = "max"
+)
+
+// FieldFilter restricts the item listing by the value of a custom field.
+// Field and Value are resolved from FieldID and Raw by the item service.
+type FieldFilter struct {
+	FieldID  uint64
+	Operator FieldOperator
+	Raw      string
+	Field    *CustomField
+	Value    interface{}
+}
+
+// FieldSortPrefix prefixes the custom field id in the sort query parameter.
+const FieldSortPrefix = "field:"
+
+// ParseFieldSort returns the custom field id of a field:<id> sort value.
+func ParseFieldSort(sort string) (uint64, bool) {
+	if !strings.HasPrefix(sort, FieldSortPrefix) {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(strings.TrimPrefix(sort, FieldSortPrefix), 10, 64)
+	return id, err == nil && id != 0
+}
+
+func (FieldOptions) GormDataType() string {
+	return "string"
+}
+
+func (options FieldOptions) Value() (driver.Value, error) {
+	if options == nil {
+		return nil, nil
+	}
+
+	bytes, err := json.Marshal([]string(options))
+	return string(bytes), err
+}
+
+func (options *FieldOptions) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*options = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("invalid field options value")
+	}
+
+	return json.Unmarshal(raw, (*[]string)(options))
+}
+
+// Column returns the item_field_value column that holds the values of the
+// type.
+func (fieldType FieldType) Column() string {
+	switch fieldType {
+	case FieldTypeNumber:
+		return "number_value"
+	case FieldTypeDate:
+		return "date_value"
+	case FieldTypeCheckbox:
+		return "bool_value"
+	case FieldTypeUser:
+		return "user_value"
+	default:
+		return "text_value"
+	}
+}
+
+// EmptySortValue is the value items without a value of the type are sorted
+// by. It comes before every valid value.
+func (fieldType FieldType) EmptySortValue() interface{} {
+	switch fieldType {
+	case FieldTypeNumber:
+		return -math.MaxFloat64
+	case FieldTypeDate:
+		return "0001-01-01"
+	case FieldTypeCheckbox:
+		return false
+	case FieldTypeUser:
+		return 0
+	default:
+		return ""
+	}
+}
+
+// Get returns the value set on the column of the field type, nil when the
+// value is empty. Dates are returned in the FieldDateLayout format.
+func (value *ItemFieldValue) Get() interface{} {
+	switch {
+	case value.TextValue != nil:
+		return *value.TextValue
+	case value.NumberValue != nil:
+		return *value.NumberValue
+	case value.DateValue != nil:
+		return value.DateValue.Format(FieldDateLayout)
+	case value.BoolValue != nil:
+		return *value.BoolValue
+	case value.UserValue != nil:
+		return *value.UserValue
+	default:
+		return nil
+	}
+}
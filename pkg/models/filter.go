@@ -0,0 +1,168 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// PageCursor points to the last row of a page, identified by the value of
+// the sort field and the row id as a tie breaker (keyset pagination).
+type PageCursor struct {
+	Sort  string      `json:"s"`
+	Value interface{} `json:"v,omitempty"`
+	ID    uint64      `json:"id"`
+}
+
+type ItemFilter struct {
+	ListID      uint64
+	UserID      uint64
+	AssigneeID  *uint64
+	StatusID    *uint64
+	Priority    ItemPriority
+	Title       string
+	Description string
+	Status      ItemStatus
+	Due         ItemDue
+	Timezone    *time.Location
+	DueFrom     *time.Time
+	DueBefore   *time.Time
+	Sort        string
+	Order       SortOrder
+	Limit       int
+	Cursor      *PageCursor
+	Tree        bool
+	TagIDs      []uint64
+	TagMatch    TagMatch
+	Fields      []FieldFilter
+	SortField   *CustomField
+}
+
+type ListFilter struct {
+	UserID uint64
+	Query  string
+	Sort   string
+	Order  SortOrder
+	Limit  int
+	Cursor *PageCursor
+}
+
+type ListPage struct {
+	Lists      []List
+	Total      int64
+	NextCursor *string
+}
+
+type ItemStatus string
+
+const (
+	ItemStatusAll  ItemStatus = ""
+	ItemStatusOpen ItemStatus = "open"
+	ItemStatusDone ItemStatus = "done"
+)
+
+type ItemDue string
+
+const (
+	ItemDueAny     ItemDue = ""
+	ItemDueOverdue ItemDue = "overdue"
+	ItemDueToday   ItemDue = "today"
+)
+
+type ItemPage struct {
+	Items      []Item
+	Total      int64
+	NextCursor *string
+}
+
+// UserItem is an item assigned to a user, from any list the user can access,
+// with the title of its list.
+type UserItem struct {
+	Item      `gorm:"embedded"`
+	ListTitle string
+	Overdue   bool `gorm:"-"`
+}
+
+// ItemSortFields are the item fields accepted by the sort query parameter.
+var ItemSortFields = map[string]bool{
+	"id":       true,
+	"title":    true,
+	"position": true,
+	"priority": true,
+}
+
+// ListSortFields are the list fields accepted by the sort query parameter.
+var ListSortFields = map[string]bool{
+	"id":         true,
+	"title":      true,
+	"created_at": true,
+}
+
+func (cursor PageCursor) Encode() string {
+	bytes, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+func DecodePageCursor(encoded string) (*PageCursor, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor PageCursor
+	if err = json.Unmarshal(bytes, &cursor); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+// SortValue returns the value of the item field used to build page cursors.
+// Custom fields are sorted by the value of their column, with the empty sort
+// value of their type for items without a value.
+func (item *Item) SortValue(field string, customField *CustomField) interface{} {
+	if customField != nil {
+		for _, value := range item.FieldValues {
+			if value.FieldID == customField.ID && value.Get() != nil {
+				return value.Get()
+			}
+		}
+		return customField.Type.EmptySortValue()
+	}
+
+	switch field {
+	case "title":
+		return item.Title
+	case "position":
+		return item.Position
+	case "priority":
+		return item.Priority.Level()
+	default:
+		return nil
+	}
+}
+
+func (list *List) SortValue(field string) interface{} {
+	switch field {
+	case "title":
+		return list.Title
+	case "created_at":
+		return formatCursorTime(list.CreatedAt)
+	default:
+		return nil
+	}
+}
+
+// formatCursorTime keeps the microseconds precision of DATETIME(6) columns in
+// a format MySQL compares directly with them.
+func formatCursorTime(value time.Time) string {
+	return value.UTC().Format("2006-01-02 15:04:05.000000")
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// LoginAttempt counts the failed logins by a key: "user:{id}" for a user,
+// "user:{id}:ip:{ip}" for a user at an IP, "login:{login}" for an unknown
+// login and "ip:{ip}" for an IP. A locked key is refused until LockedUntil.
+type LoginAttempt struct {
+	AttemptKey    string `gorm:"primaryKey"`
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+type AuthAuditEvent string
+
+const (
+	AuthAuditLoginFailed    AuthAuditEvent = "login_failed"
+	AuthAuditLoginThrottled AuthAuditEvent = "login_throttled"
+	AuthAuditLoginLocked    AuthAuditEvent = "login_locked"
+	AuthAuditMFAFailed      AuthAuditEvent = "mfa_failed"
+	AuthAuditLoginUnlocked  AuthAuditEvent = "login_unlocked"
+)
+
+// AuthAudit records a rejected login, a lockout or an unlock. ActorID is the
+// administrator that unlocked the login.
+type AuthAudit struct {
+	ID        uint64
+	Event     AuthAuditEvent
+	Login     string
+	UserID    *uint64
+	IP        string
+	ActorID   *uint64
+	CreatedAt time.Time
+}
+
+// TrustedLoginIP is an IP the user logged in from. The failed logins of the
+// user made elsewhere do not lock the user out of it.
+type TrustedLoginIP struct {
+	UserID      uint64 `gorm:"primaryKey;autoIncrement:false"`
+	IP          string `gorm:"primaryKey"`
+	LastLoginAt time.Time
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// UserMFA is the TOTP secret of a user. It protects the logins only once
+// confirmed with a code. LastUsedStep is the period of the last code
+// accepted, so a code is not accepted twice.
+type UserMFA struct {
+	UserID       uint64 `gorm:"primaryKey"`
+	Secret       string
+	LastUsedStep int64
+	ConfirmedAt  *time.Time
+	CreatedAt    time.Time
+}
+
+// MFARecoveryCode is a single-use code that replaces the TOTP code when the
+// user loses the authenticator.
+type MFARecoveryCode struct {
+	ID       uint64
+	UserID   uint64
+	CodeHash string
+	UsedAt   *time.Time
+}
+
+// MFAChallenge is a login that passed the password and waits for the second
+// factor, found by the hash of its token. An enrollment challenge is issued
+// to users that must enable MFA before logging in.
+type MFAChallenge struct {
+	TokenHash  string `gorm:"primaryKey"`
+	UserID     uint64
+	Enrollment bool
+	Attempts   int
+	ExpiresAt  time.Time
+}
+
+func (mfa *UserMFA) IsConfirmed() bool {
+	return mfa != nil && mfa.ConfirmedAt != nil
+}
@@ -1,38 +1,110 @@
 package models
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
 
 type User struct {
-	ID       uint64 `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Login    string `json:"login" gorm:"unique"`
-	Password string `json:"password"`
+	ID       uint64   `json:"id"`
+	Name     string   `json:"name"`
+	Email    string   `json:"email"`
+	Login    string   `json:"login" gorm:"unique"`
+	Password string   `json:"password"`
+	Role     UserRole `json:"role"`
 }
 
 type List struct {
-	ID    uint64
-	Title string
-	Owner *uint64
+	ID          uint64
+	Title       string
+	Description *string
+	Color       *string
+	Icon        *string
+	Owner       *uint64 `gorm:"column:user_id"`
+	CreatedAt   time.Time
+	DeletedAt   gorm.DeletedAt
+	DeletedBy   *uint64
+	Progress    ListProgress `gorm:"-"`
+}
+
+type ListProgress struct {
+	Done  int64 `json:"done"`
+	Total int64 `json:"total"`
 }
 
 type Item struct {
-	ID          uint64  `json:"id"`
-	UserID      *uint64 `json:"user_id"`
-	ListID      uint64  `json:"list_id"`
-	Title       string  `json:"title"`
-	Description *string `json:"description"`
+	ID          uint64       `json:"id"`
+	ListID      uint64       `json:"list_id"`
+	ParentID    *uint64      `json:"parent_id"`
+	StatusID    *uint64      `json:"status_id"`
+	Title       string       `json:"title"`
+	Description *string      `json:"description"`
+	CompletedAt *time.Time   `json:"completed_at"`
+	CompletedBy *uint64      `json:"completed_by"`
+	DueAt       *time.Time   `json:"due_at"`
+	DueTimezone *string      `json:"due_timezone"`
+	Recurrence  *string      `json:"recurrence"`
+	Priority    ItemPriority `json:"priority"`
+	Position    string       `json:"position"`
+	CreatedAt   time.Time    `json:"created_at"`
+
+	ReminderOffsets MinuteOffsets    `json:"reminder_offsets"`
+	Reminders       []ItemReminder   `json:"-" gorm:"-"`
+	Children        []Item           `json:"-" gorm:"-"`
+	Tags            []Tag            `json:"-" gorm:"-"`
+	AssigneeIDs     []uint64         `json:"-" gorm:"-"`
+	WatcherIDs      []uint64         `json:"-" gorm:"-"`
+	Fields          FieldInput       `json:"-" gorm:"-"`
+	FieldValues     []ItemFieldValue `json:"-" gorm:"-"`
+	Progress        *ItemProgress    `json:"-" gorm:"-"`
+	// Occurrence is the occurrence completed by the change of the item,
+	// recorded together with it.
+	Occurrence *ItemOccurrence `json:"-" gorm:"-"`
+
+	DeletedAt         gorm.DeletedAt `json:"-"`
+	DeletedBy         *uint64        `json:"-"`
+	DeletedWithList   bool           `json:"-"`
+	DeletedWithParent bool           `json:"-"`
+}
+
+// ItemProgress counts the done and total sub-items of an item, at any level.
+type ItemProgress struct {
+	Done  int64 `json:"done"`
+	Total int64 `json:"total"`
+}
+
+type ListMember struct {
+	ListID    uint64 `gorm:"primaryKey;autoIncrement:false"`
+	UserID    uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Role      ListRole
+	CreatedAt time.Time
 }
 
 func NewListFromDTO(listDTO *ListDTO) *List {
-	return &List{Title: listDTO.ListParam.Title}
+	return &List{
+		Title:       listDTO.ListParam.Title,
+		Description: listDTO.ListParam.Description,
+		Color:       listDTO.ListParam.Color,
+		Icon:        listDTO.ListParam.Icon,
+	}
 }
 
 func NewItemFromDTO(itemDTO *ItemDTO) *Item {
 	return &Item{
 		Title:       itemDTO.Title,
 		Description: itemDTO.Description,
-		UserID:      itemDTO.UserID,
+		ParentID:    itemDTO.ParentID,
+		StatusID:    itemDTO.StatusID,
+		DueAt:       itemDTO.DueAt,
+		DueTimezone: itemDTO.DueTimezone,
+		Recurrence:  itemDTO.Recurrence,
+		Priority:    itemDTO.Priority,
+		Fields:      itemDTO.Fields,
+		AssigneeIDs: itemDTO.AssigneeIDs,
+
+		ReminderOffsets: itemDTO.Reminders,
 	}
 }
 
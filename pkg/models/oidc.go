@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// OIDCLogin is an OpenID Connect login waiting for the provider callback. It
+// is found by the hash of the state and used once. When UserID is set the
+// login links the identity to that user instead of logging in.
+type OIDCLogin struct {
+	StateHash    string `gorm:"primaryKey"`
+	Nonce        string
+	CodeVerifier string
+	UserID       *uint64
+	ExpiresAt    time.Time
+}
+
+// OIDCLink is a provider subject waiting for the user of a linking login to
+// confirm the link, found by the hash of its token.
+type OIDCLink struct {
+	TokenHash string `gorm:"primaryKey"`
+	UserID    uint64
+	Issuer    string
+	Subject   string
+	ExpiresAt time.Time
+}
+
+// UserIdentity links the subject of an OpenID Connect provider to a user.
+type UserIdentity struct {
+	ID        uint64
+	UserID    uint64
+	Issuer    string
+	Subject   string
+	CreatedAt time.Time
+}
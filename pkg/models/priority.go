@@ -0,0 +1,78 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strconv"
+)
+
+// ItemPriority is the priority level of an item, stored as its numeric
+// level so items sort by it. An empty priority means it was not informed.
+type ItemPriority string
+
+const (
+	ItemPriorityNone   ItemPriority = "none"
+	ItemPriorityLow    ItemPriority = "low"
+	ItemPriorityMedium ItemPriority = "medium"
+	ItemPriorityHigh   ItemPriority = "high"
+	ItemPriorityUrgent ItemPriority = "urgent"
+)
+
+var itemPriorityLevels = map[ItemPriority]int64{
+	ItemPriorityNone:   0,
+	ItemPriorityLow:    1,
+	ItemPriorityMedium: 2,
+	ItemPriorityHigh:   3,
+	ItemPriorityUrgent: 4,
+}
+
+func (priority ItemPriority) IsValid() bool {
+	_, ok := itemPriorityLevels[priority]
+	return ok
+}
+
+// Level returns the numeric level of the priority, higher being more urgent.
+func (priority ItemPriority) Level() int64 {
+	return itemPriorityLevels[priority]
+}
+
+func (ItemPriority) GormDataType() string {
+	return "int"
+}
+
+func (priority ItemPriority) Value() (driver.Value, error) {
+	if priority == "" {
+		return ItemPriorityNone.Level(), nil
+	}
+
+	if !priority.IsValid() {
+		return nil, errors.New("invalid item priority")
+	}
+	return priority.Level(), nil
+}
+
+func (priority *ItemPriority) Scan(value interface{}) error {
+	var level int64
+	var err error
+	switch v := value.(type) {
+	case int64:
+		level = v
+	case []byte:
+		level, err = strconv.ParseInt(string(v), 10, 64)
+	default:
+		err = errors.New("invalid item priority value")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for name, nameLevel := range itemPriorityLevels {
+		if nameLevel == level {
+			*priority = name
+			return nil
+		}
+	}
+
+	return errors.New("invalid item priority value")
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ItemOccurrence is a completed occurrence of a recurring item, with the due
+// date it had.
+type ItemOccurrence struct {
+	ID          uint64
+	ItemID      uint64
+	DueAt       time.Time
+	CompletedAt time.Time
+	CompletedBy *uint64
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinuteOffsets are the reminder offsets of an item, in minutes before its
+// due date, stored as a comma separated column.
+type MinuteOffsets []int
+
+type ItemReminder struct {
+	ID       uint64
+	ItemID   uint64
+	RemindAt time.Time
+	SentAt   *time.Time
+}
+
+// ReminderNotification carries everything a notifier needs to deliver a
+// reminder without querying the database again.
+type ReminderNotification struct {
+	ReminderID  uint64    `json:"reminder_id"`
+	ItemID      uint64    `json:"item_id"`
+	ListID      uint64    `json:"list_id"`
+	Title       string    `json:"title"`
+	DueAt       time.Time `json:"due_at"`
+	DueTimezone *string   `json:"due_timezone"`
+	RemindAt    time.Time `json:"remind_at"`
+
+	Recipients []ReminderRecipient `json:"recipients" gorm:"-"`
+}
+
+// ReminderRecipient is an assignee or watcher of the item of a reminder.
+type ReminderRecipient struct {
+	ItemID uint64 `json:"-"`
+	UserID uint64 `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+}
+
+func (MinuteOffsets) GormDataType() string {
+	return "string"
+}
+
+func (offsets MinuteOffsets) Value() (driver.Value, error) {
+	if offsets == nil {
+		return nil, nil
+	}
+
+	values := make([]string, len(offsets))
+	for i, offset := range offsets {
+		values[i] = strconv.Itoa(offset)
+	}
+	return strings.Join(values, ","), nil
+}
+
+func (offsets *MinuteOffsets) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		*offsets = nil
+		return nil
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return errors.New("invalid reminder offsets value")
+	}
+
+	result := MinuteOffsets{}
+	for _, part := range strings.Split(raw, ",") {
+		if part == "" {
+			continue
+		}
+
+		offset, err := strconv.Atoi(part)
+		if err != nil {
+			return err
+		}
+		result = append(result, offset)
+	}
+
+	*offsets = result
+	return nil
+}
+
+// DueLocation returns the timezone the due date was informed in, UTC when
+// none was set.
+func (item *Item) DueLocation() *time.Location {
+	if item.DueTimezone == nil {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(*item.DueTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
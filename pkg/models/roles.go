@@ -0,0 +1,61 @@
+package models
+
+type (
+	UserRole string
+	ListRole string
+)
+
+const (
+	UserRoleAdmin   UserRole = "admin"
+	UserRoleMember  UserRole = "member"
+	UserRoleService UserRole = "service"
+)
+
+const (
+	ListRoleNone   ListRole = ""
+	ListRoleViewer ListRole = "viewer"
+	ListRoleEditor ListRole = "editor"
+	ListRoleAdmin  ListRole = "admin"
+	ListRoleOwner  ListRole = "owner"
+)
+
+var listRoleLevels = map[ListRole]int{
+	ListRoleNone:   0,
+	ListRoleViewer: 1,
+	ListRoleEditor: 2,
+	ListRoleAdmin:  3,
+	ListRoleOwner:  4,
+}
+
+// Includes reports whether the role grants at least the permissions of other.
+func (role ListRole) Includes(other ListRole) bool {
+	return listRoleLevels[role] >= listRoleLevels[other]
+}
+
+func (role ListRole) IsValid() bool {
+	_, ok := listRoleLevels[role]
+	return ok && role != ListRoleNone
+}
+
+// IsAssignable reports whether the role can be granted to a list member.
+// Ownership is not transferable through membership.
+func (role ListRole) IsAssignable() bool {
+	return role.IsValid() && role != ListRoleOwner
+}
+
+func (role UserRole) IsValid() bool {
+	switch role {
+	case UserRoleAdmin, UserRoleMember, UserRoleService:
+		return true
+	}
+	return false
+}
+
+func (role UserRole) In(roles ...UserRole) bool {
+	for _, r := range roles {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
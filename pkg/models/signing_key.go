@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SigningKey is a key pair that signs the access tokens, with the private key
+// in PKCS #8 PEM, encrypted by the JWT key secret. A key signs from
+// ActivatesAt until RetiresAt, and its public key is published to verify the
+// tokens from its creation until ExpiresAt.
+type SigningKey struct {
+	ID          uint64
+	KID         string `gorm:"column:kid"`
+	Algorithm   string
+	PrivateKey  string
+	ActivatesAt time.Time
+	RetiresAt   time.Time
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
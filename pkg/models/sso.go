@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SSOApplication is a partner application allowed to log its users in. The
+// application signs its tokens with its own key: an HMAC secret shared with
+// us, or a private key whose public key, in PEM, is registered here.
+type SSOApplication struct {
+	ID        uint64
+	Name      string
+	Issuer    string
+	Audience  string
+	Algorithm string
+	Secret    *string
+	PublicKey *string
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// SSOTokenUse records the jti of an SSO token already used to log in, until
+// the token expires.
+type SSOTokenUse struct {
+	ApplicationID uint64 `gorm:"primaryKey;autoIncrement:false"`
+	JTI           string `gorm:"column:jti;primaryKey"`
+	ExpiresAt     time.Time
+}
+
+// SSOAlgorithms are the signing algorithms accepted for SSO tokens.
+var SSOAlgorithms = map[string]bool{
+	"HS256": true, "HS384": true, "HS512": true,
+	"RS256": true, "RS384": true, "RS512": true,
+	"ES256": true, "ES384": true, "ES512": true,
+}
+
+// IsHMAC tells if the application signs its tokens with a shared secret.
+func (app *SSOApplication) IsHMAC() bool {
+	return strings.HasPrefix(app.Algorithm, "HS")
+}
+
+// VerificationKey returns the key that verifies the tokens of the
+// application, in the form expected by its signing method.
+func (app *SSOApplication) VerificationKey() (interface{}, error) {
+	if app.IsHMAC() {
+		if app.Secret == nil || *app.Secret == "" {
+			return nil, errors.New("missing application secret")
+		}
+		return []byte(*app.Secret), nil
+	}
+
+	if app.PublicKey == nil {
+		return nil, errors.New("missing application public key")
+	}
+
+	if strings.HasPrefix(app.Algorithm, "ES") {
+		return jwt.ParseECPublicKeyFromPEM([]byte(*app.PublicKey))
+	}
+	return jwt.ParseRSAPublicKeyFromPEM([]byte(*app.PublicKey))
+}
+
+// SSOAudience is the aud claim of an SSO token, a single value or an array.
+type SSOAudience []string
+
+func (audience *SSOAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*audience = SSOAudience{single}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(audience))
+}
+
+// Contains tells if the audience includes the value.
+func (audience SSOAudience) Contains(value string) bool {
+	for _, item := range audience {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Tag labels items. A tag belongs either to a user, who can use it on any
+// list, or to a list, where it is shared by its members.
+type Tag struct {
+	ID        uint64
+	Name      string
+	Color     *string
+	UserID    *uint64
+	ListID    *uint64
+	CreatedAt time.Time
+}
+
+type ItemTag struct {
+	ItemID uint64 `gorm:"primaryKey;autoIncrement:false"`
+	TagID  uint64 `gorm:"primaryKey;autoIncrement:false"`
+}
+
+// TagMatch tells if the item listing keeps items with any or with all of the
+// requested tags.
+type TagMatch string
+
+const (
+	TagMatchAny TagMatch = "any"
+	TagMatchAll TagMatch = "all"
+)
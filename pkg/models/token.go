@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type RefreshToken struct {
+	ID           uint64
+	UserID       uint64
+	FamilyID     string
+	TokenHash    string
+	ExpiresAt    time.Time
+	RevokedAt    *time.Time
+	ReplacedByID *uint64
+	CreatedAt    time.Time
+}
+
+type RevokedToken struct {
+	JTI       string `gorm:"column:jti;primaryKey"`
+	ExpiresAt time.Time
+}
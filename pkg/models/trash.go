@@ -0,0 +1,8 @@
+package models
+
+// Trash holds the lists and items deleted by or from lists owned by a user
+// that can still be restored.
+type Trash struct {
+	Lists []List
+	Items []Item
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// ListStatus is a column of the workflow of a list. Items move from a status
+// only to the statuses in Next, and entering a done status completes them.
+// A status with a WIP limit accepts at most that many items.
+type ListStatus struct {
+	ID        uint64
+	ListID    uint64
+	Name      string
+	Position  int
+	WIPLimit  *int `gorm:"column:wip_limit"`
+	Done      bool
+	CreatedAt time.Time
+	Next      []uint64 `gorm:"-"`
+}
+
+type StatusTransition struct {
+	FromStatusID uint64 `gorm:"primaryKey;autoIncrement:false"`
+	ToStatusID   uint64 `gorm:"primaryKey;autoIncrement:false"`
+}
+
+// Board groups the items of a list by status, in the workflow order.
+type Board struct {
+	Columns []BoardColumn
+}
+
+// BoardColumn holds the first items of a status, by position, and how many
+// items are in the status.
+type BoardColumn struct {
+	Status ListStatus
+	Count  int64
+	Items  []Item
+}
+
+// Allows tells if an item in the status can move to the target status.
+func (status *ListStatus) Allows(targetID uint64) bool {
+	for _, id := range status.Next {
+		if id == targetID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+type (
+	jwkSet struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+)
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// publicKey decodes the RSA and EC keys, the ones that can verify the
+// accepted ID token algorithms.
+func (key jwk) publicKey() (interface{}, error) {
+	switch key.Kty {
+	case "RSA":
+		n, err := decodeInt(key.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeInt(key.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve := curves[key.Crv]
+		if curve == nil {
+			return nil, fmt.Errorf("unsupported curve %s", key.Crv)
+		}
+
+		x, err := decodeInt(key.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeInt(key.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC point")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", key.Kty)
+	}
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+
+	return new(big.Int).SetBytes(bytes), nil
+}
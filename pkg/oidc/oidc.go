@@ -0,0 +1,424 @@
+// Package oidc implements the relying party side of the OpenID Connect
+// authorization code flow with PKCE: provider discovery, the authorization
+// URL, the code exchange and the verification of the ID token against the
+// keys published by the provider.
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/clock"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// leeway is the clock skew tolerated on the ID token times.
+	leeway = time.Minute
+	// keysReloadInterval is how often an unknown kid reloads the keys of the
+	// provider, which may have rotated them.
+	keysReloadInterval = time.Minute
+)
+
+// signingAlgorithms are the ID token algorithms accepted. Symmetric and
+// unsigned tokens are never accepted.
+var signingAlgorithms = map[string]bool{
+	"RS256": true, "RS384": true, "RS512": true,
+	"PS256": true, "PS384": true, "PS512": true,
+	"ES256": true, "ES384": true, "ES512": true,
+}
+
+var (
+	// ErrInvalidIDToken is returned when the ID token does not pass the
+	// verification.
+	ErrInvalidIDToken = errors.New("invalid ID token")
+)
+
+// Config identifies the relying party at the provider.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Claims are the verified claims of an ID token.
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	AuthorizedParty   string   `json:"azp"`
+	ExpiresAt         int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     bool     `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+// Valid is a no-op: the claims are checked by VerifyIDToken, against the
+// clock of the relying party.
+func (Claims) Valid() error {
+	return nil
+}
+
+type (
+	// RelyingParty logs users in with an OpenID Connect provider. The
+	// provider metadata is discovered on first use and cached, as are its
+	// keys.
+	RelyingParty struct {
+		config Config
+		client *http.Client
+		clock  clock.Clock
+
+		mutex        sync.Mutex
+		metadata     *metadata
+		keys         map[string]interface{}
+		keysLoadedAt time.Time
+	}
+
+	metadata struct {
+		Issuer                string   `json:"issuer"`
+		AuthorizationEndpoint string   `json:"authorization_endpoint"`
+		TokenEndpoint         string   `json:"token_endpoint"`
+		JWKSURI               string   `json:"jwks_uri"`
+		TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+	}
+
+	tokenResponse struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	audience []string
+)
+
+func NewRelyingParty(config Config, client *http.Client, clock clock.Clock) *RelyingParty {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &RelyingParty{config: config, client: client, clock: clock}
+}
+
+// RandomString returns a random URL safe string, suitable for the state, the
+// nonce and the PKCE code verifier.
+func RandomString() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge of the code verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the URL of the provider where the user logs in. The
+// state, the nonce and the code verifier must be kept to finish the login.
+func (rp *RelyingParty) AuthCodeURL(state string, nonce string, verifier string) (string, error) {
+	provider, err := rp.discover()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(provider.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", rp.config.ClientID)
+	query.Set("redirect_uri", rp.config.RedirectURL)
+	query.Set("scope", strings.Join(rp.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange trades the authorization code for the tokens of the user and
+// returns the claims of the verified ID token.
+func (rp *RelyingParty) Exchange(code string, verifier string, nonce string) (*Claims, error) {
+	provider, err := rp.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {rp.config.RedirectURL},
+		"code_verifier": {verifier},
+	}
+
+	useBasicAuth := rp.config.ClientSecret != "" && !provider.onlySupports("client_secret_post")
+	if !useBasicAuth {
+		form.Set("client_id", rp.config.ClientID)
+		if rp.config.ClientSecret != "" {
+			form.Set("client_secret", rp.config.ClientSecret)
+		}
+	}
+
+	request, err := http.NewRequest(http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if useBasicAuth {
+		request.SetBasicAuth(url.QueryEscape(rp.config.ClientID), url.QueryEscape(rp.config.ClientSecret))
+	}
+
+	var tokens tokenResponse
+	status, err := rp.doJSON(request, &tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("token request failed with status %d: %s %s",
+			status, tokens.Error, tokens.ErrorDescription)
+	}
+
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response without id_token")
+	}
+
+	return rp.VerifyIDToken(tokens.IDToken, nonce)
+}
+
+// VerifyIDToken checks the signature of the ID token with the keys of the
+// provider and its claims: the issuer, the audience, the times and the
+// nonce sent in the authorization request.
+func (rp *RelyingParty) VerifyIDToken(rawToken string, nonce string) (*Claims, error) {
+	provider, err := rp.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	parser := jwt.Parser{ValidMethods: validMethods(), SkipClaimsValidation: true}
+	_, err = parser.ParseWithClaims(rawToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return rp.key(kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidIDToken, err.Error())
+	}
+
+	if err = rp.checkClaims(&claims, provider.Issuer, nonce); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidIDToken, err.Error())
+	}
+
+	return &claims, nil
+}
+
+func (rp *RelyingParty) checkClaims(claims *Claims, issuer string, nonce string) error {
+	if claims.Issuer != issuer {
+		return errors.New("unexpected issuer")
+	}
+
+	if claims.Subject == "" {
+		return errors.New("missing subject")
+	}
+
+	if !claims.Audience.contains(rp.config.ClientID) {
+		return errors.New("unexpected audience")
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != rp.config.ClientID {
+		return errors.New("unexpected authorized party")
+	}
+
+	now := rp.clock.Now()
+	if claims.ExpiresAt == 0 || !now.Before(time.Unix(claims.ExpiresAt, 0).Add(leeway)) {
+		return errors.New("token expired")
+	}
+
+	if claims.IssuedAt != 0 && now.Add(leeway).Before(time.Unix(claims.IssuedAt, 0)) {
+		return errors.New("token issued in the future")
+	}
+
+	if nonce == "" || claims.Nonce != nonce {
+		return errors.New("unexpected nonce")
+	}
+
+	return nil
+}
+
+// discover loads the provider metadata once. The issuer it announces must be
+// the configured one.
+func (rp *RelyingParty) discover() (*metadata, error) {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+
+	if rp.metadata != nil {
+		return rp.metadata, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(rp.config.Issuer, "/") + "/.well-known/openid-configuration"
+	request, err := http.NewRequest(http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var provider metadata
+	status, err := rp.doJSON(request, &provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery failed with status %d", status)
+	}
+
+	if provider.Issuer != rp.config.Issuer {
+		return nil, fmt.Errorf("discovery announced issuer %s instead of %s", provider.Issuer, rp.config.Issuer)
+	}
+
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, errors.New("incomplete provider metadata")
+	}
+
+	rp.metadata = &provider
+	return rp.metadata, nil
+}
+
+// key returns the provider key with the kid, reloading the keys when it is
+// unknown. Tokens without kid are accepted when the provider has one key.
+func (rp *RelyingParty) key(kid string) (interface{}, error) {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+
+	if key := rp.findKey(kid); key != nil {
+		return key, nil
+	}
+
+	now := rp.clock.Now()
+	if rp.keys != nil && now.Before(rp.keysLoadedAt.Add(keysReloadInterval)) {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	keys, err := rp.loadKeys()
+	if err != nil {
+		return nil, err
+	}
+	rp.keys = keys
+	rp.keysLoadedAt = now
+
+	if key := rp.findKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+func (rp *RelyingParty) findKey(kid string) interface{} {
+	if kid == "" && len(rp.keys) == 1 {
+		for _, key := range rp.keys {
+			return key
+		}
+	}
+	return rp.keys[kid]
+}
+
+func (rp *RelyingParty) loadKeys() (map[string]interface{}, error) {
+	request, err := http.NewRequest(http.MethodGet, rp.metadata.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jwkSet
+	status, err := rp.doJSON(request, &set)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("keys request failed with status %d", status)
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (rp *RelyingParty) doJSON(request *http.Request, target interface{}) (int, error) {
+	response, err := rp.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+
+	if err = json.Unmarshal(body, target); err != nil && response.StatusCode == http.StatusOK {
+		return 0, err
+	}
+
+	return response.StatusCode, nil
+}
+
+func (m *metadata) onlySupports(method string) bool {
+	return len(m.TokenAuthMethods) == 1 && m.TokenAuthMethods[0] == method
+}
+
+func validMethods() []string {
+	methods := make([]string, 0, len(signingAlgorithms))
+	for method := range signingAlgorithms {
+		methods = append(methods, method)
+	}
+	return methods
+}
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+func (a audience) contains(value string) bool {
+	for _, item := range a {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
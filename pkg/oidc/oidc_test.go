@@ -0,0 +1,189 @@
+package oidc_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/internal/testutil"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/oidc"
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/oidc/oidctest"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const redirectURL = "https://app.test/callback"
+
+type login struct {
+	url      string
+	nonce    string
+	verifier string
+}
+
+func newRelyingParty(t *testing.T, secret string) (*oidctest.Provider, *oidc.RelyingParty, *testutil.Clock) {
+	provider, err := oidctest.NewProvider("list-manager", secret)
+	assert.Nil(t, err)
+	t.Cleanup(provider.Close)
+
+	clock := &testutil.Clock{Current: time.Now()}
+	return provider, oidc.NewRelyingParty(provider.Config(redirectURL), provider.Client(), clock), clock
+}
+
+func startLogin(t *testing.T, rp *oidc.RelyingParty) login {
+	nonce, err := oidc.RandomString()
+	assert.Nil(t, err)
+	verifier, err := oidc.RandomString()
+	assert.Nil(t, err)
+
+	authURL, err := rp.AuthCodeURL("state-1", nonce, verifier)
+	assert.Nil(t, err)
+	return login{authURL, nonce, verifier}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	provider, rp, _ := newRelyingParty(t, "")
+
+	login := startLogin(t, rp)
+	parsed, err := url.Parse(login.url)
+	assert.Nil(t, err)
+
+	query := parsed.Query()
+	assert.Equal(t, provider.Issuer+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "list-manager", query.Get("client_id"))
+	assert.Equal(t, redirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state-1", query.Get("state"))
+	assert.Equal(t, login.nonce, query.Get("nonce"))
+	assert.Equal(t, oidc.CodeChallenge(login.verifier), query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+}
+
+func TestExchange(t *testing.T) {
+	for name, secret := range map[string]string{"public client": "", "confidential client": "client-secret"} {
+		t.Run(name, func(t *testing.T) {
+			provider, rp, _ := newRelyingParty(t, secret)
+			login := startLogin(t, rp)
+
+			code, state, err := provider.Authorize(login.url, jwt.MapClaims{
+				"sub": "user-1", "email": "john@test.com", "email_verified": true,
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, "state-1", state)
+
+			claims, err := rp.Exchange(code, login.verifier, login.nonce)
+			assert.Nil(t, err)
+			assert.Equal(t, provider.Issuer, claims.Issuer)
+			assert.Equal(t, "user-1", claims.Subject)
+			assert.Equal(t, "john@test.com", claims.Email)
+			assert.True(t, claims.EmailVerified)
+
+			// A code is redeemed only once.
+			_, err = rp.Exchange(code, login.verifier, login.nonce)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestExchangeRejectsWrongVerifierAndNonce(t *testing.T) {
+	provider, rp, _ := newRelyingParty(t, "")
+	login := startLogin(t, rp)
+
+	code, _, err := provider.Authorize(login.url, jwt.MapClaims{"sub": "user-1"})
+	assert.Nil(t, err)
+	_, err = rp.Exchange(code, "another-verifier", login.nonce)
+	assert.NotNil(t, err)
+
+	code, _, err = provider.Authorize(login.url, jwt.MapClaims{"sub": "user-1"})
+	assert.Nil(t, err)
+	_, err = rp.Exchange(code, login.verifier, "another-nonce")
+	assert.True(t, errors.Is(err, oidc.ErrInvalidIDToken))
+}
+
+func TestVerifyIDTokenRejectsInvalidClaims(t *testing.T) {
+	provider, rp, clock := newRelyingParty(t, "")
+	now := clock.Now()
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": provider.Issuer, "sub": "user-1", "aud": "list-manager", "nonce": "n1",
+			"iat": now.Unix(), "exp": now.Add(5 * time.Minute).Unix(),
+		}
+	}
+
+	token, err := provider.SignIDToken(valid())
+	assert.Nil(t, err)
+	_, err = rp.VerifyIDToken(token, "n1")
+	assert.Nil(t, err)
+
+	tests := map[string]func(claims jwt.MapClaims){
+		"other issuer":      func(claims jwt.MapClaims) { claims["iss"] = "https://other.test" },
+		"other audience":    func(claims jwt.MapClaims) { claims["aud"] = "other-app" },
+		"missing subject":   func(claims jwt.MapClaims) { delete(claims, "sub") },
+		"unauthorized azp":  func(claims jwt.MapClaims) { claims["aud"] = []string{"list-manager", "other-app"} },
+		"expired":           func(claims jwt.MapClaims) { claims["exp"] = now.Add(-2 * time.Minute).Unix() },
+		"issued in future":  func(claims jwt.MapClaims) { claims["iat"] = now.Add(time.Hour).Unix() },
+		"other nonce":       func(claims jwt.MapClaims) { claims["nonce"] = "n2" },
+		"missing exp claim": func(claims jwt.MapClaims) { delete(claims, "exp") },
+	}
+
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := valid()
+			change(claims)
+
+			token, err := provider.SignIDToken(claims)
+			assert.Nil(t, err)
+
+			_, err = rp.VerifyIDToken(token, "n1")
+			assert.True(t, errors.Is(err, oidc.ErrInvalidIDToken), "expected an invalid ID token, got %v", err)
+		})
+	}
+
+	t.Run("symmetric algorithm", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid()).SignedString([]byte("list-manager"))
+		assert.Nil(t, err)
+
+		_, err = rp.VerifyIDToken(token, "n1")
+		assert.True(t, errors.Is(err, oidc.ErrInvalidIDToken))
+	})
+}
+
+func TestVerifyIDTokenReloadsRotatedKeys(t *testing.T) {
+	provider, rp, clock := newRelyingParty(t, "")
+	claims := jwt.MapClaims{
+		"iss": provider.Issuer, "sub": "user-1", "aud": "list-manager", "nonce": "n1",
+		"exp": clock.Now().Add(time.Hour).Unix(),
+	}
+
+	token, err := provider.SignIDToken(claims)
+	assert.Nil(t, err)
+	_, err = rp.VerifyIDToken(token, "n1")
+	assert.Nil(t, err)
+
+	assert.Nil(t, provider.RotateKey())
+	token, err = provider.SignIDToken(claims)
+	assert.Nil(t, err)
+
+	// The keys were just loaded, so the unknown key is not fetched yet.
+	_, err = rp.VerifyIDToken(token, "n1")
+	assert.NotNil(t, err)
+
+	clock.Advance(2 * time.Minute)
+	_, err = rp.VerifyIDToken(token, "n1")
+	assert.Nil(t, err)
+}
+
+func TestDiscoveryRequiresTheConfiguredIssuer(t *testing.T) {
+	provider, err := oidctest.NewProvider("list-manager", "")
+	assert.Nil(t, err)
+	defer provider.Close()
+
+	config := provider.Config(redirectURL)
+	config.Issuer += "/"
+	rp := oidc.NewRelyingParty(config, provider.Client(), &testutil.Clock{Current: time.Now()})
+
+	_, err = rp.AuthCodeURL("state", "nonce", "verifier")
+	assert.NotNil(t, err)
+}
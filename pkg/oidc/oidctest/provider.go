@@ -0,0 +1,261 @@
+// Package oidctest provides an in-process OpenID Connect provider to test the
+// relying party without a real identity provider.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/oidc"
+	"github.com/dgrijalva/jwt-go"
+)
+
+type (
+	// Provider is an OpenID Connect provider serving the discovery, the keys
+	// and the token endpoints. Users log in with Authorize instead of the
+	// authorization endpoint, which is never called.
+	Provider struct {
+		Issuer       string
+		ClientID     string
+		ClientSecret string
+
+		server *httptest.Server
+		mutex  sync.Mutex
+		kid    string
+		key    *rsa.PrivateKey
+		grants map[string]grant
+	}
+
+	grant struct {
+		redirectURI string
+		challenge   string
+		claims      jwt.MapClaims
+	}
+)
+
+// NewProvider starts a provider for the client. With an empty secret the
+// client is public and authenticates only with PKCE.
+func NewProvider(clientID string, clientSecret string) (*Provider, error) {
+	provider := &Provider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		grants:       map[string]grant{},
+	}
+
+	if err := provider.RotateKey(); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("/token", provider.token)
+	mux.HandleFunc("/keys", provider.keys)
+
+	provider.server = httptest.NewServer(mux)
+	provider.Issuer = provider.server.URL
+	return provider, nil
+}
+
+func (p *Provider) Close() {
+	p.server.Close()
+}
+
+// Client returns an HTTP client that reaches the provider.
+func (p *Provider) Client() *http.Client {
+	return p.server.Client()
+}
+
+// Config returns the relying party configuration for the client.
+func (p *Provider) Config(redirectURL string) oidc.Config {
+	return oidc.Config{
+		Issuer:       p.Issuer,
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  redirectURL,
+	}
+}
+
+// RotateKey replaces the key that signs the ID tokens.
+func (p *Provider) RotateKey() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+
+	kid := make([]byte, 8)
+	if _, err = rand.Read(kid); err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	p.key = key
+	p.kid = base64.RawURLEncoding.EncodeToString(kid)
+	p.mutex.Unlock()
+	return nil
+}
+
+// Authorize logs a user in at the authorization URL built by the relying
+// party and returns the code and the state of the redirect. The claims are
+// added to the ID token, after the default ones, which they may replace.
+func (p *Provider) Authorize(authorizationURL string, claims jwt.MapClaims) (string, string, error) {
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	query := parsed.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != p.ClientID {
+		return "", "", errors.New("invalid authorization request")
+	}
+
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		return "", "", errors.New("authorization request without PKCE")
+	}
+
+	now := time.Now()
+	idClaims := jwt.MapClaims{
+		"iss":   p.Issuer,
+		"aud":   p.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+	for name, value := range claims {
+		idClaims[name] = value
+	}
+
+	code, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	p.mutex.Lock()
+	p.grants[code] = grant{
+		redirectURI: query.Get("redirect_uri"),
+		challenge:   query.Get("code_challenge"),
+		claims:      idClaims,
+	}
+	p.mutex.Unlock()
+
+	return code, query.Get("state"), nil
+}
+
+// SignIDToken signs the claims with the current key of the provider.
+func (p *Provider) SignIDToken(claims jwt.MapClaims) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+	return token.SignedString(p.key)
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"jwks_uri":                              p.Issuer + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+	})
+}
+
+func (p *Provider) keys(w http.ResponseWriter, r *http.Request) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": p.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+// token redeems a code once, for the client it was issued to, with the
+// redirect URI and the PKCE verifier of the authorization request.
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	if !p.authenticateClient(r) {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	p.mutex.Lock()
+	code := r.PostForm.Get("code")
+	grant, ok := p.grants[code]
+	delete(p.grants, code)
+	p.mutex.Unlock()
+
+	if !ok || grant.redirectURI != r.PostForm.Get("redirect_uri") ||
+		oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != grant.challenge {
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	idToken, err := p.SignIDToken(grant.claims)
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": code,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) authenticateClient(r *http.Request) bool {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		clientSecret = r.PostForm.Get("client_secret")
+	}
+
+	return clientID == p.ClientID && clientSecret == p.ClientSecret
+}
+
+func tokenError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{
+		"error":             code,
+		"error_description": fmt.Sprintf("%s rejected by the test provider", code),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,137 @@
+// Package rank generates lexicographic ranks used to keep user defined
+// orderings. A new rank can always be generated between two existing ones,
+// so moving an element only rewrites that element. Ranks grow as elements are
+// placed in the same gap, so the ordering is spread again once a rank grows
+// past RebalanceLength.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const (
+	// MaxLength is the longest rank that can be stored.
+	MaxLength = 255
+	// RebalanceLength is the length past which the ordering must be spread
+	// again. A new rank is at most one digit longer than its neighbors, so
+	// ranks never reach MaxLength while they are spread in time.
+	RebalanceLength = 200
+)
+
+var (
+	ErrInvalidRange = errors.New("lower rank must sort before upper rank")
+	ErrTooLong      = errors.New("rank too long, the ordering must be spread again")
+)
+
+// Between returns a rank that sorts strictly between lower and upper. An
+// empty lower means the start of the ordering and an empty upper means the
+// end of it.
+func Between(lower string, upper string) (string, error) {
+	if !isValid(lower) || !isValid(upper) || (upper != "" && lower >= upper) {
+		return "", ErrInvalidRange
+	}
+
+	value := midpoint(lower, upper)
+	if len(value) > MaxLength {
+		return "", ErrTooLong
+	}
+	return value, nil
+}
+
+// NeedsRebalance reports whether the rank grew past RebalanceLength.
+func NeedsRebalance(value string) bool {
+	return len(value) > RebalanceLength
+}
+
+// Spread returns count increasing ranks, evenly spaced and as short as
+// possible, to renumber an ordering whose ranks grew too long.
+func Spread(count int) []string {
+	width, capacity := 1, len(digits)
+	for capacity <= count {
+		width++
+		capacity *= len(digits)
+	}
+
+	ranks := make([]string, count)
+	for i := range ranks {
+		value := (i + 1) * capacity / (count + 1)
+		rank := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			rank[j] = digits[value%len(digits)]
+			value /= len(digits)
+		}
+		// A trailing digit other than the smallest one keeps room for a rank
+		// before every rank.
+		ranks[i] = string(rank) + "i"
+	}
+	return ranks
+}
+
+func midpoint(lower string, upper string) string {
+	if upper != "" {
+		// Keep the common prefix, treating a missing lower digit as the
+		// smallest one.
+		n := 0
+		for n < len(upper) && digitAt(lower, n) == upper[n] {
+			n++
+		}
+
+		if n > 0 {
+			return upper[:n] + midpoint(suffix(lower, n), upper[n:])
+		}
+	}
+
+	lowerDigit := 0
+	if lower != "" {
+		lowerDigit = strings.IndexByte(digits, lower[0])
+	}
+
+	upperDigit := len(digits)
+	if upper != "" {
+		upperDigit = strings.IndexByte(digits, upper[0])
+	}
+
+	if upperDigit-lowerDigit > 1 {
+		return string(digits[(lowerDigit+upperDigit+1)/2])
+	}
+
+	// The first digits are consecutive: a longer rank starting with the
+	// upper digit sorts before upper, otherwise extend lower.
+	if len(upper) > 1 {
+		return upper[:1]
+	}
+
+	return string(digits[lowerDigit]) + midpoint(suffix(lower, 1), "")
+}
+
+func digitAt(value string, index int) byte {
+	if index < len(value) {
+		return value[index]
+	}
+	return digits[0]
+}
+
+func suffix(value string, index int) string {
+	if index < len(value) {
+		return value[index:]
+	}
+	return ""
+}
+
+// isValid reports whether value only has rank digits and does not end with
+// the smallest digit, which would leave no room for a rank before it.
+func isValid(value string) bool {
+	if value != "" && value[len(value)-1] == digits[0] {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		if strings.IndexByte(digits, value[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package rank_test
+
+import (
+	"strings"
+	"testing"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/rank"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBetween(t *testing.T) {
+	cases := []struct {
+		lower string
+		upper string
+	}{
+		{"", ""},
+		{"", "i"},
+		{"i", ""},
+		{"a", "b"},
+		{"a", "a1"},
+		{"0000000000001i", "0000000000002i"},
+		{"", "0000000000001i"},
+		{"zz", ""},
+		{"a0i", "a1"},
+	}
+
+	for _, c := range cases {
+		value, err := rank.Between(c.lower, c.upper)
+		assert.Nil(t, err)
+		assert.True(t, value > c.lower, "%q should sort after %q", value, c.lower)
+		if c.upper != "" {
+			assert.True(t, value < c.upper, "%q should sort before %q", value, c.upper)
+		}
+	}
+}
+
+func TestBetweenRepeatedInsertions(t *testing.T) {
+	lower, upper := "a", "b"
+	for i := 0; i < 100; i++ {
+		value, err := rank.Between(lower, upper)
+		assert.Nil(t, err)
+		assert.True(t, lower < value && value < upper)
+		upper = value
+	}
+
+	last := ""
+	for i := 0; i < 100; i++ {
+		value, err := rank.Between(last, "")
+		assert.Nil(t, err)
+		assert.True(t, value > last)
+		last = value
+	}
+}
+
+func TestBetweenInvalidRange(t *testing.T) {
+	_, err := rank.Between("b", "a")
+	assert.Equal(t, rank.ErrInvalidRange, err)
+
+	_, err = rank.Between("a", "a")
+	assert.Equal(t, rank.ErrInvalidRange, err)
+
+	_, err = rank.Between("A", "")
+	assert.Equal(t, rank.ErrInvalidRange, err)
+
+	_, err = rank.Between("", "a0")
+	assert.Equal(t, rank.ErrInvalidRange, err)
+}
+
+func TestSpread(t *testing.T) {
+	for _, count := range []int{0, 1, 35, 36, 1000} {
+		ranks := rank.Spread(count)
+		assert.Len(t, ranks, count)
+
+		lower := ""
+		for _, value := range ranks {
+			assert.False(t, rank.NeedsRebalance(value))
+			between, err := rank.Between(lower, value)
+			assert.Nil(t, err, "no room between %q and %q", lower, value)
+			assert.True(t, between > lower && between < value)
+			lower = value
+		}
+	}
+	assert.Equal(t, []string{"ii"}, rank.Spread(1))
+}
+
+func TestBetweenRequiresRebalanceBeforeMaxLength(t *testing.T) {
+	// Appending to the end makes the ranks grow until the ordering must be
+	// spread again, which happens before they are too long to be stored.
+	last := ""
+	for !rank.NeedsRebalance(last) {
+		value, err := rank.Between(last, "")
+		assert.Nil(t, err)
+		last = value
+	}
+	assert.LessOrEqual(t, len(last), rank.MaxLength)
+
+	_, err := rank.Between(strings.Repeat("z", rank.MaxLength), "")
+	assert.Equal(t, rank.ErrTooLong, err)
+}
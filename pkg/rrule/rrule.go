@@ -0,0 +1,445 @@
+// Package rrule parses and expands the subset of RFC 5545 recurrence rules
+// supported for recurring items: FREQ, INTERVAL, BYDAY, BYMONTHDAY, COUNT and
+// UNTIL. Weeks start on Monday.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the period a rule repeats on.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+const (
+	untilDateLayout     = "20060102"
+	untilDateTimeLayout = "20060102T150405Z"
+
+	// maxYears bounds the search for the next occurrence, so rules whose
+	// parts never match, like a 5th Monday on the 1st, do not loop forever.
+	maxYears = 50
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum is a BYDAY value. A non zero Ordinal selects the nth weekday of
+// the month or year, counting from the end when negative.
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// Rule is a parsed recurrence rule. Count and Until are zero when not set.
+// An Until without time, in the YYYYMMDD format, includes the whole day in
+// the timezone of the occurrences.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	Count      int
+	Until      time.Time
+	UntilDate  bool
+}
+
+// Parse parses a rule such as FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE. The RRULE:
+// prefix is optional.
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, invalid("empty rule")
+	}
+
+	rule := Rule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ";") {
+		name, partValue, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !ok || partValue == "" {
+			return nil, invalid(fmt.Sprintf("malformed part '%s'", part))
+		}
+
+		if seen[name] {
+			return nil, invalid(fmt.Sprintf("repeated part %s", name))
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			err = rule.parseFreq(partValue)
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(name, partValue)
+		case "COUNT":
+			rule.Count, err = parsePositive(name, partValue)
+		case "UNTIL":
+			err = rule.parseUntil(partValue)
+		case "BYDAY":
+			err = rule.parseByDay(partValue)
+		case "BYMONTHDAY":
+			err = rule.parseByMonthDay(partValue)
+		default:
+			err = invalid(fmt.Sprintf("unsupported part %s", name))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &rule, rule.validate(seen)
+}
+
+func (rule *Rule) parseFreq(value string) error {
+	switch freq := Frequency(strings.ToUpper(value)); freq {
+	case Daily, Weekly, Monthly, Yearly:
+		rule.Freq = freq
+		return nil
+	default:
+		return invalid(fmt.Sprintf("unsupported frequency %s", value))
+	}
+}
+
+func (rule *Rule) parseUntil(value string) error {
+	until, err := time.Parse(untilDateTimeLayout, value)
+	if err == nil {
+		rule.Until = until
+		return nil
+	}
+
+	until, err = time.Parse(untilDateLayout, value)
+	if err != nil {
+		return invalid("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+	}
+
+	rule.Until = until
+	rule.UntilDate = true
+	return nil
+}
+
+func (rule *Rule) parseByDay(value string) error {
+	for _, day := range strings.Split(strings.ToUpper(value), ",") {
+		if len(day) < 2 {
+			return invalid(fmt.Sprintf("invalid BYDAY value '%s'", day))
+		}
+
+		weekday, ok := weekdays[day[len(day)-2:]]
+		if !ok {
+			return invalid(fmt.Sprintf("invalid BYDAY value '%s'", day))
+		}
+
+		ordinal := 0
+		if prefix := day[:len(day)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return invalid(fmt.Sprintf("invalid BYDAY value '%s'", day))
+			}
+			ordinal = n
+		}
+
+		rule.ByDay = append(rule.ByDay, WeekdayNum{Ordinal: ordinal, Weekday: weekday})
+	}
+
+	return nil
+}
+
+func (rule *Rule) parseByMonthDay(value string) error {
+	for _, day := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(day)
+		if err != nil || n == 0 || n < -31 || n > 31 {
+			return invalid(fmt.Sprintf("invalid BYMONTHDAY value '%s'", day))
+		}
+
+		rule.ByMonthDay = append(rule.ByMonthDay, n)
+	}
+
+	return nil
+}
+
+func (rule *Rule) validate(seen map[string]bool) error {
+	if rule.Freq == "" {
+		return invalid("FREQ is required")
+	}
+
+	if seen["COUNT"] && seen["UNTIL"] {
+		return invalid("COUNT and UNTIL cannot be used together")
+	}
+
+	if rule.Freq == Weekly && len(rule.ByMonthDay) > 0 {
+		return invalid("BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+
+	for _, day := range rule.ByDay {
+		if day.Ordinal == 0 {
+			continue
+		}
+
+		switch {
+		case rule.Freq != Monthly && rule.Freq != Yearly:
+			return invalid("BYDAY ordinals require FREQ=MONTHLY or FREQ=YEARLY")
+		case rule.Freq == Monthly && (day.Ordinal < -5 || day.Ordinal > 5):
+			return invalid("BYDAY ordinals must be between -5 and 5 with FREQ=MONTHLY")
+		}
+	}
+
+	return nil
+}
+
+// String returns the rule in its canonical form, with the parts in a fixed
+// order and the default INTERVAL omitted.
+func (rule *Rule) String() string {
+	parts := []string{"FREQ=" + string(rule.Freq)}
+	if rule.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", rule.Interval))
+	}
+
+	if len(rule.ByDay) > 0 {
+		days := make([]string, len(rule.ByDay))
+		for i, day := range rule.ByDay {
+			days[i] = weekdayCode(day.Weekday)
+			if day.Ordinal != 0 {
+				days[i] = strconv.Itoa(day.Ordinal) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if len(rule.ByMonthDay) > 0 {
+		days := make([]string, len(rule.ByMonthDay))
+		for i, day := range rule.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	if rule.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", rule.Count))
+	}
+
+	if rule.UntilDate {
+		parts = append(parts, "UNTIL="+rule.Until.Format(untilDateLayout))
+	} else if !rule.Until.IsZero() {
+		parts = append(parts, "UNTIL="+rule.Until.UTC().Format(untilDateTimeLayout))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next returns the occurrence that follows current, the nth occurrence of
+// the series. The occurrences keep the time of day of current in its
+// location. It returns false when the series has ended.
+func (rule *Rule) Next(current time.Time, n int) (time.Time, bool) {
+	if rule.Count > 0 && n >= rule.Count {
+		return time.Time{}, false
+	}
+
+	// Any occurrence belongs to a period of the series, so the periods can
+	// be counted from the current one instead of from the first occurrence.
+	start := periodStart(rule.Freq, current)
+	limit := start.AddDate(maxYears, 0, 0)
+	for i := 0; ; i++ {
+		period := addPeriods(rule.Freq, start, i*rule.Interval)
+		if period.After(limit) {
+			return time.Time{}, false
+		}
+
+		for _, day := range rule.expand(period, current) {
+			occurrence := time.Date(
+				day.Year(), day.Month(), day.Day(),
+				current.Hour(), current.Minute(), current.Second(), current.Nanosecond(),
+				current.Location(),
+			)
+			if !occurrence.After(current) {
+				continue
+			}
+
+			if rule.isAfterUntil(occurrence) {
+				return time.Time{}, false
+			}
+
+			return occurrence, true
+		}
+	}
+}
+
+func (rule *Rule) isAfterUntil(occurrence time.Time) bool {
+	if rule.Until.IsZero() {
+		return false
+	}
+
+	if rule.UntilDate {
+		year, month, day := occurrence.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).After(rule.Until)
+	}
+
+	return occurrence.After(rule.Until)
+}
+
+// expand returns the days of the period that match the rule, in order. The
+// current occurrence gives the weekday, day and month used when the rule has
+// no BYDAY or BYMONTHDAY.
+func (rule *Rule) expand(period time.Time, current time.Time) []time.Time {
+	var days []time.Time
+	switch rule.Freq {
+	case Daily:
+		days = []time.Time{period}
+	case Weekly:
+		days = daysBetween(period, period.AddDate(0, 0, 7))
+		if len(rule.ByDay) == 0 {
+			days = filter(days, func(day time.Time) bool { return day.Weekday() == current.Weekday() })
+		}
+	case Monthly:
+		days = daysBetween(period, period.AddDate(0, 1, 0))
+		if len(rule.ByDay) == 0 && len(rule.ByMonthDay) == 0 {
+			days = filter(days, func(day time.Time) bool { return day.Day() == current.Day() })
+		}
+	case Yearly:
+		days = daysBetween(period, period.AddDate(1, 0, 0))
+		if len(rule.ByDay) == 0 && len(rule.ByMonthDay) == 0 {
+			days = filter(days, func(day time.Time) bool {
+				return day.Month() == current.Month() && day.Day() == current.Day()
+			})
+		}
+	}
+
+	if len(rule.ByMonthDay) > 0 {
+		days = filter(days, rule.matchesMonthDay)
+	}
+
+	if len(rule.ByDay) > 0 {
+		days = filter(days, rule.matchesWeekday)
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+func (rule *Rule) matchesMonthDay(day time.Time) bool {
+	last := daysIn(day.Year(), day.Month())
+	for _, monthDay := range rule.ByMonthDay {
+		if monthDay == day.Day() || (monthDay < 0 && last+monthDay+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday tells if the day matches a BYDAY value. Ordinals count the
+// weekdays of the month, or of the year for yearly rules.
+func (rule *Rule) matchesWeekday(day time.Time) bool {
+	offset, length := day.Day()-1, daysIn(day.Year(), day.Month())
+	if rule.Freq == Yearly {
+		offset = day.YearDay() - 1
+		length = time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	}
+
+	for _, weekday := range rule.ByDay {
+		if weekday.Weekday != day.Weekday() {
+			continue
+		}
+
+		switch {
+		case weekday.Ordinal == 0:
+			return true
+		case weekday.Ordinal > 0 && offset/7+1 == weekday.Ordinal:
+			return true
+		case weekday.Ordinal < 0 && (length-1-offset)/7+1 == -weekday.Ordinal:
+			return true
+		}
+	}
+	return false
+}
+
+// periodStart returns the first day of the period containing the time, at
+// midnight in its location.
+func periodStart(freq Frequency, t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch freq {
+	case Weekly:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case Monthly:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case Yearly:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+func addPeriods(freq Frequency, start time.Time, n int) time.Time {
+	switch freq {
+	case Weekly:
+		return start.AddDate(0, 0, 7*n)
+	case Monthly:
+		return start.AddDate(0, n, 0)
+	case Yearly:
+		return start.AddDate(n, 0, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+func daysBetween(start time.Time, end time.Time) []time.Time {
+	var days []time.Time
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func filter(days []time.Time, keep func(time.Time) bool) []time.Time {
+	kept := days[:0]
+	for _, day := range days {
+		if keep(day) {
+			kept = append(kept, day)
+		}
+	}
+	return kept
+}
+
+func weekdayCode(weekday time.Weekday) string {
+	for code, day := range weekdays {
+		if day == weekday {
+			return code
+		}
+	}
+	return ""
+}
+
+func parsePositive(name string, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, invalid(fmt.Sprintf("%s must be a positive integer", name))
+	}
+	return n, nil
+}
+
+func invalid(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRule, reason)
+}
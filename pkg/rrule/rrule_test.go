@@ -0,0 +1,164 @@
+package rrule_test
+
+import (
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/rrule"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		value     string
+		canonical string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:freq=weekly;INTERVAL=1;byday=mo,we", "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=12", "FREQ=MONTHLY;BYDAY=-1FR;COUNT=12"},
+		{"FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1,-1", "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1,-1"},
+		{"FREQ=YEARLY;UNTIL=20301231", "FREQ=YEARLY;UNTIL=20301231"},
+		{"FREQ=DAILY;UNTIL=20301231T235959Z", "FREQ=DAILY;UNTIL=20301231T235959Z"},
+	}
+
+	for _, c := range cases {
+		rule, err := rrule.Parse(c.value)
+		assert.Nil(t, err, c.value)
+		assert.Equal(t, c.canonical, rule.String())
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	values := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=2;UNTIL=20301231",
+		"FREQ=DAILY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=DAILY;UNTIL=2030-12-31",
+		"FREQ",
+	}
+
+	for _, value := range values {
+		_, err := rrule.Parse(value)
+		assert.ErrorIs(t, err, rrule.ErrInvalidRule, value)
+	}
+}
+
+func TestNext(t *testing.T) {
+	saoPaulo, _ := time.LoadLocation("America/Sao_Paulo")
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	cases := []struct {
+		rule     string
+		current  time.Time
+		expected []time.Time
+	}{
+		{
+			"FREQ=DAILY;INTERVAL=2",
+			time.Date(2026, 2, 27, 8, 30, 0, 0, time.UTC),
+			[]time.Time{
+				time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC),
+				time.Date(2026, 3, 3, 8, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			time.Date(2026, 10, 19, 9, 0, 0, 0, saoPaulo),
+			[]time.Time{
+				time.Date(2026, 10, 21, 9, 0, 0, 0, saoPaulo),
+				time.Date(2026, 11, 2, 9, 0, 0, 0, saoPaulo),
+				time.Date(2026, 11, 4, 9, 0, 0, 0, saoPaulo),
+			},
+		},
+		{
+			"FREQ=WEEKLY",
+			time.Date(2026, 10, 29, 18, 0, 0, 0, newYork),
+			[]time.Time{
+				time.Date(2026, 11, 5, 18, 0, 0, 0, newYork),
+			},
+		},
+		{
+			"FREQ=MONTHLY",
+			time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC),
+			[]time.Time{
+				time.Date(2026, 3, 31, 10, 0, 0, 0, time.UTC),
+				time.Date(2026, 5, 31, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			"FREQ=MONTHLY;BYMONTHDAY=-1",
+			time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC),
+			[]time.Time{
+				time.Date(2026, 2, 28, 10, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 31, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			"FREQ=MONTHLY;BYDAY=2TU,-1FR",
+			time.Date(2026, 10, 13, 10, 0, 0, 0, time.UTC),
+			[]time.Time{
+				time.Date(2026, 10, 30, 10, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 10, 10, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 27, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			"FREQ=YEARLY",
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			[]time.Time{
+				time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			"FREQ=YEARLY;BYDAY=-1MO",
+			time.Date(2026, 12, 28, 0, 0, 0, 0, time.UTC),
+			[]time.Time{
+				time.Date(2027, 12, 27, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		rule, err := rrule.Parse(c.rule)
+		assert.Nil(t, err)
+
+		current := c.current
+		for i, expected := range c.expected {
+			next, ok := rule.Next(current, i+1)
+			assert.True(t, ok, c.rule)
+			assert.True(t, expected.Equal(next), "%s: expected %s, got %s", c.rule, expected, next)
+			current = next
+		}
+	}
+}
+
+func TestNextEndOfSeries(t *testing.T) {
+	current := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	rule, _ := rrule.Parse("FREQ=DAILY;COUNT=3")
+	_, ok := rule.Next(current, 2)
+	assert.True(t, ok)
+	_, ok = rule.Next(current, 3)
+	assert.False(t, ok)
+
+	rule, _ = rrule.Parse("FREQ=DAILY;UNTIL=20261019")
+	next, ok := rule.Next(current, 1)
+	assert.True(t, ok)
+	_, ok = rule.Next(next, 2)
+	assert.False(t, ok)
+
+	rule, _ = rrule.Parse("FREQ=DAILY;UNTIL=20261019T080000Z")
+	_, ok = rule.Next(current, 1)
+	assert.False(t, ok)
+
+	rule, _ = rrule.Parse("FREQ=MONTHLY;BYMONTHDAY=1;BYDAY=5MO")
+	_, ok = rule.Next(current, 1)
+	assert.False(t, ok)
+}
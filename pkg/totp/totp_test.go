@@ -0,0 +1,68 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeMatchesRFCVectors(t *testing.T) {
+	// The RFC lists 8 digit codes; the 6 digit codes are their suffixes.
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range vectors {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, code, "code at %d", unix)
+	}
+}
+
+func TestValidateAcceptsAdjacentPeriods(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := totp.Step(now)
+
+	for _, offset := range []int64{-1, 0, 1} {
+		code, err := totp.Code(rfcSecret, step+offset)
+		assert.Nil(t, err)
+
+		matched, ok := totp.Validate(rfcSecret, code, now)
+		assert.True(t, ok)
+		assert.Equal(t, step+offset, matched)
+	}
+
+	code, err := totp.Code(rfcSecret, step+2)
+	assert.Nil(t, err)
+	_, ok := totp.Validate(rfcSecret, code, now)
+	assert.False(t, ok)
+
+	_, ok = totp.Validate(rfcSecret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	assert.Nil(t, err)
+	assert.Len(t, secret, 32)
+
+	uri, err := url.Parse(totp.URI("List Manager", "john", secret))
+	assert.Nil(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/List Manager:john", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "List Manager", uri.Query().Get("issuer"))
+}
@@ -0,0 +1,10 @@
+package utils
+
+import "regexp"
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// IsHexColor reports whether value is a color in the #RRGGBB format.
+func IsHexColor(value string) bool {
+	return hexColorPattern.MatchString(value)
+}
@@ -2,8 +2,10 @@ package utils
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/constants"
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,3 +18,33 @@ func GetIDFromRequest(c *gin.Context, key string) (uint64, error) {
 
 	return id, err
 }
+
+// GetBoolFromRequest reads an optional boolean query parameter, false when it
+// is not informed.
+func GetBoolFromRequest(c *gin.Context, key string) (bool, error) {
+	value := c.Query(key)
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", key)
+	}
+
+	return parsed, nil
+}
+
+func GetPageSizeFromRequest(c *gin.Context) (int, error) {
+	limitStr := c.Query("limit")
+	if limitStr == "" {
+		return constants.DefaultPageSize, nil
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > constants.MaxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", constants.MaxPageSize)
+	}
+
+	return limit, nil
+}
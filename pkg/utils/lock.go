@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WithLock runs fn holding the MySQL named lock, which is shared by every
+// instance of the application. It waits up to timeout for the lock and tells
+// whether fn ran. The lock is held by a connection of its own, so fn may use
+// the db freely.
+func WithLock(db *gorm.DB, name string, timeout time.Duration, fn func() error) (bool, error) {
+	acquired := false
+	err := db.Connection(func(conn *gorm.DB) error {
+		var locked sql.NullInt64
+		err := conn.Raw("SELECT GET_LOCK(?, ?)", name, int(timeout/time.Second)).Scan(&locked).Error
+		if err != nil || !locked.Valid || locked.Int64 != 1 {
+			return err
+		}
+
+		acquired = true
+		defer conn.Exec("SELECT RELEASE_LOCK(?)", name)
+		return fn()
+	})
+	return acquired, err
+}
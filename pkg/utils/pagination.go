@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"git.vibbra.com.br/vinicius-1663626255/vibbra-list-manager/pkg/models"
+	"gorm.io/gorm"
+)
+
+// ApplyKeysetPagination restricts the query to the rows after the cursor and
+// orders it by column, using id as tie breaker. It fetches limit+1 rows so
+// the caller can tell whether there is a next page.
+func ApplyKeysetPagination(
+	query *gorm.DB,
+	column string,
+	order models.SortOrder,
+	cursor *models.PageCursor,
+	limit int,
+) *gorm.DB {
+	direction, operator := "ASC", ">"
+	if order == models.SortDesc {
+		direction, operator = "DESC", "<"
+	}
+
+	if cursor != nil {
+		if column == "id" {
+			query = query.Where(fmt.Sprintf("id %s ?", operator), cursor.ID)
+		} else {
+			query = query.Where(
+				fmt.Sprintf("%s %s ? OR (%s = ? AND id %s ?)", column, operator, column, operator),
+				cursor.Value, cursor.Value, cursor.ID,
+			)
+		}
+	}
+
+	return query.
+		Order(fmt.Sprintf("%s %s", column, direction)).
+		Order(fmt.Sprintf("id %s", direction)).
+		Limit(limit + 1)
+}
+
+// EscapeLike escapes the LIKE wildcards of a user provided search term.
+func EscapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
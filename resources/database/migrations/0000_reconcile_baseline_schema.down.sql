@@ -0,0 +1,3 @@
+-- The reconciled schema is the one of 0001, so only the bookkeeping table
+-- is dropped.
+DROP TABLE IF EXISTS baseline_item_without_user;
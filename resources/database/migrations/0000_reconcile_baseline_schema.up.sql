@@ -0,0 +1,71 @@
+-- Databases created before the migrations by the AutoMigrate of the first
+-- version have the tables of 0001, which then skips them, but with the owner
+-- of the lists in list.owner, longtext columns and no foreign keys. They are
+-- reconciled here with the schema of 0001, and the foreign keys get the names
+-- MySQL gives to the unnamed ones of 0001, which later migrations rely on.
+-- Every statement is a no-op on databases without list.owner.
+SET @baseline = (
+	SELECT COUNT(*) FROM information_schema.columns
+	WHERE table_schema = DATABASE() AND table_name = 'list' AND column_name = 'owner'
+);
+
+-- Items without a responsible user were allowed, but 0018 makes the
+-- responsible user the first assignee. They get the first user for now and
+-- 0027 takes the assignment back.
+CREATE TABLE IF NOT EXISTS baseline_item_without_user (
+	item_id BIGINT UNSIGNED NOT NULL,
+	CONSTRAINT pk_baseline_item_without_user PRIMARY KEY (item_id)
+);
+
+SET @statement = IF(@baseline > 0,
+	'INSERT INTO baseline_item_without_user (item_id) SELECT id FROM item WHERE user_id IS NULL',
+	'DO 0');
+PREPARE reconcile FROM @statement;
+EXECUTE reconcile;
+DEALLOCATE PREPARE reconcile;
+
+SET @statement = IF(@baseline > 0,
+	'UPDATE item SET user_id = (SELECT MIN(id) FROM user) WHERE user_id IS NULL',
+	'DO 0');
+PREPARE reconcile FROM @statement;
+EXECUTE reconcile;
+DEALLOCATE PREPARE reconcile;
+
+SET @statement = IF(@baseline > 0,
+	'ALTER TABLE user
+		MODIFY COLUMN name VARCHAR(255) NOT NULL,
+		MODIFY COLUMN email VARCHAR(255) NOT NULL,
+		MODIFY COLUMN login VARCHAR(255) NOT NULL,
+		MODIFY COLUMN password VARCHAR(255) NOT NULL',
+	'DO 0');
+PREPARE reconcile FROM @statement;
+EXECUTE reconcile;
+DEALLOCATE PREPARE reconcile;
+
+SET @statement = IF(@baseline > 0,
+	'ALTER TABLE list
+		CHANGE COLUMN owner user_id BIGINT UNSIGNED,
+		MODIFY COLUMN title VARCHAR(255) NOT NULL',
+	'DO 0');
+PREPARE reconcile FROM @statement;
+EXECUTE reconcile;
+DEALLOCATE PREPARE reconcile;
+
+SET @statement = IF(@baseline > 0,
+	'ALTER TABLE list ADD CONSTRAINT list_ibfk_1 FOREIGN KEY (user_id) REFERENCES user(id)',
+	'DO 0');
+PREPARE reconcile FROM @statement;
+EXECUTE reconcile;
+DEALLOCATE PREPARE reconcile;
+
+SET @statement = IF(@baseline > 0,
+	'ALTER TABLE item
+		MODIFY COLUMN user_id BIGINT UNSIGNED NOT NULL,
+		MODIFY COLUMN list_id BIGINT UNSIGNED NOT NULL,
+		MODIFY COLUMN title VARCHAR(255) NOT NULL,
+		ADD CONSTRAINT item_ibfk_1 FOREIGN KEY (user_id) REFERENCES user(id),
+		ADD CONSTRAINT item_ibfk_2 FOREIGN KEY (list_id) REFERENCES list(id)',
+	'DO 0');
+PREPARE reconcile FROM @statement;
+EXECUTE reconcile;
+DEALLOCATE PREPARE reconcile;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS item;
+
+DROP TABLE IF EXISTS list;
+
+DROP TABLE IF EXISTS user;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS list_member (
+	list_id BIGINT UNSIGNED NOT NULL,
+	user_id BIGINT UNSIGNED NOT NULL,
+	role VARCHAR(16) NOT NULL,
+	created_at DATETIME NOT NULL,
+	CONSTRAINT pk_list_member PRIMARY KEY (list_id, user_id),
+	FOREIGN KEY (list_id) REFERENCES list(id),
+	FOREIGN KEY (user_id) REFERENCES user(id)
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS refresh_token (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	user_id BIGINT UNSIGNED NOT NULL,
+	family_id CHAR(32) NOT NULL,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	expires_at DATETIME NOT NULL,
+	revoked_at DATETIME,
+	replaced_by_id BIGINT UNSIGNED,
+	created_at DATETIME NOT NULL,
+	CONSTRAINT pk_refresh_token_id PRIMARY KEY (id),
+	INDEX idx_refresh_token_family (family_id),
+	FOREIGN KEY (user_id) REFERENCES user(id)
+);
+
+CREATE TABLE IF NOT EXISTS revoked_token (
+	jti CHAR(32) NOT NULL,
+	expires_at DATETIME NOT NULL,
+	CONSTRAINT pk_revoked_token_jti PRIMARY KEY (jti),
+	INDEX idx_revoked_token_expires_at (expires_at)
+);
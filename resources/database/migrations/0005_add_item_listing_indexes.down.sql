@@ -0,0 +1,3 @@
+DROP INDEX idx_item_list_user ON item;
+
+DROP INDEX idx_item_list_title ON item;
@@ -0,0 +1,3 @@
+CREATE INDEX idx_item_list_title ON item (list_id, title);
+
+CREATE INDEX idx_item_list_user ON item (list_id, user_id);
@@ -0,0 +1,5 @@
+DROP INDEX idx_list_created_at ON list;
+
+DROP INDEX idx_list_title ON list;
+
+ALTER TABLE list DROP COLUMN created_at;
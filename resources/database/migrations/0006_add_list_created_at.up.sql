@@ -0,0 +1,5 @@
+ALTER TABLE list ADD COLUMN created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6);
+
+CREATE INDEX idx_list_title ON list (title);
+
+CREATE INDEX idx_list_created_at ON list (created_at);
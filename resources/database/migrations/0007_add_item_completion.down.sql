@@ -0,0 +1,6 @@
+DROP INDEX idx_item_list_completed_at ON item;
+
+ALTER TABLE item
+	DROP FOREIGN KEY fk_item_completed_by,
+	DROP COLUMN completed_by,
+	DROP COLUMN completed_at;
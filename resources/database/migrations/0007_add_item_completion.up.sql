@@ -0,0 +1,6 @@
+ALTER TABLE item
+	ADD COLUMN completed_at DATETIME(6),
+	ADD COLUMN completed_by BIGINT UNSIGNED,
+	ADD CONSTRAINT fk_item_completed_by FOREIGN KEY (completed_by) REFERENCES user(id);
+
+CREATE INDEX idx_item_list_completed_at ON item (list_id, completed_at);
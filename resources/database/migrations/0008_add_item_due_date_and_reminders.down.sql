@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS item_reminder;
+
+DROP INDEX idx_item_list_due_at ON item;
+
+ALTER TABLE item
+	DROP COLUMN reminder_offsets,
+	DROP COLUMN due_timezone,
+	DROP COLUMN due_at;
@@ -0,0 +1,16 @@
+ALTER TABLE item
+	ADD COLUMN due_at DATETIME(6),
+	ADD COLUMN due_timezone VARCHAR(64),
+	ADD COLUMN reminder_offsets VARCHAR(255);
+
+CREATE INDEX idx_item_list_due_at ON item (list_id, due_at);
+
+CREATE TABLE IF NOT EXISTS item_reminder (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	item_id BIGINT UNSIGNED NOT NULL,
+	remind_at DATETIME(6) NOT NULL,
+	sent_at DATETIME(6),
+	CONSTRAINT pk_item_reminder_id PRIMARY KEY (id),
+	INDEX idx_item_reminder_pending (sent_at, remind_at),
+	FOREIGN KEY (item_id) REFERENCES item(id)
+);
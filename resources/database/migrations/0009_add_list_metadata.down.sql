@@ -0,0 +1,4 @@
+ALTER TABLE list
+	DROP COLUMN icon,
+	DROP COLUMN color,
+	DROP COLUMN description;
@@ -0,0 +1,4 @@
+ALTER TABLE list
+	ADD COLUMN description TEXT,
+	ADD COLUMN color CHAR(7),
+	ADD COLUMN icon VARCHAR(64);
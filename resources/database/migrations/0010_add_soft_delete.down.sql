@@ -0,0 +1,13 @@
+DROP INDEX idx_item_deleted_at ON item;
+DROP INDEX idx_list_deleted_at ON list;
+
+ALTER TABLE item
+	DROP FOREIGN KEY fk_item_deleted_by,
+	DROP COLUMN deleted_with_list,
+	DROP COLUMN deleted_by,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE list
+	DROP FOREIGN KEY fk_list_deleted_by,
+	DROP COLUMN deleted_by,
+	DROP COLUMN deleted_at;
@@ -0,0 +1,13 @@
+ALTER TABLE list
+	ADD COLUMN deleted_at DATETIME(6),
+	ADD COLUMN deleted_by BIGINT UNSIGNED,
+	ADD CONSTRAINT fk_list_deleted_by FOREIGN KEY (deleted_by) REFERENCES user(id);
+
+ALTER TABLE item
+	ADD COLUMN deleted_at DATETIME(6),
+	ADD COLUMN deleted_by BIGINT UNSIGNED,
+	ADD COLUMN deleted_with_list BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD CONSTRAINT fk_item_deleted_by FOREIGN KEY (deleted_by) REFERENCES user(id);
+
+CREATE INDEX idx_list_deleted_at ON list (deleted_at);
+CREATE INDEX idx_item_deleted_at ON item (deleted_at);
@@ -0,0 +1,4 @@
+DROP INDEX idx_item_list_position ON item;
+
+ALTER TABLE item
+	DROP COLUMN position;
@@ -0,0 +1,8 @@
+ALTER TABLE item
+	ADD COLUMN position VARCHAR(255) CHARACTER SET ascii COLLATE ascii_bin NOT NULL DEFAULT '';
+
+-- Existing items keep their creation order: the id in base 36, padded so the
+-- ranks compare like numbers and ending with a non zero digit.
+UPDATE item SET position = CONCAT(LOWER(LPAD(CONV(id, 10, 36), 13, '0')), 'i');
+
+CREATE INDEX idx_item_list_position ON item (list_id, position);
@@ -0,0 +1,4 @@
+ALTER TABLE item
+	DROP FOREIGN KEY fk_item_parent,
+	DROP COLUMN deleted_with_parent,
+	DROP COLUMN parent_id;
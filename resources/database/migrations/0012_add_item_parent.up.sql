@@ -0,0 +1,4 @@
+ALTER TABLE item
+	ADD COLUMN parent_id BIGINT UNSIGNED,
+	ADD COLUMN deleted_with_parent BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD CONSTRAINT fk_item_parent FOREIGN KEY (parent_id) REFERENCES item(id) ON DELETE SET NULL;
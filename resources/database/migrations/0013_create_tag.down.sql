@@ -0,0 +1,2 @@
+DROP TABLE item_tag;
+DROP TABLE tag;
@@ -0,0 +1,25 @@
+CREATE TABLE tag (
+	id BIGINT UNSIGNED AUTO_INCREMENT NOT NULL,
+	name VARCHAR(64) NOT NULL,
+	color CHAR(7),
+	user_id BIGINT UNSIGNED,
+	list_id BIGINT UNSIGNED,
+	created_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_tag_id PRIMARY KEY (id),
+	CONSTRAINT fk_tag_user_id FOREIGN KEY (user_id) REFERENCES user(id),
+	CONSTRAINT fk_tag_list_id FOREIGN KEY (list_id) REFERENCES list(id),
+	CONSTRAINT uq_tag_user_name UNIQUE (user_id, name),
+	CONSTRAINT uq_tag_list_name UNIQUE (list_id, name),
+	CONSTRAINT ck_tag_scope CHECK ((user_id IS NULL) <> (list_id IS NULL))
+);
+
+-- Links go away with their item or tag.
+CREATE TABLE item_tag (
+	item_id BIGINT UNSIGNED NOT NULL,
+	tag_id BIGINT UNSIGNED NOT NULL,
+	CONSTRAINT pk_item_tag PRIMARY KEY (item_id, tag_id),
+	CONSTRAINT fk_item_tag_item_id FOREIGN KEY (item_id) REFERENCES item(id) ON DELETE CASCADE,
+	CONSTRAINT fk_item_tag_tag_id FOREIGN KEY (tag_id) REFERENCES tag(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_item_tag_tag_id ON item_tag (tag_id, item_id);
@@ -0,0 +1,2 @@
+DROP TABLE item_field_value;
+DROP TABLE custom_field;
@@ -0,0 +1,31 @@
+CREATE TABLE custom_field (
+	id BIGINT UNSIGNED AUTO_INCREMENT NOT NULL,
+	list_id BIGINT UNSIGNED NOT NULL,
+	name VARCHAR(64) NOT NULL,
+	type VARCHAR(16) NOT NULL,
+	options TEXT,
+	created_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_custom_field_id PRIMARY KEY (id),
+	CONSTRAINT fk_custom_field_list_id FOREIGN KEY (list_id) REFERENCES list(id),
+	CONSTRAINT uq_custom_field_list_name UNIQUE (list_id, name)
+);
+
+-- Each value fills only the column of its field type, so values can be
+-- compared and sorted natively. Values go away with their item or field.
+CREATE TABLE item_field_value (
+	item_id BIGINT UNSIGNED NOT NULL,
+	field_id BIGINT UNSIGNED NOT NULL,
+	text_value VARCHAR(1024),
+	number_value DOUBLE,
+	date_value DATE,
+	bool_value BOOLEAN,
+	user_value BIGINT UNSIGNED,
+	CONSTRAINT pk_item_field_value PRIMARY KEY (item_id, field_id),
+	CONSTRAINT fk_item_field_value_item_id FOREIGN KEY (item_id) REFERENCES item(id) ON DELETE CASCADE,
+	CONSTRAINT fk_item_field_value_field_id FOREIGN KEY (field_id) REFERENCES custom_field(id) ON DELETE CASCADE,
+	CONSTRAINT fk_item_field_value_user_value FOREIGN KEY (user_value) REFERENCES user(id)
+);
+
+CREATE INDEX idx_item_field_value_text ON item_field_value (field_id, text_value);
+CREATE INDEX idx_item_field_value_number ON item_field_value (field_id, number_value);
+CREATE INDEX idx_item_field_value_date ON item_field_value (field_id, date_value);
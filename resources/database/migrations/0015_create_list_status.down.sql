@@ -0,0 +1,6 @@
+ALTER TABLE item
+	DROP FOREIGN KEY fk_item_status_id,
+	DROP COLUMN status_id;
+
+DROP TABLE status_transition;
+DROP TABLE list_status;
@@ -0,0 +1,27 @@
+CREATE TABLE list_status (
+	id BIGINT UNSIGNED AUTO_INCREMENT NOT NULL,
+	list_id BIGINT UNSIGNED NOT NULL,
+	name VARCHAR(64) NOT NULL,
+	position INT NOT NULL,
+	wip_limit INT,
+	done BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_list_status_id PRIMARY KEY (id),
+	CONSTRAINT fk_list_status_list_id FOREIGN KEY (list_id) REFERENCES list(id),
+	CONSTRAINT uq_list_status_list_name UNIQUE (list_id, name)
+);
+
+-- Transitions go away with either of their statuses.
+CREATE TABLE status_transition (
+	from_status_id BIGINT UNSIGNED NOT NULL,
+	to_status_id BIGINT UNSIGNED NOT NULL,
+	CONSTRAINT pk_status_transition PRIMARY KEY (from_status_id, to_status_id),
+	CONSTRAINT fk_status_transition_from FOREIGN KEY (from_status_id) REFERENCES list_status(id) ON DELETE CASCADE,
+	CONSTRAINT fk_status_transition_to FOREIGN KEY (to_status_id) REFERENCES list_status(id) ON DELETE CASCADE
+);
+
+ALTER TABLE item
+	ADD COLUMN status_id BIGINT UNSIGNED,
+	ADD CONSTRAINT fk_item_status_id FOREIGN KEY (status_id) REFERENCES list_status(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_item_status_position ON item (status_id, position);
@@ -0,0 +1,4 @@
+DROP TABLE item_occurrence;
+
+ALTER TABLE item
+	DROP COLUMN recurrence;
@@ -0,0 +1,15 @@
+ALTER TABLE item
+	ADD COLUMN recurrence VARCHAR(255);
+
+-- History of the completed occurrences of recurring items.
+CREATE TABLE item_occurrence (
+	id BIGINT UNSIGNED AUTO_INCREMENT NOT NULL,
+	item_id BIGINT UNSIGNED NOT NULL,
+	due_at DATETIME(6) NOT NULL,
+	completed_at DATETIME(6) NOT NULL,
+	completed_by BIGINT UNSIGNED,
+	CONSTRAINT pk_item_occurrence_id PRIMARY KEY (id),
+	CONSTRAINT fk_item_occurrence_item_id FOREIGN KEY (item_id) REFERENCES item(id) ON DELETE CASCADE,
+	CONSTRAINT fk_item_occurrence_completed_by FOREIGN KEY (completed_by) REFERENCES user(id),
+	INDEX idx_item_occurrence_item (item_id, completed_at)
+);
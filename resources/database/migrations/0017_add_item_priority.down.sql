@@ -0,0 +1,4 @@
+DROP INDEX idx_item_user_open ON item;
+
+ALTER TABLE item
+	DROP COLUMN priority;
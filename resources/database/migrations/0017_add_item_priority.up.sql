@@ -0,0 +1,4 @@
+ALTER TABLE item
+	ADD COLUMN priority TINYINT UNSIGNED NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_item_user_open ON item (user_id, completed_at, due_at);
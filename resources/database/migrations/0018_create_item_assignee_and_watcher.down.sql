@@ -0,0 +1,19 @@
+ALTER TABLE item
+	ADD COLUMN user_id BIGINT UNSIGNED;
+
+-- Items keep one of their assignees, or the owner of their list when they
+-- have none.
+UPDATE item SET user_id = (
+	SELECT MIN(item_assignee.user_id) FROM item_assignee WHERE item_assignee.item_id = item.id
+);
+UPDATE item JOIN list ON list.id = item.list_id SET item.user_id = list.user_id WHERE item.user_id IS NULL;
+
+ALTER TABLE item
+	MODIFY COLUMN user_id BIGINT UNSIGNED NOT NULL,
+	ADD CONSTRAINT item_ibfk_1 FOREIGN KEY (user_id) REFERENCES user(id);
+
+CREATE INDEX idx_item_list_user ON item (list_id, user_id);
+CREATE INDEX idx_item_user_open ON item (user_id, completed_at, due_at);
+
+DROP TABLE item_watcher;
+DROP TABLE item_assignee;
@@ -0,0 +1,31 @@
+CREATE TABLE item_assignee (
+	item_id BIGINT UNSIGNED NOT NULL,
+	user_id BIGINT UNSIGNED NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_item_assignee PRIMARY KEY (item_id, user_id),
+	CONSTRAINT fk_item_assignee_item_id FOREIGN KEY (item_id) REFERENCES item(id) ON DELETE CASCADE,
+	CONSTRAINT fk_item_assignee_user_id FOREIGN KEY (user_id) REFERENCES user(id),
+	INDEX idx_item_assignee_user (user_id, item_id)
+);
+
+CREATE TABLE item_watcher (
+	item_id BIGINT UNSIGNED NOT NULL,
+	user_id BIGINT UNSIGNED NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_item_watcher PRIMARY KEY (item_id, user_id),
+	CONSTRAINT fk_item_watcher_item_id FOREIGN KEY (item_id) REFERENCES item(id) ON DELETE CASCADE,
+	CONSTRAINT fk_item_watcher_user_id FOREIGN KEY (user_id) REFERENCES user(id),
+	INDEX idx_item_watcher_user (user_id, item_id)
+);
+
+-- The responsible user of each item becomes its first assignee.
+INSERT INTO item_assignee (item_id, user_id, created_at)
+SELECT id, user_id, NOW(6) FROM item;
+
+DROP INDEX idx_item_user_open ON item;
+DROP INDEX idx_item_list_user ON item;
+
+-- item_ibfk_1 is the unnamed foreign key of user_id created by 0001.
+ALTER TABLE item
+	DROP FOREIGN KEY item_ibfk_1,
+	DROP COLUMN user_id;
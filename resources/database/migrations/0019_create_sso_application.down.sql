@@ -0,0 +1,2 @@
+DROP TABLE sso_token_use;
+DROP TABLE sso_application;
@@ -0,0 +1,28 @@
+-- Applications allowed to log their users in through /authenticate/sso. HMAC
+-- applications have a secret, the others a public key in PEM.
+CREATE TABLE sso_application (
+	id BIGINT UNSIGNED AUTO_INCREMENT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	issuer VARCHAR(255) NOT NULL,
+	audience VARCHAR(255) NOT NULL,
+	algorithm VARCHAR(5) NOT NULL,
+	secret VARCHAR(255),
+	public_key TEXT,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at DATETIME(6) NOT NULL,
+	updated_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_sso_application_id PRIMARY KEY (id),
+	CONSTRAINT uq_sso_application_issuer UNIQUE (issuer),
+	CONSTRAINT ck_sso_application_key CHECK ((secret IS NULL) <> (public_key IS NULL))
+);
+
+-- The jti of the used SSO tokens, kept until they expire to reject replays.
+CREATE TABLE sso_token_use (
+	application_id BIGINT UNSIGNED NOT NULL,
+	jti VARCHAR(255) NOT NULL,
+	expires_at DATETIME NOT NULL,
+	CONSTRAINT pk_sso_token_use PRIMARY KEY (application_id, jti),
+	CONSTRAINT fk_sso_token_use_application_id FOREIGN KEY (application_id)
+		REFERENCES sso_application(id) ON DELETE CASCADE,
+	INDEX idx_sso_token_use_expires_at (expires_at)
+);
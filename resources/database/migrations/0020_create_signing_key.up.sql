@@ -0,0 +1,15 @@
+-- Key pairs that sign the access tokens when JWT_ALGORITHM is asymmetric.
+-- The private keys are shared by every instance of the application.
+CREATE TABLE signing_key (
+	id BIGINT UNSIGNED AUTO_INCREMENT NOT NULL,
+	kid VARCHAR(64) NOT NULL,
+	algorithm VARCHAR(5) NOT NULL,
+	private_key TEXT NOT NULL,
+	activates_at DATETIME(6) NOT NULL,
+	retires_at DATETIME(6) NOT NULL,
+	expires_at DATETIME(6) NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_signing_key_id PRIMARY KEY (id),
+	CONSTRAINT uq_signing_key_kid UNIQUE (kid),
+	INDEX idx_signing_key_expires_at (expires_at)
+);
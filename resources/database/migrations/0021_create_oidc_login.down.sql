@@ -0,0 +1,2 @@
+DROP TABLE user_identity;
+DROP TABLE oidc_login;
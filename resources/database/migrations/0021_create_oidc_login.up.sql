@@ -0,0 +1,24 @@
+-- OpenID Connect logins waiting for the provider callback, by the hash of
+-- their state. A login with user_id links the identity to that user.
+CREATE TABLE oidc_login (
+	state_hash CHAR(64) NOT NULL,
+	nonce VARCHAR(64) NOT NULL,
+	code_verifier VARCHAR(64) NOT NULL,
+	user_id BIGINT UNSIGNED,
+	expires_at DATETIME NOT NULL,
+	CONSTRAINT pk_oidc_login PRIMARY KEY (state_hash),
+	CONSTRAINT fk_oidc_login_user_id FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	INDEX idx_oidc_login_expires_at (expires_at)
+);
+
+-- The provider subjects linked to the users.
+CREATE TABLE user_identity (
+	id BIGINT UNSIGNED AUTO_INCREMENT NOT NULL,
+	user_id BIGINT UNSIGNED NOT NULL,
+	issuer VARCHAR(255) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_user_identity_id PRIMARY KEY (id),
+	CONSTRAINT uq_user_identity_subject UNIQUE (issuer, subject),
+	CONSTRAINT fk_user_identity_user_id FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
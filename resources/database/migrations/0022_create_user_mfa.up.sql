@@ -0,0 +1,33 @@
+-- The TOTP secret of the users with MFA, confirmed once a code is verified.
+CREATE TABLE user_mfa (
+	user_id BIGINT UNSIGNED NOT NULL,
+	secret VARCHAR(64) NOT NULL,
+	last_used_step BIGINT NOT NULL DEFAULT 0,
+	confirmed_at DATETIME(6),
+	created_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_user_mfa PRIMARY KEY (user_id),
+	CONSTRAINT fk_user_mfa_user_id FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+-- Single-use recovery codes, by their hash.
+CREATE TABLE mfa_recovery_code (
+	id BIGINT UNSIGNED AUTO_INCREMENT NOT NULL,
+	user_id BIGINT UNSIGNED NOT NULL,
+	code_hash CHAR(64) NOT NULL,
+	used_at DATETIME(6),
+	CONSTRAINT pk_mfa_recovery_code_id PRIMARY KEY (id),
+	CONSTRAINT uq_mfa_recovery_code UNIQUE (user_id, code_hash),
+	CONSTRAINT fk_mfa_recovery_code_user_id FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+-- Logins waiting for the second factor, by the hash of their token.
+CREATE TABLE mfa_challenge (
+	token_hash CHAR(64) NOT NULL,
+	user_id BIGINT UNSIGNED NOT NULL,
+	enrollment BOOLEAN NOT NULL DEFAULT FALSE,
+	attempts INT NOT NULL DEFAULT 0,
+	expires_at DATETIME NOT NULL,
+	CONSTRAINT pk_mfa_challenge PRIMARY KEY (token_hash),
+	CONSTRAINT fk_mfa_challenge_user_id FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	INDEX idx_mfa_challenge_expires_at (expires_at)
+);
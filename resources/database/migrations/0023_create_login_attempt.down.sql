@@ -0,0 +1,2 @@
+DROP TABLE auth_audit;
+DROP TABLE login_attempt;
@@ -0,0 +1,24 @@
+-- Failed logins by login and by IP, to slow down and lock password guessing.
+CREATE TABLE login_attempt (
+	attempt_key VARCHAR(300) NOT NULL,
+	failures INT NOT NULL DEFAULT 0,
+	last_failure_at DATETIME(6) NOT NULL,
+	locked_until DATETIME(6),
+	CONSTRAINT pk_login_attempt PRIMARY KEY (attempt_key),
+	INDEX idx_login_attempt_last_failure_at (last_failure_at)
+);
+
+-- Rejected logins, lockouts and unlocks.
+CREATE TABLE auth_audit (
+	id BIGINT UNSIGNED AUTO_INCREMENT NOT NULL,
+	event VARCHAR(20) NOT NULL,
+	login VARCHAR(255) NOT NULL,
+	user_id BIGINT UNSIGNED,
+	ip VARCHAR(45) NOT NULL DEFAULT '',
+	actor_id BIGINT UNSIGNED,
+	created_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_auth_audit_id PRIMARY KEY (id),
+	CONSTRAINT fk_auth_audit_user_id FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE SET NULL,
+	CONSTRAINT fk_auth_audit_actor_id FOREIGN KEY (actor_id) REFERENCES user(id) ON DELETE SET NULL,
+	INDEX idx_auth_audit_login (login, created_at)
+);
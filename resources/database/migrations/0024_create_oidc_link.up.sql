@@ -0,0 +1,12 @@
+-- Provider subjects waiting for the user to confirm the link to the account,
+-- by the hash of their token.
+CREATE TABLE oidc_link (
+	token_hash CHAR(64) NOT NULL,
+	user_id BIGINT UNSIGNED NOT NULL,
+	issuer VARCHAR(255) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	expires_at DATETIME NOT NULL,
+	CONSTRAINT pk_oidc_link PRIMARY KEY (token_hash),
+	CONSTRAINT fk_oidc_link_user_id FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	INDEX idx_oidc_link_expires_at (expires_at)
+);
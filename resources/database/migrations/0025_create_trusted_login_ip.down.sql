@@ -0,0 +1 @@
+DROP TABLE trusted_login_ip;
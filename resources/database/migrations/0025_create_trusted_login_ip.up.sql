@@ -0,0 +1,10 @@
+-- IPs the users logged in from, which are not locked by the failed logins of
+-- the user made elsewhere.
+CREATE TABLE trusted_login_ip (
+	user_id BIGINT UNSIGNED NOT NULL,
+	ip VARCHAR(45) NOT NULL,
+	last_login_at DATETIME(6) NOT NULL,
+	CONSTRAINT pk_trusted_login_ip PRIMARY KEY (user_id, ip),
+	CONSTRAINT fk_trusted_login_ip_user_id FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	INDEX idx_trusted_login_ip_last_login_at (last_login_at)
+);
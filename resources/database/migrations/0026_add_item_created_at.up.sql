@@ -0,0 +1 @@
+ALTER TABLE item ADD COLUMN created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6);
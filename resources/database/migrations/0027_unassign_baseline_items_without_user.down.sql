@@ -0,0 +1,4 @@
+CREATE TABLE baseline_item_without_user (
+	item_id BIGINT UNSIGNED NOT NULL,
+	CONSTRAINT pk_baseline_item_without_user PRIMARY KEY (item_id)
+);
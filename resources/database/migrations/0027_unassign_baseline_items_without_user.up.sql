@@ -0,0 +1,5 @@
+-- Items that had no responsible user before the migrations, see 0000.
+DELETE FROM item_assignee
+WHERE item_id IN (SELECT item_id FROM baseline_item_without_user);
+
+DROP TABLE baseline_item_without_user;